@@ -1,19 +1,40 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"flag"
 	"fmt"
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	"os"
 	"runtime"
+	"strings"
+	"time"
 
 	"github.com/epmd-edp/reconciler/v2/pkg/apis"
+	"github.com/epmd-edp/reconciler/v2/pkg/cache"
 	"github.com/epmd-edp/reconciler/v2/pkg/controller"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/outbox"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/resync"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/retention"
+	"github.com/epmd-edp/reconciler/v2/pkg/db"
+	"github.com/epmd-edp/reconciler/v2/pkg/leader"
+	"github.com/epmd-edp/reconciler/v2/pkg/message"
+	"github.com/epmd-edp/reconciler/v2/pkg/server"
+	"github.com/epmd-edp/reconciler/v2/pkg/service/migration"
+	outboxService "github.com/epmd-edp/reconciler/v2/pkg/service/outbox"
+	retentionService "github.com/epmd-edp/reconciler/v2/pkg/service/retention"
 
 	"github.com/operator-framework/operator-sdk/pkg/log/zap"
 	sdkVersion "github.com/operator-framework/operator-sdk/version"
 	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
@@ -22,6 +43,9 @@ import (
 
 var log = logf.Log.WithName("cmd")
 
+// pingTimeout limits DB ping of health probes, it should fit probe timeout.
+const pingTimeout = 3 * time.Second
+
 func printVersion() {
 	log.Info(fmt.Sprintf("Go Version: %s", runtime.Version()))
 	log.Info(fmt.Sprintf("Go OS/Arch: %s/%s", runtime.GOOS, runtime.GOARCH))
@@ -37,6 +61,50 @@ func main() {
 	// controller-runtime)
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 
+	dbConfig, err := db.ConfigFromEnv()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	dbConfig.BindFlags(pflag.CommandLine)
+	dbConfigDir := pflag.String("db-config-dir", "",
+		"Directory with files named after DB_* variables, e.g. mounted secret, which override other DB settings")
+
+	leaderOptions := leader.DefaultOptions()
+	leaderOptions.BindFlags(pflag.CommandLine)
+
+	resyncOnStartup := pflag.Bool("resync-on-startup", false,
+		"Rebuild DB projection from all custom resources once the reconciler is started")
+	resyncPeriod := pflag.Duration("resync-period", 0,
+		"Period of full DB projection rebuild, zero disables periodic resync")
+	httpAddr := pflag.String("http-bind-address", ":8081",
+		"The address reconciler HTTP endpoints, i.e. health probes, metrics and drift report, bind to")
+	driftPeriod := pflag.Duration("drift-period", 0,
+		"Period of comparing DB with custom resources, zero disables drift detection")
+	driftCollect := pflag.Bool("drift-collect-orphans", false,
+		"Remove DB rows which have no custom resources found by drift detection")
+	retentionPeriod := pflag.Duration("action-log-retention-period", 0,
+		"Period of pruning action logs, zero disables pruning")
+	retentionKeepLast := pflag.Int("action-log-keep-last", 0,
+		"Number of the latest action logs kept per codebase, branch, CD pipeline and stage, zero keeps all")
+	retentionMaxAge := pflag.Duration("action-log-max-age", 0,
+		"Prune action logs older than the age, zero keeps all")
+	retentionArchive := pflag.String("action-log-archive", "",
+		"Where pruned action logs are archived to: \"table\", \"file\" or nowhere if empty")
+	retentionArchiveDir := pflag.String("action-log-archive-dir", "",
+		"Directory action logs are archived to as gzip compressed JSON lines")
+	outboxSink := pflag.String("outbox-sink", "",
+		"URL events of DB changes are published to as CloudEvents: http(s) webhook or file, e.g. file:///tmp/events.jsonl. "+
+			"Events are kept in the outbox table until published")
+	outboxSource := pflag.String("outbox-source", outboxService.DefaultSource, "Source attribute of published events")
+	outboxPeriod := pflag.Duration("outbox-period", 5*time.Second, "Period of publishing outbox events")
+	outboxBatchSize := pflag.Int("outbox-batch-size", outboxService.DefaultBatchSize,
+		"Number of outbox events delivered in a single transaction")
+	messageLocale := pflag.String("message-locale", message.English,
+		"Locale of action messages, either built-in (\"en\", \"uk\") or provided by the message config map")
+	messageConfigMap := pflag.String("message-config-map", "",
+		"Config map overriding action message templates, \"name\" in the operator namespace or \"namespace/name\"")
+
 	pflag.Parse()
 
 	// Use a zap logr.Logger implementation. If none of the zap
@@ -51,11 +119,14 @@ func main() {
 
 	printVersion()
 
-	namespace, err := k8sutil.GetWatchNamespace()
+	// WATCH_NAMESPACE is a comma separated list, empty one means all namespaces
+	watchNamespace, err := k8sutil.GetWatchNamespace()
 	if err != nil {
 		log.Error(err, "Failed to get watch namespace")
 		os.Exit(1)
 	}
+	namespaces := cache.ParseNamespaces(watchNamespace)
+	log.Info("Watching namespaces", "namespaces", namespaces)
 
 	// Get a config to talk to the apiserver
 	cfg, err := config.GetConfig()
@@ -64,13 +135,48 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *dbConfigDir != "" {
+		if err := dbConfig.LoadDir(*dbConfigDir); err != nil {
+			log.Error(err, "Failed to read DB config")
+			os.Exit(1)
+		}
+	}
+
+	conn, err := db.Open(dbConfig)
+	if err != nil {
+		log.Error(err, "Failed to connect to DB")
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	// Upgrade tenant schemas before any controller writes into them. Schemas
+	// which fail here are migrated again once tenant controller reconciles them.
+	if err := migrateTenants(cfg, conn, namespaces); err != nil {
+		log.Error(err, "Failed to migrate tenant schemas")
+	}
+
+	if *messageConfigMap != "" {
+		if err := loadMessages(cfg, *messageConfigMap); err != nil {
+			log.Error(err, "Failed to load action message templates", "configMap", *messageConfigMap)
+		}
+	}
+	if err := message.Default().SetLocale(*messageLocale); err != nil {
+		log.Error(err, "Failed to set action message locale, English is used")
+	}
+
 	// Create a new Cmd to provide shared dependencies and start components
-	mgr, err := manager.New(cfg, manager.Options{Namespace: namespace})
+	mgr, err := manager.New(cfg, cache.ManagerOptions(namespaces))
 	if err != nil {
 		log.Error(err, "")
 		os.Exit(1)
 	}
 
+	tenants := helper.NewTenants(mgr.GetClient(), mgr.GetRecorder("reconciler-tenants"))
+	if err := tenants.Watch(mgr.GetCache()); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
 	log.Info("Registering Components.")
 	// Setup Scheme for all resources
 	if err := apis.AddToScheme(mgr.GetScheme()); err != nil {
@@ -79,16 +185,148 @@ func main() {
 	}
 
 	// Setup all Controllers
-	if err := controller.AddToManager(mgr); err != nil {
+	if err := controller.AddToManager(mgr, conn, tenants); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
+	if err := resync.Add(mgr, conn, tenants, resync.Options{OnStartup: *resyncOnStartup, Period: *resyncPeriod}); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
+	err = retention.Add(mgr, conn, tenants, retention.Options{
+		Period: *retentionPeriod,
+		Policy: retentionService.Policy{
+			KeepLast: *retentionKeepLast,
+			MaxAge:   *retentionMaxAge,
+		},
+		Archive:    *retentionArchive,
+		ArchiveDir: *retentionArchiveDir,
+	})
+	if err != nil {
 		log.Error(err, "")
 		os.Exit(1)
 	}
 
+	err = outbox.Add(mgr, conn, tenants, outbox.Options{
+		Sink:      *outboxSink,
+		Source:    *outboxSource,
+		Period:    *outboxPeriod,
+		BatchSize: *outboxBatchSize,
+	})
+	if err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
+	srv := server.New(*httpAddr)
+	dbCheck := db.PingCheck(conn, pingTimeout)
+	cacheSync := server.NewCacheSync(mgr.GetCache())
+	srv.AddHealthCheck("db", dbCheck)
+	srv.AddReadyCheck("db", dbCheck)
+	if err := mgr.Add(cacheSync); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
+	err = resync.AddDriftDetector(mgr, srv, conn, tenants, resync.DriftOptions{Period: *driftPeriod, CollectOrphan: *driftCollect})
+	if err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
+	run := mgr.Start
+	if leaderOptions.Enabled {
+		if leaderOptions.LeaseNamespace == "" {
+			if leaderOptions.LeaseNamespace, err = k8sutil.GetOperatorNamespace(); err != nil {
+				log.Error(err, "Failed to get lease namespace, set it explicitly")
+				os.Exit(1)
+			}
+		}
+		elector, err := leader.New(cfg, leaderOptions, mgr.GetRecorder("reconciler-leader-election"), mgr.Start)
+		if err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+		srv.AddHealthCheck("leader-election", elector.Check)
+		// Standby replicas don't start the cache, they are ready to take
+		// over the lease as long as DB is reachable.
+		srv.AddReadyCheck("cache", func() error {
+			if !elector.IsLeader() {
+				return nil
+			}
+			return cacheSync.Check()
+		})
+		run = elector.Start
+	} else {
+		srv.AddReadyCheck("cache", cacheSync.Check)
+	}
+
+	stop := signals.SetupSignalHandler()
+
+	// HTTP server runs regardless of leadership to serve probes and metrics.
+	go func() {
+		if err := srv.Start(stop); err != nil {
+			log.Error(err, "HTTP server exited non-zero")
+			os.Exit(1)
+		}
+	}()
+
 	log.Info("Starting the Cmd.")
 
 	// Start the Cmd
-	if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
+	if err := run(stop); err != nil {
 		log.Error(err, "Manager exited non-zero")
 		os.Exit(1)
 	}
 }
+
+// migrateTenants applies pending schema migrations to the tenants described
+// by edp-config config maps of the watched namespaces.
+func migrateTenants(cfg *rest.Config, conn *sql.DB, namespaces []string) error {
+	c, err := client.New(cfg, client.Options{})
+	if err != nil {
+		return err
+	}
+
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	var tenants []string
+	for _, ns := range namespaces {
+		l := &corev1.ConfigMapList{}
+		opts := (&client.ListOptions{}).InNamespace(ns).MatchingField("metadata.name", helper.EDPConfigCM)
+		if err := c.List(context.TODO(), opts, l); err != nil {
+			return err
+		}
+		for _, cm := range l.Items {
+			if edpN := cm.Data[helper.EDPNameKey]; edpN != "" {
+				tenants = append(tenants, edpN)
+			}
+		}
+	}
+
+	return migration.MigrationService{DB: conn}.MigrateTenants(tenants)
+}
+
+// loadMessages overrides action message templates by the config map given as
+// "name" in the operator namespace or "namespace/name".
+func loadMessages(cfg *rest.Config, cm string) error {
+	key := types.NamespacedName{Name: cm}
+	if i := strings.Index(cm, "/"); i >= 0 {
+		key.Namespace, key.Name = cm[:i], cm[i+1:]
+	} else {
+		ns, err := k8sutil.GetOperatorNamespace()
+		if err != nil {
+			return err
+		}
+		key.Namespace = ns
+	}
+
+	c, err := client.New(cfg, client.Options{})
+	if err != nil {
+		return err
+	}
+	return message.Default().LoadConfigMap(c, key)
+}
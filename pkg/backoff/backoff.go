@@ -0,0 +1,229 @@
+// Package backoff classifies reconciliation errors and decides when a failed
+// request is reconciled again. Failed attempts are counted per class of
+// error. Requests failing more times than their class allows are
+// dead-lettered: they aren't requeued until the generation of the custom
+// resource changes, and they are counted in metrics.
+package backoff
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/metrics"
+	"github.com/lib/pq"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+var log = logf.Log.WithName("backoff")
+
+// Class is the kind of failure which defines how the request is retried.
+type Class string
+
+const (
+	// Transient errors, e.g. lost DB connection, go away by themselves.
+	Transient Class = "transient"
+	// Dependency errors are caused by entities which haven't been projected
+	// yet, e.g. a stage whose previous stage hasn't been added.
+	Dependency Class = "dependency"
+	// Permanent errors, e.g. invalid custom resources, are fixed only by
+	// changing the custom resource, so they aren't retried.
+	Permanent Class = "permanent"
+)
+
+type classified struct {
+	class Class
+	err   error
+}
+
+func (c classified) Error() string {
+	return c.err.Error()
+}
+
+func (c classified) Cause() error {
+	return c.err
+}
+
+// AsDependency marks the error as caused by a missing dependency.
+func AsDependency(err error) error {
+	return classified{class: Dependency, err: err}
+}
+
+// AsPermanent marks the error as the one retrying doesn't fix.
+func AsPermanent(err error) error {
+	return classified{class: Permanent, err: err}
+}
+
+// Classify returns class the error has been marked with, otherwise the class
+// is defined by the cause of the error. Unknown errors are transient.
+func Classify(err error) Class {
+	for err != nil {
+		if c, ok := err.(classified); ok {
+			return c.class
+		}
+		causer, ok := err.(interface{ Cause() error })
+		if !ok {
+			break
+		}
+		err = causer.Cause()
+	}
+
+	switch e := err.(type) {
+	case *pq.Error:
+		return classifyPq(e)
+	case pq.Error:
+		return classifyPq(&e)
+	}
+	if k8serrors.IsNotFound(err) {
+		return Dependency
+	}
+	if k8serrors.IsInvalid(err) || k8serrors.IsBadRequest(err) {
+		return Permanent
+	}
+	return Transient
+}
+
+// classifyPq treats integrity violations, e.g. foreign key one, as missing
+// dependency and data exceptions as permanent errors.
+func classifyPq(err *pq.Error) Class {
+	switch err.Code.Class() {
+	case "23":
+		return Dependency
+	case "22":
+		return Permanent
+	}
+	return Transient
+}
+
+// Policy defines retries of a class of errors.
+type Policy struct {
+	// Base is the delay before the first retry, it doubles with every attempt.
+	Base time.Duration
+	Max  time.Duration
+	// MaxAttempts is the number of failed attempts before the request is
+	// dead-lettered.
+	MaxAttempts int
+}
+
+// DefaultPolicies retry transient errors for about an hour and missing
+// dependencies for about as long, permanent errors are dead-lettered at once.
+var DefaultPolicies = map[Class]Policy{
+	Transient:  {Base: time.Second, Max: 5 * time.Minute, MaxAttempts: 20},
+	Dependency: {Base: 2 * time.Second, Max: 2 * time.Minute, MaxAttempts: 40},
+	Permanent:  {},
+}
+
+// Delay returns delay before the attempt with equal jitter: half of
+// the exponential delay is fixed, the other half is random.
+func (p Policy) Delay(attempt int, random func(n int64) int64) time.Duration {
+	d := p.Base
+	for i := 1; i < attempt && d < p.Max; i++ {
+		d *= 2
+	}
+	if d > p.Max {
+		d = p.Max
+	}
+	half := int64(d / 2)
+	return time.Duration(half + random(int64(d)-half+1))
+}
+
+// attempt identifies failed attempts of a request with errors of a class.
+type attempt struct {
+	name  types.NamespacedName
+	class Class
+}
+
+// Requeuer keeps failed attempts of the requests of a controller.
+type Requeuer struct {
+	controller string
+	policies   map[Class]Policy
+
+	mu          sync.Mutex
+	attempts    map[attempt]int
+	dead        map[types.NamespacedName]bool
+	generations map[types.NamespacedName]int64
+	random      func(n int64) int64
+}
+
+func New(controller string) *Requeuer {
+	return NewWithPolicies(controller, DefaultPolicies)
+}
+
+func NewWithPolicies(controller string, policies map[Class]Policy) *Requeuer {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return &Requeuer{
+		controller:  controller,
+		policies:    policies,
+		attempts:    map[attempt]int{},
+		dead:        map[types.NamespacedName]bool{},
+		generations: map[types.NamespacedName]int64{},
+		random:      rnd.Int63n,
+	}
+}
+
+// Failed records the failure of the request and returns when it should be
+// reconciled again. Reconcilers return the result with nil error, otherwise
+// controller-runtime ignores the delay.
+func (r *Requeuer) Failed(req reconcile.Request, err error) reconcile.Result {
+	class := Classify(err)
+	metrics.ReconcileError(r.controller, err)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a := attempt{name: req.NamespacedName, class: class}
+	n := r.attempts[a] + 1
+	r.attempts[a] = n
+
+	p := r.policies[class]
+	if n > p.MaxAttempts {
+		if !r.dead[req.NamespacedName] {
+			r.dead[req.NamespacedName] = true
+			metrics.DeadLettered(r.controller, string(class))
+		}
+		log.Error(err, "Request has been dead-lettered until the custom resource changes",
+			"controller", r.controller, "request", req.NamespacedName, "class", class, "attempts", n)
+		return reconcile.Result{}
+	}
+
+	d := p.Delay(n, r.random)
+	metrics.Requeued(r.controller, string(class))
+	log.Error(err, "Reconciling has failed", "controller", r.controller, "request", req.NamespacedName,
+		"class", class, "attempt", n, "requeueAfter", d.String())
+	return reconcile.Result{RequeueAfter: d}
+}
+
+// Observe records generation of the custom resource of the request and
+// resets its failures once the generation changes, so a dead-lettered
+// request is retried after the custom resource has been changed.
+func (r *Requeuer) Observe(req reconcile.Request, generation int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if g, ok := r.generations[req.NamespacedName]; ok && g != generation {
+		r.reset(req.NamespacedName)
+	}
+	r.generations[req.NamespacedName] = generation
+}
+
+// Forget resets failures of the request once it has been reconciled or
+// the custom resource has gone.
+func (r *Requeuer) Forget(req reconcile.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reset(req.NamespacedName)
+	delete(r.generations, req.NamespacedName)
+}
+
+func (r *Requeuer) reset(name types.NamespacedName) {
+	for a := range r.attempts {
+		if a.name == name {
+			delete(r.attempts, a)
+		}
+	}
+	if r.dead[name] {
+		delete(r.dead, name)
+		metrics.Revived(r.controller)
+	}
+}
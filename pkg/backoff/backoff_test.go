@@ -0,0 +1,116 @@
+package backoff
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	pkgErrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func noJitter(n int64) int64 {
+	return n - 1
+}
+
+func TestClassify(t *testing.T) {
+	gr := schema.GroupResource{Group: "v2.edp.epam.com", Resource: "stages"}
+	tests := []struct {
+		name string
+		err  error
+		want Class
+	}{
+		{"unknown", errors.New("fake error"), Transient},
+		{"db", pkgErrors.Wrap(&pq.Error{Code: "08006"}, "fake"), Transient},
+		{"foreign key", pkgErrors.Wrap(&pq.Error{Code: "23503"}, "fake"), Dependency},
+		{"invalid data", &pq.Error{Code: "22P02"}, Permanent},
+		{"k8s not found", k8serrors.NewNotFound(gr, "fake-stage"), Dependency},
+		{"marked dependency", pkgErrors.Wrap(AsDependency(fmt.Errorf("fake error")), "fake"), Dependency},
+		{"marked permanent", AsPermanent(pkgErrors.Wrap(&pq.Error{Code: "08006"}, "fake")), Permanent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Classify(tt.err))
+		})
+	}
+}
+
+func TestPolicy_DelayShouldGrowUpToMax(t *testing.T) {
+	p := Policy{Base: time.Second, Max: 5 * time.Second}
+
+	assert.Equal(t, time.Second, p.Delay(1, noJitter))
+	assert.Equal(t, 2*time.Second, p.Delay(2, noJitter))
+	assert.Equal(t, 4*time.Second, p.Delay(3, noJitter))
+	assert.Equal(t, 5*time.Second, p.Delay(4, noJitter))
+	assert.Equal(t, 5*time.Second, p.Delay(100, noJitter))
+	assert.Equal(t, 2*time.Second, p.Delay(3, func(int64) int64 { return 0 }))
+}
+
+func TestRequeuer_ShouldDeadLetterAfterMaxAttempts(t *testing.T) {
+	r := NewWithPolicies("fake-controller", map[Class]Policy{
+		Transient: {Base: time.Second, Max: time.Minute, MaxAttempts: 2},
+	})
+	r.random = noJitter
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "fake-ns", Name: "fake-name"}}
+	err := errors.New("fake error")
+
+	assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, r.Failed(req, err))
+	assert.Equal(t, reconcile.Result{RequeueAfter: 2 * time.Second}, r.Failed(req, err))
+	assert.Equal(t, reconcile.Result{}, r.Failed(req, err))
+	assert.True(t, r.dead[req.NamespacedName])
+
+	r.Forget(req)
+	assert.False(t, r.dead[req.NamespacedName])
+	assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, r.Failed(req, err))
+}
+
+func TestRequeuer_ShouldNotRequeuePermanentErrors(t *testing.T) {
+	r := New("fake-controller")
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "fake-ns", Name: "fake-name"}}
+
+	assert.Equal(t, reconcile.Result{}, r.Failed(req, AsPermanent(errors.New("fake error"))))
+	assert.True(t, r.dead[req.NamespacedName])
+}
+
+func TestRequeuer_ShouldCountAttemptsPerClass(t *testing.T) {
+	r := NewWithPolicies("fake-controller", map[Class]Policy{
+		Transient:  {Base: time.Second, Max: time.Minute, MaxAttempts: 1},
+		Dependency: {Base: time.Second, Max: time.Minute, MaxAttempts: 2},
+	})
+	r.random = noJitter
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "fake-ns", Name: "fake-name"}}
+	dependencyErr := AsDependency(errors.New("fake error"))
+
+	assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, r.Failed(req, dependencyErr))
+	assert.Equal(t, reconcile.Result{RequeueAfter: 2 * time.Second}, r.Failed(req, dependencyErr))
+	assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, r.Failed(req, errors.New("fake error")))
+	assert.False(t, r.dead[req.NamespacedName])
+}
+
+func TestRequeuer_ShouldRetryDeadLetteredRequestOnceGenerationChanges(t *testing.T) {
+	r := NewWithPolicies("fake-controller", map[Class]Policy{
+		Transient: {Base: time.Second, Max: time.Minute, MaxAttempts: 1},
+	})
+	r.random = noJitter
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "fake-ns", Name: "fake-name"}}
+	err := errors.New("fake error")
+
+	r.Observe(req, 1)
+	assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, r.Failed(req, err))
+	r.Observe(req, 1)
+	assert.Equal(t, reconcile.Result{}, r.Failed(req, err))
+	assert.True(t, r.dead[req.NamespacedName])
+
+	r.Observe(req, 1)
+	assert.True(t, r.dead[req.NamespacedName])
+
+	r.Observe(req, 2)
+	assert.False(t, r.dead[req.NamespacedName])
+	assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, r.Failed(req, err))
+}
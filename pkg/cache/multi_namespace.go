@@ -0,0 +1,195 @@
+// Package cache implements informer cache restricted to a list of namespaces,
+// which controller-runtime manager doesn't support out of the box.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	crcache "sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// ParseNamespaces splits comma separated list of namespaces. Empty list
+// means all namespaces.
+func ParseNamespaces(s string) []string {
+	var ns []string
+	for _, n := range strings.Split(s, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			ns = append(ns, n)
+		}
+	}
+	return ns
+}
+
+// ManagerOptions returns manager options which restrict its cache to
+// the namespaces.
+func ManagerOptions(namespaces []string) manager.Options {
+	if len(namespaces) <= 1 {
+		return manager.Options{Namespace: strings.Join(namespaces, "")}
+	}
+	return manager.Options{NewCache: MultiNamespacedCacheBuilder(namespaces)}
+}
+
+// MultiNamespacedCacheBuilder returns function creating cache which keeps
+// a namespaced cache per each of the namespaces.
+func MultiNamespacedCacheBuilder(namespaces []string) manager.NewCacheFunc {
+	return func(config *rest.Config, opts crcache.Options) (crcache.Cache, error) {
+		caches := make(map[string]crcache.Cache, len(namespaces))
+		for _, ns := range namespaces {
+			opts.Namespace = ns
+			c, err := crcache.New(config, opts)
+			if err != nil {
+				return nil, err
+			}
+			caches[ns] = c
+		}
+		return &multiNamespaceCache{caches: caches}, nil
+	}
+}
+
+type multiNamespaceCache struct {
+	caches map[string]crcache.Cache
+}
+
+var _ crcache.Cache = &multiNamespaceCache{}
+
+func (c *multiNamespaceCache) GetInformer(obj runtime.Object) (toolscache.SharedIndexInformer, error) {
+	return c.informer(func(nc crcache.Cache) (toolscache.SharedIndexInformer, error) {
+		return nc.GetInformer(obj)
+	})
+}
+
+func (c *multiNamespaceCache) GetInformerForKind(gvk schema.GroupVersionKind) (toolscache.SharedIndexInformer, error) {
+	return c.informer(func(nc crcache.Cache) (toolscache.SharedIndexInformer, error) {
+		return nc.GetInformerForKind(gvk)
+	})
+}
+
+func (c *multiNamespaceCache) informer(get func(crcache.Cache) (toolscache.SharedIndexInformer, error)) (toolscache.SharedIndexInformer, error) {
+	mi := &multiNamespaceInformer{informers: make(map[string]toolscache.SharedIndexInformer, len(c.caches))}
+	for ns, nc := range c.caches {
+		i, err := get(nc)
+		if err != nil {
+			return nil, err
+		}
+		mi.informers[ns] = i
+		mi.SharedIndexInformer = i
+	}
+	return mi, nil
+}
+
+func (c *multiNamespaceCache) Start(stop <-chan struct{}) error {
+	errCh := make(chan error, len(c.caches))
+	for ns, nc := range c.caches {
+		go func(ns string, nc crcache.Cache) {
+			if err := nc.Start(stop); err != nil {
+				errCh <- fmt.Errorf("cache of %v namespace has failed: %v", ns, err)
+			}
+		}(ns, nc)
+	}
+	select {
+	case <-stop:
+		return nil
+	case err := <-errCh:
+		return err
+	}
+}
+
+func (c *multiNamespaceCache) WaitForCacheSync(stop <-chan struct{}) bool {
+	synced := true
+	for _, nc := range c.caches {
+		if !nc.WaitForCacheSync(stop) {
+			synced = false
+		}
+	}
+	return synced
+}
+
+func (c *multiNamespaceCache) IndexField(obj runtime.Object, field string, extractValue client.IndexerFunc) error {
+	for _, nc := range c.caches {
+		if err := nc.IndexField(obj, field, extractValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *multiNamespaceCache) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	nc, ok := c.caches[key.Namespace]
+	if !ok {
+		return fmt.Errorf("unable to get %v: namespace %v is not watched", key, key.Namespace)
+	}
+	return nc.Get(ctx, key, obj)
+}
+
+// List lists objects of the namespace from options or of all the
+// namespaces if it isn't set.
+func (c *multiNamespaceCache) List(ctx context.Context, opts *client.ListOptions, list runtime.Object) error {
+	if opts != nil && opts.Namespace != "" {
+		nc, ok := c.caches[opts.Namespace]
+		if !ok {
+			return fmt.Errorf("unable to list: namespace %v is not watched", opts.Namespace)
+		}
+		return nc.List(ctx, opts, list)
+	}
+
+	var items []runtime.Object
+	for _, nc := range c.caches {
+		l := list.DeepCopyObject()
+		if err := nc.List(ctx, opts, l); err != nil {
+			return err
+		}
+		objs, err := meta.ExtractList(l)
+		if err != nil {
+			return err
+		}
+		items = append(items, objs...)
+	}
+	return meta.SetList(list, items)
+}
+
+// multiNamespaceInformer fans event handlers out to informers of every
+// namespace. The rest of the methods are served by one of the informers.
+type multiNamespaceInformer struct {
+	toolscache.SharedIndexInformer
+	informers map[string]toolscache.SharedIndexInformer
+}
+
+func (i *multiNamespaceInformer) AddEventHandler(handler toolscache.ResourceEventHandler) {
+	for _, ni := range i.informers {
+		ni.AddEventHandler(handler)
+	}
+}
+
+func (i *multiNamespaceInformer) AddEventHandlerWithResyncPeriod(handler toolscache.ResourceEventHandler, resyncPeriod time.Duration) {
+	for _, ni := range i.informers {
+		ni.AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+	}
+}
+
+func (i *multiNamespaceInformer) AddIndexers(indexers toolscache.Indexers) error {
+	for _, ni := range i.informers {
+		if err := ni.AddIndexers(indexers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *multiNamespaceInformer) HasSynced() bool {
+	for _, ni := range i.informers {
+		if !ni.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
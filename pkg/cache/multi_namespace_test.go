@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	crcache "sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// readerCache serves reads of a single namespace by the fake client.
+type readerCache struct {
+	crcache.Cache
+	client.Client
+}
+
+func (c readerCache) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c readerCache) List(ctx context.Context, opts *client.ListOptions, list runtime.Object) error {
+	return c.Client.List(ctx, opts, list)
+}
+
+func configMap(ns, name string) *coreV1.ConfigMap {
+	return &coreV1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{Name: name, Namespace: ns}}
+}
+
+func TestParseNamespaces(t *testing.T) {
+	assert.Equal(t, []string{"team-a", "team-b"}, ParseNamespaces(" team-a,team-b,"))
+	assert.Empty(t, ParseNamespaces(""))
+}
+
+func TestMultiNamespaceCache_ListsAllNamespaces(t *testing.T) {
+	c := &multiNamespaceCache{caches: map[string]crcache.Cache{
+		"team-a": readerCache{Client: fake.NewFakeClient(configMap("team-a", "edp-config"))},
+		"team-b": readerCache{Client: fake.NewFakeClient(configMap("team-b", "edp-config"))},
+	}}
+
+	l := &coreV1.ConfigMapList{}
+	assert.NoError(t, c.List(context.TODO(), &client.ListOptions{}, l))
+	assert.Len(t, l.Items, 2)
+
+	l = &coreV1.ConfigMapList{}
+	assert.NoError(t, c.List(context.TODO(), &client.ListOptions{Namespace: "team-b"}, l))
+	assert.Len(t, l.Items, 1)
+
+	cm := &coreV1.ConfigMap{}
+	assert.NoError(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "team-a", Name: "edp-config"}, cm))
+	assert.Error(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "team-c", Name: "edp-config"}, cm))
+}
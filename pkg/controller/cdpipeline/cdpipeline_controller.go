@@ -2,19 +2,23 @@ package cdpipeline
 
 import (
 	"context"
+	"database/sql"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/dependency"
 	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
-	"github.com/epmd-edp/reconciler/v2/pkg/db"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/resync"
+	"github.com/epmd-edp/reconciler/v2/pkg/metrics"
 	"github.com/epmd-edp/reconciler/v2/pkg/model/cdpipeline"
 	"github.com/epmd-edp/reconciler/v2/pkg/platform"
 	"github.com/epmd-edp/reconciler/v2/pkg/service/cd-pipeline"
-	"github.com/epmd-edp/reconciler/v2/pkg/service/thirdpartyservice"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
 	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
-	"time"
 
 	edpv1alpha1 "github.com/epmd-edp/cd-pipeline-operator/v2/pkg/apis/edp/v1alpha1"
 
+	errWrap "github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -33,32 +37,38 @@ var log = logf.Log.WithName("controller_cdpipeline")
 * business logic.  Delete these comments after modifying this file.*
  */
 
+const controllerName = "cdpipeline-controller"
+
 // Add creates a new CDPipeline Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) error {
+	return add(mgr, newReconciler(mgr, db, tenants))
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) reconcile.Reconciler {
 	clientSet, err := platform.CreateOpenshiftClients()
 	if err != nil {
 		panic(err)
 	}
 	cdpService := cd_pipeline.CdPipelineService{
-		DB:        db.Instance,
+		Storage:   postgres.New(db),
 		ClientSet: *clientSet,
-		ThirdPartyService: thirdpartyservice.ThirdPartyService{
-			DB: db.Instance,
-		},
 	}
-	return &ReconcileCDPipeline{client: mgr.GetClient(), scheme: mgr.GetScheme(), cdpService: cdpService}
+	return &ReconcileCDPipeline{
+		client:     mgr.GetClient(),
+		tenants:    tenants,
+		scheme:     mgr.GetScheme(),
+		sync:       helper.NewSyncReporter(mgr.GetClient(), mgr.GetRecorder(controllerName)),
+		requeue:    backoff.New(controllerName),
+		cdpService: cdpService,
+	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("cdpipeline-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: metrics.Instrument(controllerName, r)})
 	if err != nil {
 		return err
 	}
@@ -89,6 +99,16 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	err = c.Watch(resync.Source(resync.CDPipeline), &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	err = dependency.Watch(mgr, c, dependency.CDPipelines)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -101,7 +121,10 @@ type ReconcileCDPipeline struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
 	client     client.Client
+	tenants    *helper.Tenants
 	scheme     *runtime.Scheme
+	sync       helper.SyncReporter
+	requeue    *backoff.Requeuer
 	cdpService cd_pipeline.CdPipelineService
 }
 
@@ -122,35 +145,47 @@ func (r *ReconcileCDPipeline) Reconcile(request reconcile.Request) (reconcile.Re
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			r.requeue.Forget(request)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
+	r.requeue.Observe(request, instance.GetGeneration())
 
 	reqLogger.Info("CD pipeline has been retrieved", "cd pipeline", instance)
 
-	edpN, err := helper.GetEDPName(r.client, instance.Namespace)
+	edpN, err := r.tenants.GetEDPName(instance.Namespace)
 	if err != nil {
-		reqLogger.Error(err, "cannot get edp name")
-		return reconcile.Result{RequeueAfter: 2 * time.Second}, nil
+		if helper.IsTenantNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return r.requeue.Failed(request, errWrap.Wrap(err, "cannot get edp name")), nil
 	}
 
-	if res, err := r.tryToDeleteCDPipeline(instance, *edpN); err != nil || res != nil {
-		return *res, err
+	res, err := r.tryToDeleteCDPipeline(instance, *edpN)
+	if err != nil {
+		return r.requeue.Failed(request, errWrap.Wrap(err, "cannot delete cd pipeline")), nil
+	}
+	if res != nil {
+		r.requeue.Forget(request)
+		return *res, nil
 	}
 
 	cdp, err := cdpipeline.ConvertToCDPipeline(*instance, *edpN)
 	if err != nil {
-		reqLogger.Error(err, "cannot convert to cd pipeline dto")
-		return reconcile.Result{RequeueAfter: 2 * time.Second}, nil
+		r.sync.Failed(instance, err)
+		return r.requeue.Failed(request, backoff.AsPermanent(errWrap.Wrap(err, "cannot convert to cd pipeline dto"))), nil
 	}
 	err = r.cdpService.PutCDPipeline(*cdp)
 	if err != nil {
-		reqLogger.Error(err, "cannot put cd pipeline")
-		return reconcile.Result{RequeueAfter: 2 * time.Second}, nil
+		r.sync.Failed(instance, err)
+		return r.requeue.Failed(request, errWrap.Wrap(err, "cannot put cd pipeline")), nil
 	}
 
+	r.requeue.Forget(request)
+	r.sync.Synced(instance)
+	dependency.Committed(resync.CDPipeline, instance)
 	reqLogger.Info("Reconciling has been finished successfully")
 	return reconcile.Result{}, nil
 }
@@ -167,12 +202,12 @@ func (r *ReconcileCDPipeline) tryToDeleteCDPipeline(p *edpv1alpha1.CDPipeline, s
 	}
 
 	if err := r.cdpService.DeleteCDPipeline(p.Name, schema); err != nil {
-		return &reconcile.Result{RequeueAfter: 2 * time.Second}, err
+		return nil, err
 	}
 
 	p.ObjectMeta.Finalizers = helper.RemoveString(p.ObjectMeta.Finalizers, cdPipelineReconcilerFinalizerName)
 	if err := r.client.Update(context.TODO(), p); err != nil {
-		return &reconcile.Result{RequeueAfter: 2 * time.Second}, err
+		return nil, err
 	}
 	return &reconcile.Result{}, nil
 }
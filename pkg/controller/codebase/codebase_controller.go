@@ -2,18 +2,20 @@ package codebase
 
 import (
 	"context"
+	"database/sql"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/dependency"
 	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
-	"github.com/epmd-edp/reconciler/v2/pkg/db"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/resync"
+	"github.com/epmd-edp/reconciler/v2/pkg/metrics"
 	"github.com/epmd-edp/reconciler/v2/pkg/model/codebase"
 	"github.com/epmd-edp/reconciler/v2/pkg/service"
-	"github.com/epmd-edp/reconciler/v2/pkg/service/codebaseperfdatasource"
-	"github.com/epmd-edp/reconciler/v2/pkg/service/perfdatasource"
-	"github.com/epmd-edp/reconciler/v2/pkg/service/perfserver"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
 	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
-	"time"
 
+	errWrap "github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -27,31 +29,27 @@ import (
 	edpv1alpha1Codebase "github.com/epmd-edp/codebase-operator/v2/pkg/apis/edp/v1alpha1"
 )
 
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+const controllerName = "codebase-controller"
+
+func Add(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) error {
+	return add(mgr, newReconciler(mgr, db, tenants))
 }
 
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) reconcile.Reconciler {
 	return &ReconcileCodebase{
-		client: mgr.GetClient(),
-		scheme: mgr.GetScheme(),
+		client:  mgr.GetClient(),
+		tenants: tenants,
+		scheme:  mgr.GetScheme(),
+		sync:    helper.NewSyncReporter(mgr.GetClient(), mgr.GetRecorder(controllerName)),
+		requeue: backoff.New(controllerName),
 		service: service.CodebaseService{
-			DB: db.Instance,
-			DataSourceService: perfdatasource.PerfDataSourceService{
-				DB: db.Instance,
-			},
-			PerfService: perfserver.PerfServerService{
-				DB: db.Instance,
-			},
-			CodebaseDsService: codebaseperfdatasource.CodebasePerfDataSourceService{
-				DB: db.Instance,
-			},
+			Storage: postgres.New(db),
 		},
 	}
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
-	c, err := controller.New("codebase-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: metrics.Instrument(controllerName, r)})
 	if err != nil {
 		return err
 	}
@@ -82,6 +80,11 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	err = c.Watch(resync.Source(resync.Codebase), &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -94,7 +97,10 @@ const codebaseReconcilerFinalizerName = "codebase.reconciler.finalizer.name"
 
 type ReconcileCodebase struct {
 	client  client.Client
+	tenants *helper.Tenants
 	scheme  *runtime.Scheme
+	sync    helper.SyncReporter
+	requeue *backoff.Requeuer
 	service service.CodebaseService
 }
 
@@ -105,34 +111,45 @@ func (r *ReconcileCodebase) Reconcile(request reconcile.Request) (reconcile.Resu
 	i := &edpv1alpha1Codebase.Codebase{}
 	if err := r.client.Get(context.TODO(), request.NamespacedName, i); err != nil {
 		if errors.IsNotFound(err) {
+			r.requeue.Forget(request)
 			return reconcile.Result{}, nil
 		}
 		return reconcile.Result{}, err
 	}
+	r.requeue.Observe(request, i.GetGeneration())
 	rl.Info("Codebase has been retrieved", "codebase", i)
 
-	edpN, err := helper.GetEDPName(r.client, i.Namespace)
+	edpN, err := r.tenants.GetEDPName(i.Namespace)
 	if err != nil {
-		rl.Error(err, "cannot get edp name")
-		return reconcile.Result{RequeueAfter: 2 * time.Second}, nil
+		if helper.IsTenantNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return r.requeue.Failed(request, errWrap.Wrap(err, "cannot get edp name")), nil
 	}
 
 	result, err := r.tryToDeleteCodebase(i, *edpN)
-	if err != nil || result != nil {
-		return *result, err
+	if err != nil {
+		return r.requeue.Failed(request, errWrap.Wrap(err, "cannot delete codebase")), nil
+	}
+	if result != nil {
+		r.requeue.Forget(request)
+		return *result, nil
 	}
 
 	c, err := codebase.Convert(*i, *edpN)
 	if err != nil {
-		rl.Error(err, "cannot convert codebase to dto")
-		return reconcile.Result{RequeueAfter: 2 * time.Second}, nil
+		r.sync.Failed(i, err)
+		return r.requeue.Failed(request, backoff.AsPermanent(errWrap.Wrap(err, "cannot convert codebase to dto"))), nil
 	}
 
 	if err = r.service.PutCodebase(*c); err != nil {
-		rl.Error(err, "cannot put codebase", "name", c.Name)
-		return reconcile.Result{RequeueAfter: 2 * time.Second}, nil
+		r.sync.Failed(i, err)
+		return r.requeue.Failed(request, errWrap.Wrapf(err, "cannot put codebase %v", c.Name)), nil
 	}
 
+	r.requeue.Forget(request)
+	r.sync.Synced(i)
+	dependency.Committed(resync.Codebase, i)
 	rl.Info("Reconciling has been finished successfully")
 	return reconcile.Result{}, nil
 }
@@ -2,15 +2,19 @@ package codebasebranch
 
 import (
 	"context"
+	"database/sql"
 	"github.com/epmd-edp/codebase-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/dependency"
 	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
-	"github.com/epmd-edp/reconciler/v2/pkg/db"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/resync"
+	"github.com/epmd-edp/reconciler/v2/pkg/metrics"
 	"github.com/epmd-edp/reconciler/v2/pkg/model/codebasebranch"
 	cbs "github.com/epmd-edp/reconciler/v2/pkg/service/codebasebranch"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
 	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
-	"time"
 
 	errWrap "github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -26,22 +30,27 @@ import (
 
 var log = logf.Log.WithName("controller_codebasebranch")
 
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+const controllerName = "codebasebranch-controller"
+
+func Add(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) error {
+	return add(mgr, newReconciler(mgr, db, tenants))
 }
 
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) reconcile.Reconciler {
 	return &ReconcileCodebaseBranch{
-		client: mgr.GetClient(),
-		scheme: mgr.GetScheme(),
+		client:  mgr.GetClient(),
+		tenants: tenants,
+		scheme:  mgr.GetScheme(),
+		sync:    helper.NewSyncReporter(mgr.GetClient(), mgr.GetRecorder(controllerName)),
+		requeue: backoff.New(controllerName),
 		cbService: cbs.CodebaseBranchService{
-			DB: db.Instance,
+			Storage: postgres.New(db),
 		},
 	}
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
-	c, err := controller.New("codebasebranch-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: metrics.Instrument(controllerName, r)})
 	if err != nil {
 		return err
 	}
@@ -80,6 +89,16 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	err = c.Watch(resync.Source(resync.CodebaseBranch), &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	err = dependency.Watch(mgr, c, dependency.CodebaseBranches)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -89,7 +108,10 @@ const codebaseBranchReconcilerFinalizerName = "codebasebranch.reconciler.finaliz
 
 type ReconcileCodebaseBranch struct {
 	client    client.Client
+	tenants   *helper.Tenants
 	scheme    *runtime.Scheme
+	sync      helper.SyncReporter
+	requeue   *backoff.Requeuer
 	cbService cbs.CodebaseBranchService
 }
 
@@ -100,27 +122,42 @@ func (r *ReconcileCodebaseBranch) Reconcile(request reconcile.Request) (reconcil
 	i := &v1alpha1.CodebaseBranch{}
 	if err := r.client.Get(context.TODO(), request.NamespacedName, i); err != nil {
 		if errors.IsNotFound(err) {
+			r.requeue.Forget(request)
 			return reconcile.Result{}, nil
 		}
 		return reconcile.Result{}, err
 	}
+	r.requeue.Observe(request, i.GetGeneration())
 
-	edpN, err := helper.GetEDPName(r.client, i.Namespace)
+	edpN, err := r.tenants.GetEDPName(i.Namespace)
 	if err != nil {
-		return reconcile.Result{RequeueAfter: 2 * time.Second}, errWrap.Wrap(err, "couldn't get edp name")
+		if helper.IsTenantNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return r.requeue.Failed(request, errWrap.Wrap(err, "couldn't get edp name")), nil
 	}
 
-	if res, err := r.tryToDeleteCodebaseBranch(i, *edpN); err != nil || res != nil {
-		return *res, err
+	res, err := r.tryToDeleteCodebaseBranch(i, *edpN)
+	if err != nil {
+		return r.requeue.Failed(request, errWrap.Wrap(err, "couldn't delete codebase branch")), nil
+	}
+	if res != nil {
+		r.requeue.Forget(request)
+		return *res, nil
 	}
 
 	app, err := codebasebranch.ConvertToCodebaseBranch(*i, *edpN)
 	if err != nil {
-		return reconcile.Result{RequeueAfter: 2 * time.Second}, errWrap.Wrap(err, "cannot convert to codebase branch dto")
+		r.sync.Failed(i, err)
+		return r.requeue.Failed(request, backoff.AsPermanent(errWrap.Wrap(err, "cannot convert to codebase branch dto"))), nil
 	}
 	if err := r.cbService.PutCodebaseBranch(*app); err != nil {
-		return reconcile.Result{RequeueAfter: 2 * time.Second}, errWrap.Wrap(err, "couldn't insert codebase branch")
+		r.sync.Failed(i, err)
+		return r.requeue.Failed(request, errWrap.Wrap(err, "couldn't insert codebase branch")), nil
 	}
+	r.requeue.Forget(request)
+	r.sync.Synced(i)
+	dependency.Committed(resync.CodebaseBranch, i)
 	rl.Info("Reconciling has been finished successfully")
 	return reconcile.Result{}, nil
 }
@@ -137,12 +174,12 @@ func (r *ReconcileCodebaseBranch) tryToDeleteCodebaseBranch(cb *v1alpha1.Codebas
 	}
 
 	if err := r.cbService.Delete(cb.Spec.CodebaseName, cb.Spec.BranchName, schema); err != nil {
-		return &reconcile.Result{RequeueAfter: 2 * time.Second}, err
+		return nil, err
 	}
 
 	cb.ObjectMeta.Finalizers = helper.RemoveString(cb.ObjectMeta.Finalizers, codebaseBranchReconcilerFinalizerName)
 	if err := r.client.Update(context.TODO(), cb); err != nil {
-		return &reconcile.Result{RequeueAfter: 2 * time.Second}, err
+		return nil, err
 	}
 	return &reconcile.Result{}, nil
 }
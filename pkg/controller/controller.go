@@ -1,16 +1,19 @@
 package controller
 
 import (
+	"database/sql"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 // AddToManagerFuncs is a list of functions to add all Controllers to the Manager
-var AddToManagerFuncs []func(manager.Manager) error
+var AddToManagerFuncs []func(manager.Manager, *sql.DB, *helper.Tenants) error
 
-// AddToManager adds all Controllers to the Manager
-func AddToManager(m manager.Manager) error {
+// AddToManager adds all Controllers to the Manager, db is shared by their services
+// and tenants resolve EDP name of the watched namespaces
+func AddToManager(m manager.Manager, db *sql.DB, tenants *helper.Tenants) error {
 	for _, f := range AddToManagerFuncs {
-		if err := f(m); err != nil {
+		if err := f(m, db, tenants); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,235 @@
+// Package dependency enqueues custom resources once the rows they depend on
+// are committed to DB, e.g. branches of a codebase once the codebase has
+// been added, instead of waiting for their next requeue.
+package dependency
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	cdPipeApi "github.com/epmd-edp/cd-pipeline-operator/v2/pkg/apis/edp/v1alpha1"
+	codebaseApi "github.com/epmd-edp/codebase-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/resync"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("dependency")
+
+// bufferSize is the number of commits kept until the dependent controller
+// has been started.
+const bufferSize = 100
+
+// index is a field index of dependents by the key of their prerequisite.
+type index struct {
+	field   string
+	obj     runtime.Object
+	list    func() runtime.Object
+	extract client.IndexerFunc
+}
+
+var (
+	branchesByCodebase = index{
+		field: "spec.codebaseName",
+		obj:   &codebaseApi.CodebaseBranch{},
+		list:  func() runtime.Object { return &codebaseApi.CodebaseBranchList{} },
+		extract: func(o runtime.Object) []string {
+			return []string{o.(*codebaseApi.CodebaseBranch).Spec.CodebaseName}
+		},
+	}
+	pipelinesByStream = index{
+		field: "spec.inputDockerStreams",
+		obj:   &cdPipeApi.CDPipeline{},
+		list:  func() runtime.Object { return &cdPipeApi.CDPipelineList{} },
+		extract: func(o runtime.Object) []string {
+			return o.(*cdPipeApi.CDPipeline).Spec.InputDockerStreams
+		},
+	}
+	stagesByPipeline = index{
+		field: "spec.cdPipeline",
+		obj:   &cdPipeApi.Stage{},
+		list:  func() runtime.Object { return &cdPipeApi.StageList{} },
+		extract: func(o runtime.Object) []string {
+			return []string{o.(*cdPipeApi.Stage).Spec.CdPipeline}
+		},
+	}
+)
+
+// Relation enqueues dependents once a prerequisite of the Parent kind has
+// been committed.
+type Relation struct {
+	Parent string
+	index  index
+	// key returns the value of the indexed field dependents of the parent have.
+	key func(parent runtime.Object) string
+	// match filters dependents further, nil matches every one.
+	match func(parent, dependent runtime.Object) bool
+}
+
+var (
+	// CodebaseBranches need the codebase record.
+	CodebaseBranches = Relation{
+		Parent: resync.Codebase,
+		index:  branchesByCodebase,
+		key:    func(p runtime.Object) string { return p.(*codebaseApi.Codebase).Name },
+	}
+	// CDPipelines need docker streams of their input branches.
+	CDPipelines = Relation{
+		Parent: resync.CodebaseBranch,
+		index:  pipelinesByStream,
+		key: func(p runtime.Object) string {
+			s := p.(*codebaseApi.CodebaseBranch).Spec
+			return fmt.Sprintf("%v-%v", s.CodebaseName, s.BranchName)
+		},
+	}
+	// Stages need the cd pipeline record.
+	Stages = Relation{
+		Parent: resync.CDPipeline,
+		index:  stagesByPipeline,
+		key:    func(p runtime.Object) string { return p.(*cdPipeApi.CDPipeline).Spec.Name },
+	}
+	// NextStages need the previous stage and its output docker streams.
+	NextStages = Relation{
+		Parent: resync.Stage,
+		index:  stagesByPipeline,
+		key:    func(p runtime.Object) string { return p.(*cdPipeApi.Stage).Spec.CdPipeline },
+		match: func(p, d runtime.Object) bool {
+			return d.(*cdPipeApi.Stage).Spec.Order == p.(*cdPipeApi.Stage).Spec.Order+1
+		},
+	}
+)
+
+var (
+	mu       sync.Mutex
+	channels = map[string]chan event.GenericEvent{}
+	indexed  = map[string]bool{}
+)
+
+// Committed notifies controllers depending on the kind that the object has
+// been committed to DB. The notification is dropped if nobody depends on
+// the kind or the buffer is full, failed dependents are still requeued
+// with backoff then.
+func Committed(kind string, obj helper.Object) {
+	mu.Lock()
+	ch, ok := channels[kind]
+	mu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case ch <- event.GenericEvent{Meta: obj, Object: obj}:
+	default:
+		log.V(2).Info("Commit notification has been dropped", "kind", kind,
+			"namespace", obj.GetNamespace(), "name", obj.GetName())
+	}
+}
+
+// Watch makes the controller reconcile its pending custom resources once
+// their prerequisites have been committed. Dependents are looked up by
+// field indexes, so it should be called before the manager is started.
+func Watch(mgr manager.Manager, c controller.Controller, relations ...Relation) error {
+	for _, r := range relations {
+		if err := addIndex(mgr.GetFieldIndexer(), r.index); err != nil {
+			return err
+		}
+		err := c.Watch(&source.Channel{Source: channel(r.Parent)},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: r.requests(mgr.GetClient())})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addIndex(i client.FieldIndexer, idx index) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	key := fmt.Sprintf("%T/%v", idx.obj, idx.field)
+	if indexed[key] {
+		return nil
+	}
+	if err := i.IndexField(idx.obj, idx.field, idx.extract); err != nil {
+		return err
+	}
+	indexed[key] = true
+	return nil
+}
+
+func channel(kind string) chan event.GenericEvent {
+	mu.Lock()
+	defer mu.Unlock()
+
+	ch, ok := channels[kind]
+	if !ok {
+		ch = make(chan event.GenericEvent, bufferSize)
+		channels[kind] = ch
+	}
+	return ch
+}
+
+func (r Relation) requests(c client.Client) handler.ToRequestsFunc {
+	return func(o handler.MapObject) []reconcile.Request {
+		key := r.key(o.Object)
+		l := r.index.list()
+		opts := client.InNamespace(o.Meta.GetNamespace()).MatchingField(r.index.field, key)
+		if err := c.List(context.TODO(), opts, l); err != nil {
+			log.Error(err, "Couldn't list dependents", "kind", r.Parent, "key", key)
+			return nil
+		}
+		items, err := meta.ExtractList(l)
+		if err != nil {
+			log.Error(err, "Couldn't extract dependents", "kind", r.Parent, "key", key)
+			return nil
+		}
+		return r.dependents(o.Object, items)
+	}
+}
+
+// dependents returns requests of the items which depend on the parent and
+// haven't been synced yet.
+func (r Relation) dependents(parent runtime.Object, items []runtime.Object) []reconcile.Request {
+	key := r.key(parent)
+	var reqs []reconcile.Request
+	for _, d := range items {
+		if !contains(r.index.extract(d), key) || (r.match != nil && !r.match(parent, d)) {
+			continue
+		}
+		m, err := meta.Accessor(d)
+		if err != nil || !pending(m) {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: m.GetNamespace(), Name: m.GetName()},
+		})
+	}
+	return reqs
+}
+
+// pending tells whether the current generation of the object hasn't been
+// synced to DB yet.
+func pending(m metaV1.Object) bool {
+	s := helper.GetSyncStatus(m)
+	return s == nil || s.Error != "" || s.Generation != m.GetGeneration()
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
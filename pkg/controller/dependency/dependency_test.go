@@ -0,0 +1,67 @@
+package dependency
+
+import (
+	"testing"
+
+	cdPipeApi "github.com/epmd-edp/cd-pipeline-operator/v2/pkg/apis/edp/v1alpha1"
+	codebaseApi "github.com/epmd-edp/codebase-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
+	"github.com/stretchr/testify/assert"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func stage(name, pipeline string, order int, synced string) *cdPipeApi.Stage {
+	s := &cdPipeApi.Stage{}
+	s.Name = name
+	s.Namespace = "fake-ns"
+	s.Generation = 1
+	s.Spec.Name = name
+	s.Spec.CdPipeline = pipeline
+	s.Spec.Order = order
+	if synced != "" {
+		s.Annotations = map[string]string{helper.SyncedAnnotation: synced}
+	}
+	return s
+}
+
+func request(name string) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "fake-ns", Name: name}}
+}
+
+func TestNextStages_ShouldEnqueueOnlyFollowingPendingStage(t *testing.T) {
+	items := []runtime.Object{
+		stage("dev", "fake-pipeline", 0, `{"generation":1}`),
+		stage("sit", "fake-pipeline", 1, `{"generation":0,"error":"previous stage has not been added yet"}`),
+		stage("qa", "fake-pipeline", 2, ""),
+		stage("sit-other", "other-pipeline", 1, ""),
+	}
+
+	reqs := NextStages.dependents(items[0], items)
+
+	assert.Equal(t, []reconcile.Request{request("sit")}, reqs)
+}
+
+func TestStages_ShouldSkipSyncedStages(t *testing.T) {
+	p := &cdPipeApi.CDPipeline{ObjectMeta: metaV1.ObjectMeta{Name: "fake-pipeline", Namespace: "fake-ns"}}
+	p.Spec.Name = "fake-pipeline"
+	items := []runtime.Object{
+		stage("dev", "fake-pipeline", 0, `{"generation":1}`),
+		stage("sit", "fake-pipeline", 1, ""),
+	}
+
+	assert.Equal(t, []reconcile.Request{request("sit")}, Stages.dependents(p, items))
+}
+
+func TestCDPipelines_ShouldBeFoundByBranchDockerStream(t *testing.T) {
+	b := &codebaseApi.CodebaseBranch{ObjectMeta: metaV1.ObjectMeta{Name: "fake-app-master", Namespace: "fake-ns"}}
+	b.Spec.CodebaseName = "fake-app"
+	b.Spec.BranchName = "master"
+	p := &cdPipeApi.CDPipeline{ObjectMeta: metaV1.ObjectMeta{Name: "fake-pipeline", Namespace: "fake-ns"}}
+	p.Spec.InputDockerStreams = []string{"other-app-master", "fake-app-master"}
+
+	assert.Equal(t, []reconcile.Request{request("fake-pipeline")},
+		CDPipelines.dependents(b, []runtime.Object{p}))
+}
@@ -2,14 +2,16 @@ package edp_component
 
 import (
 	"context"
+	"database/sql"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
 	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
-	"github.com/epmd-edp/reconciler/v2/pkg/db"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/resync"
+	"github.com/epmd-edp/reconciler/v2/pkg/metrics"
 	"github.com/epmd-edp/reconciler/v2/pkg/model"
 	ec "github.com/epmd-edp/reconciler/v2/pkg/service/edp-component"
 	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
-	"time"
 
 	edpComponentV1Api "github.com/epmd-edp/edp-component-operator/pkg/apis/v1/v1alpha1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -29,24 +31,28 @@ var log = logf.Log.WithName("edp_component_controller")
 * business logic.  Delete these comments after modifying this file.*
  */
 
+const controllerName = "edp-component-controller"
+
 // Add creates a new JobProvisioning Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) error {
+	return add(mgr, newReconciler(mgr, db, tenants))
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) reconcile.Reconciler {
 	return &EDPComponent{
 		client:              mgr.GetClient(),
-		EDPComponentService: ec.EDPComponentService{DB: db.Instance},
+		tenants:             tenants,
+		requeue:             backoff.New(controllerName),
+		EDPComponentService: ec.EDPComponentService{DB: db},
 	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("edp-component-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: metrics.Instrument(controllerName, r)})
 	if err != nil {
 		return err
 	}
@@ -69,6 +75,11 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	err = c.Watch(resync.Source(resync.EDPComponent), &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -77,6 +88,8 @@ var _ reconcile.Reconciler = &EDPComponent{}
 // EDPComponent reconciles a EDPComponent object
 type EDPComponent struct {
 	client              client.Client
+	tenants             *helper.Tenants
+	requeue             *backoff.Requeuer
 	EDPComponentService ec.EDPComponentService
 }
 
@@ -95,24 +108,30 @@ func (r *EDPComponent) Reconcile(request reconcile.Request) (reconcile.Result, e
 	err := r.client.Get(context.TODO(), request.NamespacedName, i)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			r.requeue.Forget(request)
 			return reconcile.Result{}, nil
 		}
 		return reconcile.Result{}, err
 	}
+	r.requeue.Observe(request, i.GetGeneration())
 
 	c, err := model.ConvertToEDPComponent(*i)
 	if err != nil {
-		return reconcile.Result{}, err
+		return r.requeue.Failed(request, backoff.AsPermanent(err)), nil
 	}
 	log.Info("start reconciling for component", "type", c.Type, "url", c.Url)
-	edpN, err := helper.GetEDPName(r.client, i.Namespace)
+	edpN, err := r.tenants.GetEDPName(i.Namespace)
 	if err != nil {
+		if helper.IsTenantNotFound(err) {
+			return reconcile.Result{}, nil
+		}
 		return reconcile.Result{}, err
 	}
 	err = r.EDPComponentService.PutEDPComponent(*c, *edpN)
 	if err != nil {
-		return reconcile.Result{RequeueAfter: time.Second * 120}, err
+		return r.requeue.Failed(request, err), nil
 	}
 
+	r.requeue.Forget(request)
 	return reconcile.Result{}, nil
 }
@@ -2,12 +2,17 @@ package git_server
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	edpv1alpha1Codebase "github.com/epmd-edp/codebase-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
 	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
-	"github.com/epmd-edp/reconciler/v2/pkg/db"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/resync"
+	"github.com/epmd-edp/reconciler/v2/pkg/metrics"
 	"github.com/epmd-edp/reconciler/v2/pkg/model/gitserver"
 	"github.com/epmd-edp/reconciler/v2/pkg/service/git"
 	"github.com/epmd-edp/reconciler/v2/pkg/service/infrastructure"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
 	errWrap "github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -26,21 +31,25 @@ var log = logf.Log.WithName("controller_git_server")
 * business logic.  Delete these comments after modifying this file.*
  */
 
+const controllerName = "git-server-controller"
+
 // Add creates a new GitServer Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) error {
+	return add(mgr, newReconciler(mgr, db, tenants))
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) reconcile.Reconciler {
 	return &ReconcileGitServer{
-		Client: mgr.GetClient(),
+		Client:  mgr.GetClient(),
+		Tenants: tenants,
+		requeue: backoff.New(controllerName),
 		GitServerService: git.GitServerService{
-			DB: db.Instance,
+			Storage: postgres.New(db),
 		},
 		InfrastructureDbService: infrastructure.InfrastructureDbService{
-			DB: db.Instance,
+			DB: db,
 		},
 	}
 }
@@ -48,7 +57,7 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("git-server-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: metrics.Instrument(controllerName, r)})
 	if err != nil {
 		return err
 	}
@@ -59,6 +68,11 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	err = c.Watch(resync.Source(resync.GitServer), &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -69,8 +83,10 @@ type ReconcileGitServer struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
 	Client                  client.Client
+	Tenants                 *helper.Tenants
 	GitServerService        git.GitServerService
 	InfrastructureDbService infrastructure.InfrastructureDbService
+	requeue                 *backoff.Requeuer
 }
 
 // Reconcile reads that state of the cluster for a ReconcileGitServer object and makes changes based on the state read
@@ -92,34 +108,41 @@ func (r *ReconcileGitServer) Reconcile(request reconcile.Request) (reconcile.Res
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			r.requeue.Forget(request)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
+	r.requeue.Observe(request, instance.GetGeneration())
 	log.WithValues("GitServer", instance)
-	edpN, err := helper.GetEDPName(r.Client, instance.Namespace)
+	edpN, err := r.Tenants.GetEDPName(instance.Namespace)
 	if err != nil {
-		return reconcile.Result{}, err
+		if helper.IsTenantNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return r.requeue.Failed(request, errWrap.Wrap(err, "cannot get edp name")), nil
 	}
 	gitServer, err := gitserver.ConvertToGitServer(*instance, *edpN)
 	if err != nil {
-		return reconcile.Result{}, err
+		return r.requeue.Failed(request, backoff.AsPermanent(err)), nil
 	}
 
 	exists, err := r.InfrastructureDbService.DoesSchemaExist(gitServer.Tenant)
 	if err != nil {
-		return reconcile.Result{}, errWrap.Wrap(err, "an error has occurred while checking schema in BD")
+		return r.requeue.Failed(request, errWrap.Wrap(err, "an error has occurred while checking schema in BD")), nil
 	}
 	reqLogger.Info("Check schema: ", "schema", gitServer.Tenant, "exists", exists)
 
-	if exists {
-		err := r.GitServerService.PutGitServer(*gitServer)
-		if err != nil {
-			return reconcile.Result{}, err
-		}
+	if !exists {
+		reqLogger.Info("Schema hasn't been bootstrapped yet. Requeue", "schema", gitServer.Tenant)
+		return r.requeue.Failed(request, backoff.AsDependency(fmt.Errorf("schema %v hasn't been bootstrapped yet", gitServer.Tenant))), nil
+	}
 
+	if err := r.GitServerService.PutGitServer(*gitServer); err != nil {
+		return r.requeue.Failed(request, err), nil
 	}
 
+	r.requeue.Forget(request)
 	return reconcile.Result{}, nil
 }
@@ -20,7 +20,10 @@ package helper
 import (
 	"context"
 	"fmt"
+
+	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -30,20 +33,46 @@ const (
 	EDPNameKey  = "edp_name"
 )
 
+// TenantNotFoundError means there is no edp-config config map in the
+// namespace or the config map has no edp name.
+type TenantNotFoundError struct {
+	Namespace string
+	Reason    string
+}
+
+func (e TenantNotFoundError) Error() string {
+	return fmt.Sprintf("tenant of %v namespace is unknown: %v", e.Namespace, e.Reason)
+}
+
+// IsTenantNotFound reports whether the error is caused by TenantNotFoundError.
+func IsTenantNotFound(err error) bool {
+	_, ok := errors.Cause(err).(TenantNotFoundError)
+	return ok
+}
+
 // GetEDPName tries to find edp name parameter from edp-config CM using
 // provided client and namespace to search
-func GetEDPName(client client.Client, namespace string) (*string, error) {
+func GetEDPName(client client.Reader, namespace string) (*string, error) {
 	cm := &v1.ConfigMap{}
 	err := client.Get(context.TODO(), types.NamespacedName{
 		Namespace: namespace,
 		Name:      EDPConfigCM,
 	}, cm)
 	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, TenantNotFoundError{
+				Namespace: namespace,
+				Reason:    fmt.Sprintf("there is no cm %v", EDPConfigCM),
+			}
+		}
 		return nil, err
 	}
 	r := cm.Data[EDPNameKey]
 	if len(r) == 0 {
-		return nil, fmt.Errorf("there is not key %v in cm %v", EDPNameKey, EDPConfigCM)
+		return nil, TenantNotFoundError{
+			Namespace: namespace,
+			Reason:    fmt.Sprintf("there is not key %v in cm %v", EDPNameKey, EDPConfigCM),
+		}
 	}
 	return &r, nil
 }
@@ -0,0 +1,127 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+var syncLog = logf.Log.WithName("sync-status")
+
+// SyncedAnnotation holds SyncStatus of a custom resource projected to DB.
+const SyncedAnnotation = "reconciler.edp.epam.com/synced"
+
+// Reasons of the events emitted on custom resources.
+const (
+	ReasonSynced     = "Synced"
+	ReasonSyncFailed = "SyncFailed"
+)
+
+// SyncStatus is the result of projecting a custom resource to DB.
+type SyncStatus struct {
+	// Generation and Time are of the last successful projection.
+	Generation int64      `json:"generation"`
+	Time       *time.Time `json:"time,omitempty"`
+	// Error is the error of the last projection, empty if it has succeeded.
+	Error string `json:"error,omitempty"`
+}
+
+// Object is a custom resource the reconciler projects.
+type Object interface {
+	runtime.Object
+	metaV1.Object
+}
+
+// SyncReporter writes the result of projection back to custom resources as
+// SyncedAnnotation and Kubernetes events. The annotation is updated only
+// if the result changes, so reconciling the same generation again doesn't
+// touch the custom resource.
+type SyncReporter struct {
+	Client   client.Client
+	Recorder record.EventRecorder
+}
+
+// NewSyncReporter returns reporter emitting events on behalf of the controller.
+func NewSyncReporter(c client.Client, recorder record.EventRecorder) SyncReporter {
+	return SyncReporter{Client: c, Recorder: recorder}
+}
+
+// Synced records successful projection of the current generation.
+func (r SyncReporter) Synced(obj Object) {
+	old := GetSyncStatus(obj)
+	if old != nil && old.Generation == obj.GetGeneration() && old.Error == "" {
+		return
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	r.update(obj, SyncStatus{Generation: obj.GetGeneration(), Time: &now})
+	r.Recorder.Eventf(obj, coreV1.EventTypeNormal, ReasonSynced, "Generation %v has been synced to DB", obj.GetGeneration())
+}
+
+// Failed records failed projection keeping generation and time of the last
+// successful one.
+func (r SyncReporter) Failed(obj Object, err error) {
+	r.Recorder.Event(obj, coreV1.EventTypeWarning, ReasonSyncFailed, err.Error())
+
+	s := SyncStatus{}
+	if old := GetSyncStatus(obj); old != nil {
+		if old.Error == err.Error() {
+			return
+		}
+		s = *old
+	}
+	s.Error = err.Error()
+	r.update(obj, s)
+}
+
+// GetSyncStatus returns status the annotation of the object holds, nil if
+// there is no valid one.
+func GetSyncStatus(obj metaV1.Object) *SyncStatus {
+	v, ok := obj.GetAnnotations()[SyncedAnnotation]
+	if !ok {
+		return nil
+	}
+	s := &SyncStatus{}
+	if err := json.Unmarshal([]byte(v), s); err != nil {
+		return nil
+	}
+	return s
+}
+
+// update sets the annotation on the latest version of the object, so it
+// doesn't overwrite changes made since the object has been read.
+func (r SyncReporter) update(obj Object, s SyncStatus) {
+	v, err := json.Marshal(s)
+	if err != nil {
+		syncLog.Error(err, "couldn't marshal sync status")
+		return
+	}
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	first := true
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if !first {
+			if err := r.Client.Get(context.TODO(), key, obj); err != nil {
+				return err
+			}
+		}
+		first = false
+		a := obj.GetAnnotations()
+		if a == nil {
+			a = map[string]string{}
+		}
+		a[SyncedAnnotation] = string(v)
+		obj.SetAnnotations(a)
+		return r.Client.Update(context.TODO(), obj)
+	})
+	if err != nil {
+		syncLog.Error(err, "couldn't update sync status", "namespace", key.Namespace, "name", key.Name)
+	}
+}
@@ -0,0 +1,52 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSyncReporter_ShouldKeepLastSyncedGenerationOnFailure(t *testing.T) {
+	cm := &coreV1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{Name: "fake-name", Namespace: "fake-ns", Generation: 2}}
+	cl := fake.NewFakeClient(cm)
+	recorder := record.NewFakeRecorder(10)
+	r := NewSyncReporter(cl, recorder)
+	key := types.NamespacedName{Namespace: "fake-ns", Name: "fake-name"}
+
+	obj := &coreV1.ConfigMap{}
+	assert.NoError(t, cl.Get(context.TODO(), key, obj))
+	r.Synced(obj)
+	r.Failed(obj, errors.New("fake error"))
+
+	actual := &coreV1.ConfigMap{}
+	assert.NoError(t, cl.Get(context.TODO(), key, actual))
+	s := GetSyncStatus(actual)
+	if assert.NotNil(t, s) {
+		assert.Equal(t, int64(2), s.Generation)
+		assert.NotNil(t, s.Time)
+		assert.Equal(t, "fake error", s.Error)
+	}
+	assert.Equal(t, "Normal Synced Generation 2 has been synced to DB", <-recorder.Events)
+	assert.Equal(t, "Warning SyncFailed fake error", <-recorder.Events)
+}
+
+func TestSyncReporter_ShouldNotReportSameGenerationTwice(t *testing.T) {
+	cm := &coreV1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{Name: "fake-name", Namespace: "fake-ns", Generation: 1}}
+	cl := fake.NewFakeClient(cm)
+	recorder := record.NewFakeRecorder(10)
+	r := NewSyncReporter(cl, recorder)
+
+	obj := &coreV1.ConfigMap{}
+	assert.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Namespace: "fake-ns", Name: "fake-name"}, obj))
+	r.Synced(obj)
+	r.Synced(obj)
+
+	assert.Len(t, recorder.Events, 1)
+}
@@ -0,0 +1,199 @@
+package helper
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+var log = logf.Log.WithName("tenants")
+
+// ReasonEDPNameMissing is the reason of the event recorded on edp-config
+// config map which has no edp name.
+const ReasonEDPNameMissing = "EDPNameMissing"
+
+// Tenants maps watched namespaces to EDP names, i.e. tenant schemas, taken
+// from edp-config config maps. It is kept up to date by config map events
+// and reports namespaces which tenant has changed, so their custom resources
+// can be reconciled again.
+type Tenants struct {
+	client   client.Reader
+	recorder record.EventRecorder
+
+	mu         sync.RWMutex
+	namespaces map[string]string
+	changed    map[string]bool
+	signal     chan struct{}
+}
+
+// NewTenants returns tenants which resolve namespaces missing in the map
+// by the client. The recorder is used to report config maps without edp name.
+func NewTenants(client client.Reader, recorder record.EventRecorder) *Tenants {
+	return &Tenants{
+		client:     client,
+		recorder:   recorder,
+		namespaces: map[string]string{},
+		changed:    map[string]bool{},
+		signal:     make(chan struct{}, 1),
+	}
+}
+
+// Watch subscribes the tenants to config map events of the informer cache.
+func (t *Tenants) Watch(c cache.Informers) error {
+	i, err := c.GetInformer(&v1.ConfigMap{})
+	if err != nil {
+		return err
+	}
+	i.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: t.set,
+		UpdateFunc: func(_, obj interface{}) {
+			t.set(obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if d, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = d.Obj
+			}
+			if cm, ok := obj.(*v1.ConfigMap); ok && cm.Name == EDPConfigCM {
+				t.delete(cm.Namespace)
+			}
+		},
+	})
+	return nil
+}
+
+func (t *Tenants) set(obj interface{}) {
+	cm, ok := obj.(*v1.ConfigMap)
+	if !ok || cm.Name != EDPConfigCM {
+		return
+	}
+	edpN := cm.Data[EDPNameKey]
+	if edpN == "" {
+		t.delete(cm.Namespace)
+		if t.recorder != nil {
+			t.recorder.Event(cm, v1.EventTypeWarning, ReasonEDPNameMissing,
+				fmt.Sprintf("There is no %v key, custom resources of the namespace aren't reconciled", EDPNameKey))
+		}
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.namespaces[cm.Namespace] == edpN {
+		return
+	}
+	log.Info("Tenant of namespace has been changed", "namespace", cm.Namespace,
+		"from", t.namespaces[cm.Namespace], "to", edpN)
+	t.namespaces[cm.Namespace] = edpN
+	t.changed[cm.Namespace] = true
+	select {
+	case t.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (t *Tenants) delete(namespace string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.namespaces, namespace)
+}
+
+// GetEDPName returns tenant of the namespace. Namespaces which haven't been
+// seen by the watch yet are resolved by the client. TenantNotFoundError is
+// returned if the namespace has no tenant.
+func (t *Tenants) GetEDPName(namespace string) (*string, error) {
+	if edpN, ok := t.Get(namespace); ok {
+		return &edpN, nil
+	}
+
+	edpN, err := GetEDPName(t.client, namespace)
+	if err != nil {
+		if IsTenantNotFound(err) {
+			log.Info("Skip reconciling until tenant is set", "namespace", namespace, "reason", err.Error())
+		}
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.namespaces[namespace]; !ok {
+		t.namespaces[namespace] = *edpN
+	}
+	return edpN, nil
+}
+
+// Get returns tenant of the namespace known from the watch.
+func (t *Tenants) Get(namespace string) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	edpN, ok := t.namespaces[namespace]
+	return edpN, ok
+}
+
+// Namespaces returns sorted namespaces which have tenant.
+func (t *Tenants) Namespaces() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	ns := make([]string, 0, len(t.namespaces))
+	for n := range t.namespaces {
+		ns = append(ns, n)
+	}
+	sort.Strings(ns)
+	return ns
+}
+
+// EDPNames returns sorted tenants of the namespaces, each tenant once.
+func (t *Tenants) EDPNames() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	seen := map[string]bool{}
+	names := make([]string, 0, len(t.namespaces))
+	for _, edpN := range t.namespaces {
+		if !seen[edpN] {
+			seen[edpN] = true
+			names = append(names, edpN)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NamespacesOf returns sorted namespaces which share the tenant.
+func (t *Tenants) NamespacesOf(edpN string) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var ns []string
+	for n, e := range t.namespaces {
+		if e == edpN {
+			ns = append(ns, n)
+		}
+	}
+	sort.Strings(ns)
+	return ns
+}
+
+// Changes returns channel which is signalled when tenant of a namespace is
+// set or changed. The namespaces are returned by TakeChanged.
+func (t *Tenants) Changes() <-chan struct{} {
+	return t.signal
+}
+
+// TakeChanged returns sorted namespaces which tenant has changed since
+// the previous call.
+func (t *Tenants) TakeChanged() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ns := make([]string, 0, len(t.changed))
+	for n := range t.changed {
+		ns = append(ns, n)
+	}
+	t.changed = map[string]bool{}
+	sort.Strings(ns)
+	return ns
+}
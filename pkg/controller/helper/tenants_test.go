@@ -0,0 +1,105 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func edpConfig(ns, edpN string) *coreV1.ConfigMap {
+	return &coreV1.ConfigMap{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      EDPConfigCM,
+			Namespace: ns,
+		},
+		Data: map[string]string{
+			EDPNameKey: edpN,
+		},
+	}
+}
+
+func TestTenants_FollowConfigMapEvents(t *testing.T) {
+	// given
+	informers := &informertest.FakeInformers{}
+	tenants := NewTenants(fake.NewFakeClient(), nil)
+	assert.NoError(t, tenants.Watch(informers))
+	fi, err := informers.FakeInformerFor(&coreV1.ConfigMap{})
+	assert.NoError(t, err)
+
+	// when
+	fi.Add(edpConfig("team-b", "b"))
+	fi.Add(edpConfig("team-a", "a"))
+	fi.Add(&coreV1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{Name: "other", Namespace: "team-c"}})
+
+	// then
+	assert.Equal(t, []string{"team-a", "team-b"}, tenants.Namespaces())
+	edpN, ok := tenants.Get("team-a")
+	assert.True(t, ok)
+	assert.Equal(t, "a", edpN)
+
+	// when
+	fi.Update(edpConfig("team-a", "a"), edpConfig("team-a", ""))
+	fi.Delete(edpConfig("team-b", "b"))
+
+	// then
+	assert.Empty(t, tenants.Namespaces())
+}
+
+func TestTenants_GetEDPName(t *testing.T) {
+	// given
+	recorder := record.NewFakeRecorder(1)
+	tenants := NewTenants(fake.NewFakeClient(edpConfig("team-a", "a")), recorder)
+
+	// when
+	edpN, err := tenants.GetEDPName("team-a")
+	_, notFoundErr := tenants.GetEDPName("team-b")
+	tenants.set(edpConfig("team-c", ""))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "a", *edpN)
+	assert.True(t, IsTenantNotFound(notFoundErr))
+	assert.Contains(t, <-recorder.Events, ReasonEDPNameMissing)
+}
+
+func TestTenants_TakeChanged(t *testing.T) {
+	// given
+	tenants := NewTenants(fake.NewFakeClient(edpConfig("team-a", "a")), nil)
+	_, err := tenants.GetEDPName("team-a")
+	assert.NoError(t, err)
+
+	// when
+	tenants.set(edpConfig("team-a", "a"))
+	tenants.set(edpConfig("team-b", "b"))
+	tenants.set(edpConfig("team-a", "a2"))
+
+	// then
+	select {
+	case <-tenants.Changes():
+	default:
+		t.Fatal("change hasn't been signalled")
+	}
+	assert.Equal(t, []string{"team-a", "team-b"}, tenants.TakeChanged())
+	assert.Empty(t, tenants.TakeChanged())
+}
+
+func TestTenants_GroupNamespacesByTenant(t *testing.T) {
+	// given
+	tenants := NewTenants(fake.NewFakeClient(), nil)
+
+	// when
+	tenants.set(edpConfig("team-b", "shared"))
+	tenants.set(edpConfig("team-c", "c"))
+	tenants.set(edpConfig("team-a", "shared"))
+
+	// then
+	assert.Equal(t, []string{"c", "shared"}, tenants.EDPNames())
+	assert.Equal(t, []string{"team-a", "team-b"}, tenants.NamespacesOf("shared"))
+	assert.Equal(t, []string{"team-c"}, tenants.NamespacesOf("c"))
+	assert.Empty(t, tenants.NamespacesOf("unknown"))
+}
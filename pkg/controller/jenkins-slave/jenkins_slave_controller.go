@@ -2,14 +2,15 @@ package jenkins_slave
 
 import (
 	"context"
+	"database/sql"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
 	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
-	"github.com/epmd-edp/reconciler/v2/pkg/db"
+	"github.com/epmd-edp/reconciler/v2/pkg/metrics"
 	"github.com/epmd-edp/reconciler/v2/pkg/service/jenkins-slave"
 	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sort"
-	"time"
 
 	jenkinsV2Api "github.com/epmd-edp/jenkins-operator/v2/pkg/apis/v2/v1alpha1"
 	errWrap "github.com/pkg/errors"
@@ -30,18 +31,22 @@ var log = logf.Log.WithName("jenkins_slave_controller")
 * business logic.  Delete these comments after modifying this file.*
  */
 
+const controllerName = "jenkins-slave-controller"
+
 // Add creates a new JenkinsSlave Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) error {
+	return add(mgr, newReconciler(mgr, db, tenants))
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) reconcile.Reconciler {
 	return &ReconcileJenkinsSlave{
-		client: mgr.GetClient(),
+		client:  mgr.GetClient(),
+		tenants: tenants,
+		requeue: backoff.New(controllerName),
 		JenkinsSlaveService: jenkins_slave.JenkinsSlaveService{
-			DB: db.Instance,
+			DB: db,
 		},
 	}
 }
@@ -49,7 +54,7 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("jenkins-slave-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: metrics.Instrument(controllerName, r)})
 	if err != nil {
 		return err
 	}
@@ -88,6 +93,8 @@ var _ reconcile.Reconciler = &ReconcileJenkinsSlave{}
 // ReconcileJenkinsSlave reconciles a JenkinsCR object
 type ReconcileJenkinsSlave struct {
 	client              client.Client
+	tenants             *helper.Tenants
+	requeue             *backoff.Requeuer
 	JenkinsSlaveService jenkins_slave.JenkinsSlaveService
 }
 
@@ -106,24 +113,29 @@ func (r *ReconcileJenkinsSlave) Reconcile(request reconcile.Request) (reconcile.
 	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			r.requeue.Forget(request)
 			return reconcile.Result{}, nil
 		}
 		return reconcile.Result{}, err
 	}
+	r.requeue.Observe(request, instance.GetGeneration())
 
 	log.WithValues("Jenkins", instance)
 
 	cs := instance.Status.Slaves
 
-	edpN, err := helper.GetEDPName(r.client, instance.Namespace)
+	edpN, err := r.tenants.GetEDPName(instance.Namespace)
 	if err != nil {
+		if helper.IsTenantNotFound(err) {
+			return reconcile.Result{}, nil
+		}
 		return reconcile.Result{}, err
 	}
 	err = r.JenkinsSlaveService.CreateSlavesOrDoNothing(cs, *edpN)
 	if err != nil {
-		return reconcile.Result{RequeueAfter: time.Second * 120},
-			errWrap.Wrapf(err, "an error has occurred while adding {%v} slaves into DB", cs)
+		return r.requeue.Failed(request, errWrap.Wrapf(err, "an error has occurred while adding {%v} slaves into DB", cs)), nil
 	}
 
+	r.requeue.Forget(request)
 	return reconcile.Result{}, nil
 }
@@ -2,9 +2,12 @@ package jenkins_job
 
 import (
 	"context"
+	"database/sql"
 	jenv1alpha1 "github.com/epmd-edp/jenkins-operator/v2/pkg/apis/v2/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
 	"github.com/epmd-edp/reconciler/v2/pkg/controller/jenkins_job/service"
-	"github.com/epmd-edp/reconciler/v2/pkg/db"
+	"github.com/epmd-edp/reconciler/v2/pkg/metrics"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -16,7 +19,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 	"sigs.k8s.io/controller-runtime/pkg/source"
-	"time"
 )
 
 var (
@@ -24,21 +26,25 @@ var (
 	log                      = logf.Log.WithName("jenkins-job-controller")
 )
 
+const controllerName = "jenkins-job-controller"
+
 // Add creates a new Stage Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) error {
+	return add(mgr, newReconciler(mgr, db, tenants))
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) reconcile.Reconciler {
 	c := mgr.GetClient()
 	return &ReconcileJenkinsJob{
-		client: c,
-		scheme: mgr.GetScheme(),
+		client:  c,
+		scheme:  mgr.GetScheme(),
+		requeue: backoff.New(controllerName),
 		JenkinsJobService: service.JenkinsJobService{
-			DB:     db.Instance,
-			Client: c,
+			DB:      db,
+			Client:  c,
+			Tenants: tenants,
 		},
 	}
 }
@@ -46,7 +52,7 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("jenkins-job-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: metrics.Instrument(controllerName, r)})
 	if err != nil {
 		return err
 	}
@@ -75,6 +81,7 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 type ReconcileJenkinsJob struct {
 	client            client.Client
 	scheme            *runtime.Scheme
+	requeue           *backoff.Requeuer
 	JenkinsJobService service.JenkinsJobService
 }
 
@@ -84,15 +91,21 @@ func (r *ReconcileJenkinsJob) Reconcile(request reconcile.Request) (reconcile.Re
 	i := &jenv1alpha1.JenkinsJob{}
 	if err := r.client.Get(context.TODO(), request.NamespacedName, i); err != nil {
 		if k8serrors.IsNotFound(err) {
+			r.requeue.Forget(request)
 			return reconcile.Result{}, nil
 		}
 		return reconcile.Result{}, err
 	}
+	r.requeue.Observe(request, i.GetGeneration())
 
 	if err := r.JenkinsJobService.UpdateActionLog(i); err != nil {
-		return reconcile.Result{RequeueAfter: 5 * time.Second}, err
+		if helper.IsTenantNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return r.requeue.Failed(request, err), nil
 	}
 
+	r.requeue.Forget(request)
 	rl.V(2).Info("Reconciling JenkinsJob has been finished successfully")
 	return reconcile.Result{}, nil
 }
@@ -7,10 +7,13 @@ import (
 	"github.com/epmd-edp/cd-pipeline-operator/v2/pkg/apis/edp/v1alpha1"
 	jenv1alpha1 "github.com/epmd-edp/jenkins-operator/v2/pkg/apis/v2/v1alpha1"
 	"github.com/epmd-edp/jenkins-operator/v2/pkg/util/consts"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
 	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
-	"github.com/epmd-edp/reconciler/v2/pkg/db"
+	"github.com/epmd-edp/reconciler/v2/pkg/message"
 	"github.com/epmd-edp/reconciler/v2/pkg/model"
 	"github.com/epmd-edp/reconciler/v2/pkg/repository"
+	sr "github.com/epmd-edp/reconciler/v2/pkg/repository/stage"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
 	"github.com/epmd-edp/reconciler/v2/pkg/util/cluster"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/types"
@@ -20,15 +23,10 @@ import (
 
 const ErrorStatus = "error"
 
-var actions = map[string]string{
-	"platform_project_creation": "Create Platform Project for Stage %v",
-	"role_binding":              "Create Role Binding for project stage %v",
-	"create_jenkins_pipeline":   "Create Jenkins pipeline for CD Stage %v",
-}
-
 type JenkinsJobService struct {
-	DB     *sql.DB
-	Client client.Client
+	DB      *sql.DB
+	Client  client.Client
+	Tenants *helper.Tenants
 }
 
 var log = logf.Log.WithName("jenkins-job-service")
@@ -40,7 +38,7 @@ func (s JenkinsJobService) UpdateActionLog(jj *jenv1alpha1.JenkinsJob) error {
 		return err
 	}
 
-	edpN, err := helper.GetEDPName(s.Client, jj.Namespace)
+	edpN, err := s.Tenants.GetEDPName(jj.Namespace)
 	if err != nil {
 		return errors.Wrap(err, "cannot get edp name")
 	}
@@ -50,38 +48,23 @@ func (s JenkinsJobService) UpdateActionLog(jj *jenv1alpha1.JenkinsJob) error {
 		return err
 	}
 
-	tx, err := db.Instance.Begin()
-	if err != nil {
-		return err
-	}
-
-	p, err := repository.GetCDPipeline(*tx, stage.Spec.CdPipeline, *edpN)
-	if err != nil {
-		_ = tx.Rollback()
-		return errors.Wrapf(err, "cannot get CD Pipeline %v", stage.Spec.CdPipeline)
-	}
-
-	if p == nil {
-		_ = tx.Rollback()
-		return fmt.Errorf("cd pipeline %v is not inserted into table yet", stage.Spec.CdPipeline)
-	}
+	return postgres.WithTx(context.TODO(), s.DB, func(tx *sql.Tx) error {
+		id, err := sr.GetStageId(*tx, *edpN, stage.Spec.Name, stage.Spec.CdPipeline)
+		if err != nil {
+			return errors.Wrapf(err, "cannot get CD Stage %v", stage.Name)
+		}
 
-	alid, err := repository.CreateEventActionLog(*tx, *l, *edpN)
-	if err != nil {
-		_ = tx.Rollback()
-		return err
-	}
+		if id == nil {
+			return backoff.AsDependency(fmt.Errorf("cd stage %v is not inserted into table yet", stage.Name))
+		}
 
-	if err = repository.CreateCDPipelineActionLog(*tx, p.Id, *alid, *edpN); err != nil {
-		_ = tx.Rollback()
-		return err
-	}
-
-	if err := tx.Commit(); err != nil {
-		return err
-	}
-	log.V(2).Info("action log record has been added", "name", jj.Name)
-	return nil
+		stored, err := repository.PutCDStageActionLog(*tx, *id, *l, *edpN)
+		if err != nil {
+			return err
+		}
+		log.V(2).Info("action log record has been added", "name", jj.Name, "stored", stored)
+		return nil
+	})
 
 }
 
@@ -103,7 +86,7 @@ func (s JenkinsJobService) createActionLogModel(jj jenv1alpha1.JenkinsJob) (*mod
 	if err != nil {
 		return nil, err
 	}
-	l.ActionMessage = fmt.Sprintf(actions[string(st.Action)], stage.Name)
+	l.ActionMessage = message.Format(message.JenkinsJob, string(st.Action), message.Args{"name": stage.Name})
 	return l, nil
 }
 
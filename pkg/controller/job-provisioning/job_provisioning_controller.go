@@ -2,12 +2,13 @@ package job_provisioning
 
 import (
 	"context"
+	"database/sql"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
+	"github.com/epmd-edp/reconciler/v2/pkg/metrics"
 	"reflect"
 	"sort"
-	"time"
 
 	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
-	"github.com/epmd-edp/reconciler/v2/pkg/db"
 	jp "github.com/epmd-edp/reconciler/v2/pkg/service/job-provisioning"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -31,18 +32,22 @@ var log = logf.Log.WithName("job_provisioning_controller")
 * business logic.  Delete these comments after modifying this file.*
  */
 
+const controllerName = "job-provisioning-controller"
+
 // Add creates a new JobProvisioning Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) error {
+	return add(mgr, newReconciler(mgr, db, tenants))
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) reconcile.Reconciler {
 	return &ReconcileJobProvision{
-		client: mgr.GetClient(),
+		client:  mgr.GetClient(),
+		tenants: tenants,
+		requeue: backoff.New(controllerName),
 		JobProvisionService: jp.JobProvisionService{
-			DB: db.Instance,
+			DB: db,
 		},
 	}
 }
@@ -50,7 +55,7 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("job-provisioning-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: metrics.Instrument(controllerName, r)})
 	if err != nil {
 		return err
 	}
@@ -89,6 +94,8 @@ var _ reconcile.Reconciler = &ReconcileJobProvision{}
 // ReconcileJobProvisioning reconciles a JenkinsCR object
 type ReconcileJobProvision struct {
 	client              client.Client
+	tenants             *helper.Tenants
+	requeue             *backoff.Requeuer
 	JobProvisionService jp.JobProvisionService
 }
 
@@ -107,21 +114,26 @@ func (r *ReconcileJobProvision) Reconcile(request reconcile.Request) (reconcile.
 	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			r.requeue.Forget(request)
 			return reconcile.Result{}, nil
 		}
 		return reconcile.Result{}, err
 	}
+	r.requeue.Observe(request, instance.GetGeneration())
 
 	jp := instance.Status.JobProvisions
-	edpN, err := helper.GetEDPName(r.client, instance.Namespace)
+	edpN, err := r.tenants.GetEDPName(instance.Namespace)
 	if err != nil {
+		if helper.IsTenantNotFound(err) {
+			return reconcile.Result{}, nil
+		}
 		return reconcile.Result{}, err
 	}
 	err = r.JobProvisionService.PutJobProvisions(jp, *edpN)
 	if err != nil {
-		return reconcile.Result{RequeueAfter: time.Second * 120},
-			errWrap.Wrapf(err, "an error has occurred while adding {%v} job provisions into DB", jp)
+		return r.requeue.Failed(request, errWrap.Wrapf(err, "an error has occurred while adding {%v} job provisions into DB", jp)), nil
 	}
 
+	r.requeue.Forget(request)
 	return reconcile.Result{}, nil
 }
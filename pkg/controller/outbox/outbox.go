@@ -0,0 +1,129 @@
+package outbox
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
+	"github.com/epmd-edp/reconciler/v2/pkg/service/outbox"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+var log = logf.Log.WithName("outbox-publisher")
+
+// sinkTimeout limits a single delivery to the webhook.
+const sinkTimeout = 10 * time.Second
+
+var (
+	publishedEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_outbox_published_total",
+		Help: "Number of outbox events delivered to the sink by tenant",
+	}, []string{"tenant"})
+	publishFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_outbox_publish_failures_total",
+		Help: "Number of failed outbox publishing runs by tenant",
+	}, []string{"tenant"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(publishedEvents, publishFailures)
+}
+
+// Options configures publishing of outbox events.
+type Options struct {
+	// Sink is either http(s) URL of the webhook or file URL, e.g.
+	// "file:///tmp/events.jsonl". Publishing is disabled if it's empty.
+	Sink      string
+	Source    string
+	Period    time.Duration
+	BatchSize int
+}
+
+// Publisher periodically delivers outbox events of every tenant to the sink.
+type Publisher struct {
+	tenants *helper.Tenants
+	options Options
+	service outbox.PublisherService
+}
+
+// Add registers Publisher in the manager if sink is set.
+func Add(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants, o Options) error {
+	if o.Sink == "" {
+		return nil
+	}
+	if o.Period <= 0 {
+		return fmt.Errorf("outbox publishing period should be positive")
+	}
+	sink, err := NewSink(o.Sink)
+	if err != nil {
+		return err
+	}
+
+	return mgr.Add(&Publisher{
+		tenants: tenants,
+		options: o,
+		service: outbox.PublisherService{DB: db, Sink: sink, Source: o.Source},
+	})
+}
+
+// NewSink returns sink by its URL.
+func NewSink(sink string) (outbox.Sink, error) {
+	u, err := url.Parse(sink)
+	if err != nil {
+		return nil, fmt.Errorf("invalid outbox sink %q: %v", sink, err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return outbox.HTTPSink{URL: sink, Client: &http.Client{Timeout: sinkTimeout}}, nil
+	case "file":
+		if u.Path == "" {
+			return nil, fmt.Errorf("outbox sink %q has no file path", sink)
+		}
+		return &outbox.FileSink{Path: u.Path}, nil
+	default:
+		return nil, fmt.Errorf("unknown outbox sink %q, expected http(s) or file URL", sink)
+	}
+}
+
+func (p *Publisher) Start(stop <-chan struct{}) error {
+	t := time.NewTicker(p.options.Period)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-t.C:
+			p.publish()
+		}
+	}
+}
+
+// publish handles every tenant separately, so failure of one doesn't affect
+// the others.
+func (p *Publisher) publish() {
+	seen := map[string]bool{}
+	for _, ns := range p.tenants.Namespaces() {
+		edpN, ok := p.tenants.Get(ns)
+		if !ok || seen[edpN] {
+			continue
+		}
+		seen[edpN] = true
+
+		n, err := p.service.Publish(edpN, p.options.BatchSize)
+		publishedEvents.WithLabelValues(edpN).Add(float64(n))
+		if err != nil {
+			publishFailures.WithLabelValues(edpN).Inc()
+			log.Error(err, "Outbox events publishing has been failed", "tenant", edpN, "published", n)
+			continue
+		}
+		if n > 0 {
+			log.V(2).Info("Outbox events have been published", "tenant", edpN, "published", n)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package perfdatasource
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+
+	"github.com/epmd-edp/perf-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
+	"github.com/epmd-edp/reconciler/v2/pkg/metrics"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/perfdatasource"
+	dsService "github.com/epmd-edp/reconciler/v2/pkg/service/perfdatasource"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
+	"github.com/epmd-edp/reconciler/v2/pkg/util/cluster"
+	errWrap "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller_perf_data_source")
+
+const codebaseKind = "Codebase"
+
+// kind is a data source custom resource exposed by perf-operator.
+type kind struct {
+	// name is the lower-cased kind of the data source, e.g. "jenkins".
+	name    string
+	object  func() helper.Object
+	convert func(obj runtime.Object) perfdatasource.PerfDataSource
+}
+
+var kinds = []kind{
+	{
+		name:   "jenkins",
+		object: func() helper.Object { return &v1alpha1.PerfDataSourceJenkins{} },
+		convert: func(obj runtime.Object) perfdatasource.PerfDataSource {
+			return perfdatasource.ConvertJenkins(*obj.(*v1alpha1.PerfDataSourceJenkins))
+		},
+	},
+	{
+		name:   "sonar",
+		object: func() helper.Object { return &v1alpha1.PerfDataSourceSonar{} },
+		convert: func(obj runtime.Object) perfdatasource.PerfDataSource {
+			return perfdatasource.ConvertSonar(*obj.(*v1alpha1.PerfDataSourceSonar))
+		},
+	},
+	{
+		name:   "gitlab",
+		object: func() helper.Object { return &v1alpha1.PerfDataSourceGitLab{} },
+		convert: func(obj runtime.Object) perfdatasource.PerfDataSource {
+			return perfdatasource.ConvertGitLab(*obj.(*v1alpha1.PerfDataSourceGitLab))
+		},
+	},
+}
+
+func (k kind) controllerName() string {
+	return "perf-data-source-" + k.name + "-controller"
+}
+
+func (k kind) finalizerName() string {
+	return k.name + ".data.source.reconciler.finalizer.name"
+}
+
+// Add registers a controller per data source kind. Kinds the cluster doesn't
+// serve are skipped, as perf-operator may install only some of them.
+func Add(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) error {
+	for _, k := range kinds {
+		ok, err := served(mgr.GetRESTMapper(), mgr.GetScheme(), k.object())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			log.Info("data source kind isn't served by the cluster, skipping its controller", "kind", k.name)
+			continue
+		}
+		if err := add(mgr, k, newReconciler(mgr, db, tenants, k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// served tells whether the custom resource definition of the object is
+// installed in the cluster.
+func served(mapper meta.RESTMapper, scheme *runtime.Scheme, obj runtime.Object) (bool, error) {
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return false, err
+	}
+	if _, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func newReconciler(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants, k kind) reconcile.Reconciler {
+	return &ReconcilePerfDataSource{
+		client:  mgr.GetClient(),
+		tenants: tenants,
+		kind:    k,
+		sync:    helper.NewSyncReporter(mgr.GetClient(), mgr.GetRecorder(k.controllerName())),
+		requeue: backoff.New(k.controllerName()),
+		service: dsService.PerfDataSourceService{Storage: postgres.New(db)},
+	}
+}
+
+func add(mgr manager.Manager, k kind, r reconcile.Reconciler) error {
+	c, err := controller.New(k.controllerName(), mgr,
+		controller.Options{Reconciler: metrics.Instrument(k.controllerName(), r)})
+	if err != nil {
+		return err
+	}
+
+	p := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.MetaNew.GetDeletionTimestamp() != nil {
+				return true
+			}
+			return !reflect.DeepEqual(k.convert(e.ObjectOld), k.convert(e.ObjectNew))
+		},
+	}
+
+	if err = c.Watch(&source.Kind{Type: k.object()}, &handler.EnqueueRequestForObject{}, p); err != nil {
+		return err
+	}
+	return nil
+}
+
+var _ reconcile.Reconciler = &ReconcilePerfDataSource{}
+
+type ReconcilePerfDataSource struct {
+	client  client.Client
+	tenants *helper.Tenants
+	kind    kind
+	sync    helper.SyncReporter
+	requeue *backoff.Requeuer
+	service dsService.PerfDataSourceService
+}
+
+func (r *ReconcilePerfDataSource) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	rl := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name, "kind", r.kind.name)
+	rl.Info("Reconciling PerfDataSource")
+
+	i := r.kind.object()
+	if err := r.client.Get(context.TODO(), request.NamespacedName, i); err != nil {
+		if errors.IsNotFound(err) {
+			r.requeue.Forget(request)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	r.requeue.Observe(request, i.GetGeneration())
+
+	schema, err := r.tenants.GetEDPName(i.GetNamespace())
+	if err != nil {
+		if helper.IsTenantNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return r.requeue.Failed(request, errWrap.Wrap(err, "cannot get edp name")), nil
+	}
+
+	ds := r.kind.convert(i)
+	if ds.Codebase == "" {
+		if ow := cluster.GetOwnerReference(codebaseKind, i.GetOwnerReferences()); ow != nil {
+			ds.Codebase = ow.Name
+		}
+	}
+
+	result, err := r.tryToDeletePerfDataSource(i, ds, *schema)
+	if err != nil {
+		return r.requeue.Failed(request, errWrap.Wrapf(err, "cannot delete %v data source", r.kind.name)), nil
+	}
+	if result != nil {
+		r.requeue.Forget(request)
+		return *result, nil
+	}
+
+	if ds.Codebase == "" {
+		err := errWrap.Errorf("%v data source %v doesn't refer to codebase", r.kind.name, i.GetName())
+		r.sync.Failed(i, err)
+		return r.requeue.Failed(request, backoff.AsDependency(err)), nil
+	}
+
+	if err := r.service.PutPerfDataSource(ds, *schema); err != nil {
+		r.sync.Failed(i, err)
+		return r.requeue.Failed(request, errWrap.Wrapf(err, "cannot put %v data source %v", r.kind.name, i.GetName())), nil
+	}
+
+	r.requeue.Forget(request)
+	r.sync.Synced(i)
+	rl.Info("PerfDataSource reconciling has been finished successfully")
+	return reconcile.Result{}, nil
+}
+
+func (r *ReconcilePerfDataSource) tryToDeletePerfDataSource(i helper.Object, ds perfdatasource.PerfDataSource,
+	schema string) (*reconcile.Result, error) {
+	finalizers := i.GetFinalizers()
+	if i.GetDeletionTimestamp().IsZero() {
+		if !helper.ContainsString(finalizers, r.kind.finalizerName()) {
+			i.SetFinalizers(append(finalizers, r.kind.finalizerName()))
+			if err := r.client.Update(context.TODO(), i); err != nil {
+				return &reconcile.Result{}, err
+			}
+		}
+		return nil, nil
+	}
+
+	if ds.Codebase == "" {
+		log.Info("data source doesn't refer to codebase, nothing to remove", "kind", r.kind.name, "data source", i.GetName())
+	} else if err := r.service.RemoveCodebaseDataSource(ds.Codebase, ds.Type, schema); err != nil {
+		return &reconcile.Result{}, err
+	}
+
+	i.SetFinalizers(helper.RemoveString(finalizers, r.kind.finalizerName()))
+	if err := r.client.Update(context.TODO(), i); err != nil {
+		return &reconcile.Result{}, err
+	}
+	return &reconcile.Result{}, nil
+}
@@ -0,0 +1,26 @@
+package perfdatasource
+
+import (
+	"testing"
+
+	"github.com/epmd-edp/perf-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestServed_ShouldSkipKindsWithoutDefinition(t *testing.T) {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypes(v1alpha1.SchemeGroupVersion, &v1alpha1.PerfDataSourceJenkins{}, &v1alpha1.PerfDataSourceGitLab{})
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{v1alpha1.SchemeGroupVersion})
+	mapper.Add(v1alpha1.SchemeGroupVersion.WithKind("PerfDataSourceJenkins"), meta.RESTScopeNamespace)
+
+	ok, err := served(mapper, scheme, &v1alpha1.PerfDataSourceJenkins{})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = served(mapper, scheme, &v1alpha1.PerfDataSourceGitLab{})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -2,11 +2,14 @@ package perfserver
 
 import (
 	"context"
+	"database/sql"
 	"github.com/epmd-edp/perf-operator/v2/pkg/apis/edp/v1alpha1"
 	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
-	"github.com/epmd-edp/reconciler/v2/pkg/db"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/resync"
+	"github.com/epmd-edp/reconciler/v2/pkg/metrics"
 	perfServerModel "github.com/epmd-edp/reconciler/v2/pkg/model/perfserver"
 	"github.com/epmd-edp/reconciler/v2/pkg/service/perfserver"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -21,19 +24,22 @@ import (
 
 var log = logf.Log.WithName("controller_perf_server")
 
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+const controllerName = "perf-server-controller"
+
+func Add(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) error {
+	return add(mgr, newReconciler(mgr, db, tenants))
 }
 
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) reconcile.Reconciler {
 	return &ReconcilePerfServer{
 		client:      mgr.GetClient(),
-		perfService: perfserver.PerfServerService{DB: db.Instance},
+		tenants:     tenants,
+		perfService: perfserver.PerfServerService{Storage: postgres.New(db)},
 	}
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
-	c, err := controller.New("perf-server-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: metrics.Instrument(controllerName, r)})
 	if err != nil {
 		return err
 	}
@@ -55,6 +61,9 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	if err = c.Watch(&source.Kind{Type: &v1alpha1.PerfServer{}}, &handler.EnqueueRequestForObject{}, p); err != nil {
 		return err
 	}
+	if err = c.Watch(resync.Source(resync.PerfServer), &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -62,6 +71,7 @@ var _ reconcile.Reconciler = &ReconcilePerfServer{}
 
 type ReconcilePerfServer struct {
 	client      client.Client
+	tenants     *helper.Tenants
 	perfService perfserver.PerfServerService
 }
 
@@ -77,8 +87,11 @@ func (r *ReconcilePerfServer) Reconcile(request reconcile.Request) (reconcile.Re
 		return reconcile.Result{}, err
 	}
 
-	schema, err := helper.GetEDPName(r.client, i.Namespace)
+	schema, err := r.tenants.GetEDPName(i.Namespace)
 	if err != nil {
+		if helper.IsTenantNotFound(err) {
+			return reconcile.Result{}, nil
+		}
 		return reconcile.Result{}, err
 	}
 
@@ -0,0 +1,214 @@
+package resync
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	codebaseApi "github.com/epmd-edp/codebase-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
+	"github.com/epmd-edp/reconciler/v2/pkg/server"
+	"github.com/epmd-edp/reconciler/v2/pkg/service"
+	cdPipeService "github.com/epmd-edp/reconciler/v2/pkg/service/cd-pipeline"
+	"github.com/epmd-edp/reconciler/v2/pkg/service/codebasebranch"
+	"github.com/epmd-edp/reconciler/v2/pkg/service/resync"
+	stageService "github.com/epmd-edp/reconciler/v2/pkg/service/stage"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// DriftPath is the path drift report is served on.
+const DriftPath = "/drift"
+
+var driftRows = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "reconciler_drift_rows",
+	Help: "Number of DB rows which differ from custom resources by tenant, kind and drift type",
+}, []string{"tenant", "kind", "type"})
+
+func init() {
+	metrics.Registry.MustRegister(driftRows)
+}
+
+// collectors remove orphaned rows of the kind. Kinds without collector
+// are only reported.
+var collectors = map[string]func(st storage.Storage, key, schema string) error{
+	Codebase: func(st storage.Storage, key, schema string) error {
+		s := service.CodebaseService{Storage: st}
+		return s.Delete(&codebaseApi.Perf{}, key, schema)
+	},
+	CodebaseBranch: func(st storage.Storage, key, schema string) error {
+		cb, br := splitKey(key)
+		s := codebasebranch.CodebaseBranchService{Storage: st}
+		return s.Delete(cb, br, schema)
+	},
+	CDPipeline: func(st storage.Storage, key, schema string) error {
+		s := cdPipeService.CdPipelineService{Storage: st}
+		return s.DeleteCDPipeline(key, schema)
+	},
+	Stage: func(st storage.Storage, key, schema string) error {
+		pipe, name := splitKey(key)
+		s := stageService.StageService{Storage: st}
+		return s.DeleteCDStage(pipe, name, schema)
+	},
+}
+
+// DriftOptions configures drift detector.
+type DriftOptions struct {
+	Period        time.Duration
+	CollectOrphan bool
+}
+
+// DriftDetector periodically compares tenant schema with custom resources
+// and exposes the difference as JSON and Prometheus gauges.
+type DriftDetector struct {
+	client  client.Client
+	tenants *helper.Tenants
+	options DriftOptions
+	service resync.ResyncService
+	storage storage.Storage
+
+	mu      sync.RWMutex
+	reports []resync.DriftReport
+}
+
+// AddDriftDetector registers DriftDetector in the manager and serves its
+// report on the server if period is set.
+func AddDriftDetector(mgr manager.Manager, srv *server.Server, db *sql.DB, tenants *helper.Tenants, o DriftOptions) error {
+	if o.Period <= 0 {
+		return nil
+	}
+	d := &DriftDetector{
+		client:  mgr.GetClient(),
+		tenants: tenants,
+		options: o,
+		service: resync.ResyncService{DB: db},
+		storage: postgres.New(db),
+		reports: []resync.DriftReport{},
+	}
+	srv.Handle(DriftPath, d)
+	return mgr.Add(d)
+}
+
+func (d *DriftDetector) Start(stop <-chan struct{}) error {
+	t := time.NewTicker(d.options.Period)
+	defer t.Stop()
+	for {
+		d.detect()
+		select {
+		case <-stop:
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// detect compares every tenant separately, so failure of one doesn't
+// affect reports of the others. Namespaces sharing a tenant are compared
+// with its schema together, as its rows come from all of them.
+func (d *DriftDetector) detect() {
+	reports := []resync.DriftReport{}
+	driftRows.Reset()
+	for _, edpN := range d.tenants.EDPNames() {
+		namespaces := d.tenants.NamespacesOf(edpN)
+		rs, err := d.Detect(namespaces, edpN)
+		if err != nil {
+			log.Error(err, "Drift detection has been failed", "namespaces", namespaces, "tenant", edpN)
+			continue
+		}
+
+		for _, r := range rs {
+			driftRows.WithLabelValues(edpN, r.Kind, "missing").Set(float64(len(r.Missing)))
+			driftRows.WithLabelValues(edpN, r.Kind, "orphaned").Set(float64(len(r.Orphaned) - len(r.Collected)))
+			driftRows.WithLabelValues(edpN, r.Kind, "mismatched").Set(float64(len(r.Mismatches)))
+			if len(r.Missing)+len(r.Orphaned)+len(r.Mismatches) > 0 {
+				log.Info("Drift has been detected", "tenant", edpN, "kind", r.Kind, "missing", len(r.Missing),
+					"orphaned", len(r.Orphaned), "mismatched", len(r.Mismatches), "collected", len(r.Collected))
+			}
+		}
+		reports = append(reports, rs...)
+	}
+
+	d.mu.Lock()
+	d.reports = reports
+	d.mu.Unlock()
+}
+
+// Detect compares every watched kind of the namespaces with tenant schema
+// and removes orphaned rows if it's enabled. The namespaces are expected to
+// be all the namespaces of the tenant.
+func (d *DriftDetector) Detect(namespaces []string, edpN string) ([]resync.DriftReport, error) {
+	var reports []resync.DriftReport
+	for _, k := range kinds {
+		if !watched(k.name) {
+			continue
+		}
+
+		objs, err := listKind(d.client, namespaces, k)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't list %v resources", k.name)
+		}
+		cluster := make(map[string][]string, len(objs))
+		for _, o := range objs {
+			cluster[k.key(o)] = k.values(o)
+		}
+
+		rows, err := d.service.GetProjectedRows(k.fieldsQuery, edpN)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't get %v rows", k.name)
+		}
+
+		r := resync.Compare(k.name, k.fields, cluster, rows)
+		r.Tenant = edpN
+		reports = append(reports, r)
+	}
+
+	if d.options.CollectOrphan {
+		// dependent kinds go last in the list, so collect them first
+		for i := len(reports) - 1; i >= 0; i-- {
+			d.collect(&reports[i], edpN)
+		}
+	}
+	return reports, nil
+}
+
+func (d *DriftDetector) collect(r *resync.DriftReport, schema string) {
+	c, ok := collectors[r.Kind]
+	if !ok {
+		return
+	}
+	for _, key := range r.Orphaned {
+		if err := c(d.storage, key, schema); err != nil {
+			log.Error(err, "Couldn't remove orphaned row", "kind", r.Kind, "key", key)
+			continue
+		}
+		log.Info("Orphaned row has been removed", "kind", r.Kind, "key", key)
+		r.Collected = append(r.Collected, key)
+	}
+}
+
+// ServeHTTP writes the last drift report as JSON.
+func (d *DriftDetector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(d.reports); err != nil {
+		log.Error(err, "Couldn't write drift report")
+	}
+}
+
+func splitKey(key string) (string, string) {
+	i := strings.Index(key, "/")
+	if i < 0 {
+		return key, ""
+	}
+	return key[:i], key[i+1:]
+}
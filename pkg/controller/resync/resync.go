@@ -0,0 +1,300 @@
+package resync
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	cdPipeApi "github.com/epmd-edp/cd-pipeline-operator/v2/pkg/apis/edp/v1alpha1"
+	codebaseApi "github.com/epmd-edp/codebase-operator/v2/pkg/apis/edp/v1alpha1"
+	edpComponentApi "github.com/epmd-edp/edp-component-operator/pkg/apis/v1/v1alpha1"
+	perfApi "github.com/epmd-edp/perf-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
+	projection "github.com/epmd-edp/reconciler/v2/pkg/repository/resync"
+	"github.com/epmd-edp/reconciler/v2/pkg/service/resync"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+var log = logf.Log.WithName("resync")
+
+// Options configures when full resync is triggered.
+type Options struct {
+	OnStartup bool
+	Period    time.Duration
+}
+
+type kind struct {
+	name  string
+	query string
+	list  func() runtime.Object
+	key   func(o runtime.Object) string
+	// fields are compared by drift detector, fieldsQuery selects key and
+	// the fields from DB, values returns the fields of custom resource.
+	fields      []string
+	fieldsQuery string
+	values      func(o runtime.Object) []string
+}
+
+var kinds = []kind{
+	{
+		name:        GitServer,
+		query:       projection.SelectGitServerKeys,
+		list:        func() runtime.Object { return &codebaseApi.GitServerList{} },
+		key:         func(o runtime.Object) string { return o.(*codebaseApi.GitServer).Name },
+		fields:      []string{"hostname"},
+		fieldsQuery: projection.SelectGitServerFields,
+		values: func(o runtime.Object) []string {
+			return []string{o.(*codebaseApi.GitServer).Spec.GitHost}
+		},
+	},
+	{
+		name:        JiraServer,
+		query:       projection.SelectJiraServerKeys,
+		list:        func() runtime.Object { return &codebaseApi.JiraServerList{} },
+		key:         func(o runtime.Object) string { return o.(*codebaseApi.JiraServer).Name },
+		fields:      []string{"available"},
+		fieldsQuery: projection.SelectJiraServerFields,
+		values: func(o runtime.Object) []string {
+			return []string{strconv.FormatBool(o.(*codebaseApi.JiraServer).Status.Available)}
+		},
+	},
+	{
+		name:        PerfServer,
+		query:       projection.SelectPerfServerKeys,
+		list:        func() runtime.Object { return &perfApi.PerfServerList{} },
+		key:         func(o runtime.Object) string { return o.(*perfApi.PerfServer).Name },
+		fields:      []string{"available"},
+		fieldsQuery: projection.SelectPerfServerFields,
+		values: func(o runtime.Object) []string {
+			return []string{strconv.FormatBool(o.(*perfApi.PerfServer).Status.Available)}
+		},
+	},
+	{
+		name:        Service,
+		query:       projection.SelectServiceKeys,
+		list:        func() runtime.Object { return &codebaseApi.ServiceList{} },
+		key:         func(o runtime.Object) string { return o.(*codebaseApi.Service).Name },
+		fields:      []string{"version", "url"},
+		fieldsQuery: projection.SelectServiceFields,
+		values: func(o runtime.Object) []string {
+			s := o.(*codebaseApi.Service).Spec
+			return []string{s.Version, s.Url}
+		},
+	},
+	{
+		name:        EDPComponent,
+		query:       projection.SelectEDPComponentKeys,
+		list:        func() runtime.Object { return &edpComponentApi.EDPComponentList{} },
+		key:         func(o runtime.Object) string { return o.(*edpComponentApi.EDPComponent).Spec.Type },
+		fields:      []string{"url", "visible"},
+		fieldsQuery: projection.SelectEDPComponentFields,
+		values: func(o runtime.Object) []string {
+			s := o.(*edpComponentApi.EDPComponent).Spec
+			return []string{s.Url, strconv.FormatBool(s.Visible)}
+		},
+	},
+	{
+		name:        Codebase,
+		query:       projection.SelectCodebaseKeys,
+		list:        func() runtime.Object { return &codebaseApi.CodebaseList{} },
+		key:         func(o runtime.Object) string { return o.(*codebaseApi.Codebase).Name },
+		fields:      []string{"status", "commit_message_pattern", "ticket_name_pattern", "default_branch"},
+		fieldsQuery: projection.SelectCodebaseFields,
+		values: func(o runtime.Object) []string {
+			c := o.(*codebaseApi.Codebase)
+			return []string{c.Status.Value, stringOrEmpty(c.Spec.CommitMessagePattern),
+				stringOrEmpty(c.Spec.TicketNamePattern), c.Spec.DefaultBranch}
+		},
+	},
+	{
+		name:  CodebaseBranch,
+		query: projection.SelectCodebaseBranchKeys,
+		list:  func() runtime.Object { return &codebaseApi.CodebaseBranchList{} },
+		key: func(o runtime.Object) string {
+			s := o.(*codebaseApi.CodebaseBranch).Spec
+			return s.CodebaseName + "/" + s.BranchName
+		},
+		fields:      []string{"status"},
+		fieldsQuery: projection.SelectCodebaseBranchFields,
+		values: func(o runtime.Object) []string {
+			return []string{o.(*codebaseApi.CodebaseBranch).Status.Value}
+		},
+	},
+	{
+		name:        CDPipeline,
+		query:       projection.SelectCDPipelineKeys,
+		list:        func() runtime.Object { return &cdPipeApi.CDPipelineList{} },
+		key:         func(o runtime.Object) string { return o.(*cdPipeApi.CDPipeline).Spec.Name },
+		fields:      []string{"status"},
+		fieldsQuery: projection.SelectCDPipelineFields,
+		values: func(o runtime.Object) []string {
+			return []string{o.(*cdPipeApi.CDPipeline).Status.Value}
+		},
+	},
+	{
+		name:  Stage,
+		query: projection.SelectStageKeys,
+		list:  func() runtime.Object { return &cdPipeApi.StageList{} },
+		key: func(o runtime.Object) string {
+			s := o.(*cdPipeApi.Stage).Spec
+			return s.CdPipeline + "/" + s.Name
+		},
+		fields:      []string{"status"},
+		fieldsQuery: projection.SelectStageFields,
+		values: func(o runtime.Object) []string {
+			return []string{o.(*cdPipeApi.Stage).Status.Value}
+		},
+	},
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// Resyncer periodically lists custom resources and pushes them to the
+// controllers, so DB projection is rebuilt even if no resource has changed.
+// Every tenant is resynced separately, so failure of one doesn't affect
+// the others. Custom resources of a tenant are also resynced once it's set
+// or changed for a namespace.
+type Resyncer struct {
+	client  client.Client
+	tenants *helper.Tenants
+	options Options
+	service resync.ResyncService
+}
+
+// Add registers Resyncer in the manager.
+func Add(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants, o Options) error {
+	return mgr.Add(&Resyncer{
+		client:  mgr.GetClient(),
+		tenants: tenants,
+		options: o,
+		service: resync.ResyncService{DB: db},
+	})
+}
+
+func (r *Resyncer) Start(stop <-chan struct{}) error {
+	// controllers reconcile every resource once the cache is synced,
+	// so tenants found so far need no resync
+	select {
+	case <-r.tenants.Changes():
+	default:
+	}
+	r.tenants.TakeChanged()
+
+	if r.options.OnStartup {
+		r.resync(stop)
+	}
+
+	var tick <-chan time.Time
+	if r.options.Period > 0 {
+		t := time.NewTicker(r.options.Period)
+		defer t.Stop()
+		tick = t.C
+	}
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-tick:
+			r.resync(stop)
+		case <-r.tenants.Changes():
+			seen := map[string]bool{}
+			for _, ns := range r.tenants.TakeChanged() {
+				edpN, ok := r.tenants.Get(ns)
+				if !ok || seen[edpN] {
+					continue
+				}
+				seen[edpN] = true
+				r.resyncTenant(edpN, stop)
+			}
+		}
+	}
+}
+
+func (r *Resyncer) resync(stop <-chan struct{}) {
+	for _, edpN := range r.tenants.EDPNames() {
+		r.resyncTenant(edpN, stop)
+	}
+}
+
+// resyncTenant resyncs all the namespaces of the tenant at once, as rows of
+// its schema come from all of them.
+func (r *Resyncer) resyncTenant(edpN string, stop <-chan struct{}) {
+	namespaces := r.tenants.NamespacesOf(edpN)
+	log.Info("Start full resync", "namespaces", namespaces, "tenant", edpN)
+	reports, err := r.Resync(namespaces, edpN, stop)
+	if err != nil {
+		log.Error(err, "Full resync has been failed", "namespaces", namespaces, "tenant", edpN)
+		return
+	}
+	for _, rep := range reports {
+		log.Info("Resync report", "tenant", edpN, "kind", rep.Kind, "created", len(rep.Created),
+			"existing", len(rep.Existing), "orphaned", len(rep.Orphaned), "orphanedRows", rep.Orphaned)
+	}
+}
+
+// Resync enqueues every watched custom resource of the namespaces to its
+// controller and returns difference between the cluster and tenant schema
+// found before the resync. The namespaces are expected to be all the
+// namespaces of the tenant.
+func (r *Resyncer) Resync(namespaces []string, edpN string, stop <-chan struct{}) ([]resync.Report, error) {
+	var reports []resync.Report
+	for _, k := range kinds {
+		if !watched(k.name) {
+			continue
+		}
+
+		objs, err := listKind(r.client, namespaces, k)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't list %v resources", k.name)
+		}
+
+		dbKeys, err := r.service.GetProjectedKeys(k.query, edpN)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't get %v rows", k.name)
+		}
+
+		crKeys := make([]string, 0, len(objs))
+		for _, o := range objs {
+			crKeys = append(crKeys, k.key(o))
+			m, err := meta.Accessor(o)
+			if err != nil {
+				return nil, err
+			}
+			select {
+			case channel(k.name) <- event.GenericEvent{Meta: m, Object: o}:
+			case <-stop:
+				return reports, nil
+			}
+		}
+		reports = append(reports, resync.Diff(k.name, crKeys, dbKeys))
+	}
+	return reports, nil
+}
+
+func listKind(c client.Client, namespaces []string, k kind) ([]runtime.Object, error) {
+	var objs []runtime.Object
+	for _, ns := range namespaces {
+		l := k.list()
+		if err := c.List(context.TODO(), &client.ListOptions{Namespace: ns}, l); err != nil {
+			return nil, err
+		}
+		items, err := meta.ExtractList(l)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, items...)
+	}
+	return objs, nil
+}
@@ -0,0 +1,52 @@
+package resync
+
+import (
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	Codebase       = "Codebase"
+	CodebaseBranch = "CodebaseBranch"
+	CDPipeline     = "CDPipeline"
+	Stage          = "Stage"
+	GitServer      = "GitServer"
+	JiraServer     = "JiraServer"
+	PerfServer     = "PerfServer"
+	Service        = "Service"
+	EDPComponent   = "EDPComponent"
+)
+
+var (
+	mu       sync.Mutex
+	channels = map[string]chan event.GenericEvent{}
+)
+
+// Source returns a source controllers watch to receive resync events
+// for the kind. Resync events are generic ones, so they bypass update
+// predicates of the controllers.
+func Source(kind string) source.Source {
+	return &source.Channel{Source: channel(kind)}
+}
+
+func channel(kind string) chan event.GenericEvent {
+	mu.Lock()
+	defer mu.Unlock()
+
+	ch, ok := channels[kind]
+	if !ok {
+		ch = make(chan event.GenericEvent)
+		channels[kind] = ch
+	}
+	return ch
+}
+
+func watched(kind string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	_, ok := channels[kind]
+	return ok
+}
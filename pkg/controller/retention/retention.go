@@ -0,0 +1,125 @@
+package retention
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
+	"github.com/epmd-edp/reconciler/v2/pkg/service/retention"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+var log = logf.Log.WithName("action-log-retention")
+
+// Archive targets of pruned action logs.
+const (
+	ArchiveNone  = ""
+	ArchiveTable = "table"
+	ArchiveFile  = "file"
+)
+
+var (
+	prunedLogs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_action_log_pruned_total",
+		Help: "Number of action logs pruned by retention policy by tenant",
+	}, []string{"tenant"})
+	archivedLogs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_action_log_archived_total",
+		Help: "Number of pruned action logs archived by tenant and archive target",
+	}, []string{"tenant", "target"})
+	pruneFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_action_log_prune_failures_total",
+		Help: "Number of failed action log retention runs by tenant",
+	}, []string{"tenant"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(prunedLogs, archivedLogs, pruneFailures)
+}
+
+// Options configures action log retention.
+type Options struct {
+	Period     time.Duration
+	Policy     retention.Policy
+	Archive    string
+	ArchiveDir string
+}
+
+// Pruner periodically removes action logs of every tenant which are out of
+// retention policy.
+type Pruner struct {
+	tenants *helper.Tenants
+	options Options
+	service retention.RetentionService
+}
+
+// Add registers Pruner in the manager if period is set.
+func Add(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants, o Options) error {
+	if o.Period <= 0 {
+		return nil
+	}
+
+	s := retention.RetentionService{DB: db}
+	switch o.Archive {
+	case ArchiveNone:
+	case ArchiveTable:
+		s.Archiver = retention.TableArchiver{}
+	case ArchiveFile:
+		if o.ArchiveDir == "" {
+			return fmt.Errorf("archive directory is required to archive action logs to files")
+		}
+		s.Archiver = retention.FileArchiver{Dir: o.ArchiveDir}
+	default:
+		return fmt.Errorf("unknown action log archive %q, expected %q or %q", o.Archive, ArchiveTable, ArchiveFile)
+	}
+
+	return mgr.Add(&Pruner{
+		tenants: tenants,
+		options: o,
+		service: s,
+	})
+}
+
+func (p *Pruner) Start(stop <-chan struct{}) error {
+	t := time.NewTicker(p.options.Period)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-t.C:
+			p.prune()
+		}
+	}
+}
+
+// prune handles every tenant separately, so failure of one doesn't affect
+// the others.
+func (p *Pruner) prune() {
+	seen := map[string]bool{}
+	for _, ns := range p.tenants.Namespaces() {
+		edpN, ok := p.tenants.Get(ns)
+		if !ok || seen[edpN] {
+			continue
+		}
+		seen[edpN] = true
+
+		n, err := p.service.Prune(edpN, p.options.Policy, time.Now())
+		prunedLogs.WithLabelValues(edpN).Add(float64(n))
+		if p.options.Archive != ArchiveNone {
+			archivedLogs.WithLabelValues(edpN, p.options.Archive).Add(float64(n))
+		}
+		if err != nil {
+			pruneFailures.WithLabelValues(edpN).Inc()
+			log.Error(err, "Action log pruning has been failed", "tenant", edpN, "pruned", n)
+			continue
+		}
+		if n > 0 {
+			log.Info("Action logs have been pruned", "tenant", edpN, "pruned", n)
+		}
+	}
+}
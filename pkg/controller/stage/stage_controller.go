@@ -2,17 +2,21 @@ package stage
 
 import (
 	"context"
+	"database/sql"
 	edpV1alpha1 "github.com/epmd-edp/cd-pipeline-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/dependency"
 	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
-	"github.com/epmd-edp/reconciler/v2/pkg/db"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/resync"
+	"github.com/epmd-edp/reconciler/v2/pkg/metrics"
 	"github.com/epmd-edp/reconciler/v2/pkg/model/stage"
 	"github.com/epmd-edp/reconciler/v2/pkg/platform"
 	stage2 "github.com/epmd-edp/reconciler/v2/pkg/service/stage"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
 	"github.com/pkg/errors"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
-	"time"
 
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -30,24 +34,29 @@ var (
 	log                      = logf.Log.WithName("controller_stage")
 )
 
+const controllerName = "stage-controller"
+
 // Add creates a new Stage Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) error {
+	return add(mgr, newReconciler(mgr, db, tenants))
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) reconcile.Reconciler {
 	clientSet, err := platform.CreateOpenshiftClients()
 	if err != nil {
 		panic(err)
 	}
 
 	return &ReconcileStage{
-		client: mgr.GetClient(),
-		scheme: mgr.GetScheme(),
+		client:  mgr.GetClient(),
+		tenants: tenants,
+		scheme:  mgr.GetScheme(),
+		sync:    helper.NewSyncReporter(mgr.GetClient(), mgr.GetRecorder(controllerName)),
+		requeue: backoff.New(controllerName),
 		service: stage2.StageService{
-			DB:        db.Instance,
+			Storage:   postgres.New(db),
 			ClientSet: *clientSet,
 		},
 	}
@@ -56,7 +65,7 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("stage-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: metrics.Instrument(controllerName, r)})
 	if err != nil {
 		return err
 	}
@@ -85,6 +94,16 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	err = c.Watch(resync.Source(resync.Stage), &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	err = dependency.Watch(mgr, c, dependency.Stages, dependency.NextStages)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -92,7 +111,10 @@ const stageReconcilerFinalizerName = "stage.reconciler.finalizer.name"
 
 type ReconcileStage struct {
 	client  client.Client
+	tenants *helper.Tenants
 	scheme  *runtime.Scheme
+	sync    helper.SyncReporter
+	requeue *backoff.Requeuer
 	service stage2.StageService
 }
 
@@ -102,28 +124,43 @@ func (r *ReconcileStage) Reconcile(request reconcile.Request) (reconcile.Result,
 	i := &edpV1alpha1.Stage{}
 	if err := r.client.Get(context.TODO(), request.NamespacedName, i); err != nil {
 		if k8serrors.IsNotFound(err) {
+			r.requeue.Forget(request)
 			return reconcile.Result{}, nil
 		}
 		return reconcile.Result{}, err
 	}
+	r.requeue.Observe(request, i.GetGeneration())
 
-	edpN, err := helper.GetEDPName(r.client, i.Namespace)
+	edpN, err := r.tenants.GetEDPName(i.Namespace)
 	if err != nil {
-		return reconcile.Result{RequeueAfter: 2 * time.Second}, errors.Wrap(err, "cannot get edp name")
+		if helper.IsTenantNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return r.requeue.Failed(request, errors.Wrap(err, "cannot get edp name")), nil
 	}
 
-	if res, err := r.tryToDeleteCDStage(i, *edpN); err != nil || res != nil {
-		return *res, err
+	res, err := r.tryToDeleteCDStage(i, *edpN)
+	if err != nil {
+		return r.requeue.Failed(request, errors.Wrap(err, "couldn't delete stage")), nil
+	}
+	if res != nil {
+		r.requeue.Forget(request)
+		return *res, nil
 	}
 
 	st, err := stage.ConvertToStage(*i, *edpN)
 	if err != nil {
-		return reconcile.Result{RequeueAfter: 2 * time.Second}, errors.Wrap(err, "couldn't convert to stage dto")
+		r.sync.Failed(i, err)
+		return r.requeue.Failed(request, backoff.AsPermanent(errors.Wrap(err, "couldn't convert to stage dto"))), nil
 	}
 
 	if err = r.service.PutStage(*st); err != nil {
-		return reconcile.Result{RequeueAfter: 2 * time.Second}, errors.Wrap(err, "couldn't put stage")
+		r.sync.Failed(i, err)
+		return r.requeue.Failed(request, errors.Wrap(err, "couldn't put stage")), nil
 	}
+	r.requeue.Forget(request)
+	r.sync.Synced(i)
+	dependency.Committed(resync.Stage, i)
 	rl.V(2).Info("Reconciling has been finished successfully")
 	return reconcile.Result{}, nil
 }
@@ -140,12 +177,12 @@ func (r ReconcileStage) tryToDeleteCDStage(i *edpV1alpha1.Stage, schema string)
 	}
 
 	if err := r.service.DeleteCDStage(i.Spec.CdPipeline, i.Spec.Name, schema); err != nil {
-		return &reconcile.Result{RequeueAfter: 2 * time.Second}, err
+		return nil, err
 	}
 
 	i.ObjectMeta.Finalizers = helper.RemoveString(i.ObjectMeta.Finalizers, stageReconcilerFinalizerName)
 	if err := r.client.Update(context.TODO(), i); err != nil {
-		return &reconcile.Result{RequeueAfter: 2 * time.Second}, err
+		return nil, err
 	}
 	return &reconcile.Result{}, nil
 }
@@ -0,0 +1,125 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
+	"github.com/epmd-edp/reconciler/v2/pkg/metrics"
+	"github.com/epmd-edp/reconciler/v2/pkg/service/migration"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	recorderName = "edp-reconciler"
+
+	reasonBootstrapped    = "TenantBootstrapped"
+	reasonMigrated        = "TenantMigrated"
+	reasonBootstrapFailed = "TenantBootstrapFailed"
+)
+
+var log = logf.Log.WithName("controller_tenant")
+
+const controllerName = "tenant-controller"
+
+// Add creates a new tenant Controller which watches edp-config config map and
+// keeps schema of the EDP installation created and up to date.
+func Add(mgr manager.Manager, db *sql.DB, _ *helper.Tenants) error {
+	return add(mgr, newReconciler(mgr, db))
+}
+
+func newReconciler(mgr manager.Manager, db *sql.DB) reconcile.Reconciler {
+	return &ReconcileTenant{
+		client:           mgr.GetClient(),
+		recorder:         mgr.GetRecorder(recorderName),
+		migrationService: migration.MigrationService{DB: db},
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: metrics.Instrument(controllerName, r)})
+	if err != nil {
+		return err
+	}
+
+	p := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return e.Meta.GetName() == helper.EDPConfigCM
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.MetaNew.GetName() != helper.EDPConfigCM {
+				return false
+			}
+			oldObject := e.ObjectOld.(*v1.ConfigMap)
+			newObject := e.ObjectNew.(*v1.ConfigMap)
+			return oldObject.Data[helper.EDPNameKey] != newObject.Data[helper.EDPNameKey]
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return e.Meta.GetName() == helper.EDPConfigCM
+		},
+	}
+
+	if err = c.Watch(&source.Kind{Type: &v1.ConfigMap{}}, &handler.EnqueueRequestForObject{}, p); err != nil {
+		return err
+	}
+	return nil
+}
+
+var _ reconcile.Reconciler = &ReconcileTenant{}
+
+type ReconcileTenant struct {
+	client           client.Client
+	recorder         record.EventRecorder
+	migrationService migration.MigrationService
+}
+
+func (r *ReconcileTenant) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	rl := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	rl.Info("Reconciling tenant")
+
+	cm := &v1.ConfigMap{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, cm); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	edpN := cm.Data[helper.EDPNameKey]
+	if edpN == "" {
+		rl.Info("edp_name is not set in config map. Skip bootstrapping")
+		return reconcile.Result{}, nil
+	}
+
+	res, err := r.migrationService.Bootstrap(edpN)
+	if err != nil {
+		r.recorder.Event(cm, v1.EventTypeWarning, reasonBootstrapFailed,
+			fmt.Sprintf("Couldn't bootstrap schema %v: %v", edpN, err))
+		return reconcile.Result{}, err
+	}
+
+	if res.Created {
+		r.recorder.Event(cm, v1.EventTypeNormal, reasonBootstrapped,
+			fmt.Sprintf("Schema %v has been created, %v migrations applied", edpN, res.Applied))
+	} else if res.Applied > 0 {
+		r.recorder.Event(cm, v1.EventTypeNormal, reasonMigrated,
+			fmt.Sprintf("Schema %v has been upgraded, %v migrations applied", edpN, res.Applied))
+	}
+
+	rl.Info("Tenant reconciling has been finished successfully", "tenant", edpN)
+	return reconcile.Result{}, nil
+}
@@ -2,9 +2,11 @@ package thirdpartyservice
 
 import (
 	"context"
+	"database/sql"
 	edpv1alpha1Codebase "github.com/epmd-edp/codebase-operator/v2/pkg/apis/edp/v1alpha1"
 	"github.com/epmd-edp/reconciler/v2/pkg/controller/helper"
-	"github.com/epmd-edp/reconciler/v2/pkg/db"
+	"github.com/epmd-edp/reconciler/v2/pkg/controller/resync"
+	"github.com/epmd-edp/reconciler/v2/pkg/metrics"
 	dtoService "github.com/epmd-edp/reconciler/v2/pkg/model/service"
 	tps "github.com/epmd-edp/reconciler/v2/pkg/service/thirdpartyservice"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -21,16 +23,19 @@ import (
 
 var log = logf.Log.WithName("service_controller")
 
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+const controllerName = "thirdpartyservice-controller"
+
+func Add(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) error {
+	return add(mgr, newReconciler(mgr, db, tenants))
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, db *sql.DB, tenants *helper.Tenants) reconcile.Reconciler {
 	return &ReconcileService{
-		client: mgr.GetClient(),
+		client:  mgr.GetClient(),
+		tenants: tenants,
 		tps: tps.ThirdPartyService{
-			DB: db.Instance,
+			DB: db,
 		},
 	}
 }
@@ -38,7 +43,7 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("thirdpartyservice-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: metrics.Instrument(controllerName, r)})
 	if err != nil {
 		return err
 	}
@@ -54,14 +59,20 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	err = c.Watch(resync.Source(resync.Service), &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
 var _ reconcile.Reconciler = &ReconcileService{}
 
 type ReconcileService struct {
-	client client.Client
-	tps    tps.ThirdPartyService
+	client  client.Client
+	tenants *helper.Tenants
+	tps     tps.ThirdPartyService
 }
 
 func (r *ReconcileService) Reconcile(request reconcile.Request) (reconcile.Result, error) {
@@ -76,8 +87,11 @@ func (r *ReconcileService) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, err
 	}
 
-	edpName, err := helper.GetEDPName(r.client, instance.Namespace)
+	edpName, err := r.tenants.GetEDPName(instance.Namespace)
 	if err != nil {
+		if helper.IsTenantNotFound(err) {
+			return reconcile.Result{}, nil
+		}
 		return reconcile.Result{}, err
 	}
 
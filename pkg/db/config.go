@@ -0,0 +1,169 @@
+package db
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Config describes connection to the reconciler database. It is filled from
+// environment variables, command line flags and files of a mounted secret,
+// see ConfigFromEnv, BindFlags and LoadDir.
+type Config struct {
+	Host        string
+	Port        string
+	Name        string
+	User        string
+	Password    string
+	SSLMode     string
+	SSLRootCert string
+
+	MaxOpenConns     int
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration
+	StatementTimeout time.Duration
+	// ConnectTimeout limits how long Open retries to reach the database.
+	ConnectTimeout time.Duration
+}
+
+// DefaultConfig returns config with defaults of the optional settings.
+func DefaultConfig() Config {
+	return Config{
+		SSLMode:         "disable",
+		MaxOpenConns:    5,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnectTimeout:  2 * time.Minute,
+	}
+}
+
+// ConfigFromEnv returns default config overridden by DB_* environment variables.
+func ConfigFromEnv() (Config, error) {
+	c := DefaultConfig()
+	return c, c.load(os.LookupEnv)
+}
+
+// LoadDir overrides the config with files named after the environment
+// variables, e.g. DB_PASS, found in the dir. It is intended for secrets
+// mounted as volumes. Missing files are skipped.
+func (c *Config) LoadDir(dir string) error {
+	return c.load(func(key string) (string, bool) {
+		b, err := ioutil.ReadFile(filepath.Join(dir, key))
+		if err != nil {
+			return "", false
+		}
+		return strings.TrimSpace(string(b)), true
+	})
+}
+
+// BindFlags registers flags of the config with its current values as
+// defaults. Password has no flag so it never appears in process list.
+func (c *Config) BindFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&c.Host, "db-host", c.Host, "Database host")
+	fs.StringVar(&c.Port, "db-port", c.Port, "Database port")
+	fs.StringVar(&c.Name, "db-name", c.Name, "Database name")
+	fs.StringVar(&c.User, "db-user", c.User, "Database user")
+	fs.StringVar(&c.SSLMode, "db-ssl-mode", c.SSLMode, "Database SSL mode")
+	fs.StringVar(&c.SSLRootCert, "db-ssl-root-cert", c.SSLRootCert, "Path to root CA certificate of the database")
+	fs.IntVar(&c.MaxOpenConns, "db-max-open-conn", c.MaxOpenConns, "Maximum number of open connections")
+	fs.IntVar(&c.MaxIdleConns, "db-max-idle-conn", c.MaxIdleConns, "Maximum number of idle connections")
+	fs.DurationVar(&c.ConnMaxLifetime, "db-conn-max-lifetime", c.ConnMaxLifetime,
+		"Maximum time a connection is reused, zero keeps connections forever")
+	fs.DurationVar(&c.StatementTimeout, "db-statement-timeout", c.StatementTimeout,
+		"Statement timeout of the database sessions, zero disables it")
+	fs.DurationVar(&c.ConnectTimeout, "db-connect-timeout", c.ConnectTimeout,
+		"How long to retry connecting to the database on start")
+}
+
+// Validate checks all the connection settings are set.
+func (c Config) Validate() error {
+	var missing []string
+	for key, v := range map[string]string{
+		"DB_HOST": c.Host, "DB_PORT": c.Port, "DB_NAME": c.Name, "DB_USER": c.User, "DB_PASS": c.Password,
+	} {
+		if v == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("database settings %v are missing", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// DataSourceName returns lib/pq connection string of the config.
+func (c Config) DataSourceName() string {
+	params := []string{
+		"host=" + quote(c.Host),
+		"port=" + quote(c.Port),
+		"dbname=" + quote(c.Name),
+		"user=" + quote(c.User),
+		"password=" + quote(c.Password),
+		"sslmode=" + quote(c.SSLMode),
+		"application_name=Reconciler",
+	}
+	if c.SSLRootCert != "" {
+		params = append(params, "sslrootcert="+quote(c.SSLRootCert))
+	}
+	if c.StatementTimeout > 0 {
+		params = append(params, fmt.Sprintf("statement_timeout=%d", c.StatementTimeout.Milliseconds()))
+	}
+	return strings.Join(params, " ")
+}
+
+func (c *Config) load(lookup func(string) (string, bool)) error {
+	for key, p := range map[string]*string{
+		"DB_HOST":          &c.Host,
+		"DB_PORT":          &c.Port,
+		"DB_NAME":          &c.Name,
+		"DB_USER":          &c.User,
+		"DB_PASS":          &c.Password,
+		"DB_SSL_MODE":      &c.SSLMode,
+		"DB_SSL_ROOT_CERT": &c.SSLRootCert,
+	} {
+		if v, ok := lookup(key); ok {
+			*p = v
+		}
+	}
+	for key, p := range map[string]*int{
+		"DB_MAX_OPEN_CONN": &c.MaxOpenConns,
+		"DB_MAX_IDLE_CONN": &c.MaxIdleConns,
+	} {
+		if v, ok := lookup(key); ok {
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("cannot convert %v value %v to int", key, v)
+			}
+			*p = i
+		}
+	}
+	for key, p := range map[string]*time.Duration{
+		"DB_CONN_MAX_LIFETIME": &c.ConnMaxLifetime,
+		"DB_STATEMENT_TIMEOUT": &c.StatementTimeout,
+		"DB_CONNECT_TIMEOUT":   &c.ConnectTimeout,
+	} {
+		if v, ok := lookup(key); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("cannot convert %v value %v to duration", key, v)
+			}
+			*p = d
+		}
+	}
+	return nil
+}
+
+// quote escapes value of connection string parameter.
+func quote(v string) string {
+	v = strings.Replace(v, `\`, `\\`, -1)
+	v = strings.Replace(v, `'`, `\'`, -1)
+	return "'" + v + "'"
+}
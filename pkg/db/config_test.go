@@ -0,0 +1,64 @@
+package db
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_LoadDirOverridesValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "DB_PASS"), []byte("secret\n"), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "DB_STATEMENT_TIMEOUT"), []byte("30s"), 0600))
+
+	c := DefaultConfig()
+	c.Password = "old"
+	assert.NoError(t, c.LoadDir(dir))
+
+	assert.Equal(t, "secret", c.Password)
+	assert.Equal(t, 30*time.Second, c.StatementTimeout)
+	assert.Equal(t, 30*time.Minute, c.ConnMaxLifetime)
+}
+
+func TestConfig_LoadReturnsErrorOnInvalidDuration(t *testing.T) {
+	c := DefaultConfig()
+	err := c.load(func(key string) (string, bool) {
+		return "ten", key == "DB_CONNECT_TIMEOUT"
+	})
+
+	assert.Error(t, err)
+}
+
+func TestConfig_ValidateListsMissingSettings(t *testing.T) {
+	c := DefaultConfig()
+	c.Host = "edp-db"
+	c.Port = "5432"
+
+	err := c.Validate()
+
+	assert.EqualError(t, err, "database settings DB_NAME, DB_PASS, DB_USER are missing")
+}
+
+func TestConfig_DataSourceName(t *testing.T) {
+	c := DefaultConfig()
+	c.Host = "edp-db"
+	c.Port = "5432"
+	c.Name = "edp-db"
+	c.User = "admin"
+	c.Password = "it's"
+	c.SSLMode = "verify-full"
+	c.SSLRootCert = "/etc/ca.crt"
+	c.StatementTimeout = 5 * time.Second
+
+	assert.Equal(t, "host='edp-db' port='5432' dbname='edp-db' user='admin' password='it\\'s' "+
+		"sslmode='verify-full' application_name=Reconciler sslrootcert='/etc/ca.crt' statement_timeout=5000",
+		c.DataSourceName())
+}
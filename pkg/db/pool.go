@@ -1,62 +1,67 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
+	"time"
+
 	_ "github.com/lib/pq"
-	"log"
-	"os"
-	"strconv"
+	"github.com/pkg/errors"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 )
 
-var Instance *sql.DB
-
-func init() {
-	host := getEnvOrFatal("DB_HOST")
-	port := getEnvOrFatal("DB_PORT")
-	name := getEnvOrFatal("DB_NAME")
-	user := getEnvOrFatal("DB_USER")
-	pass := getEnvOrFatal("DB_PASS")
-	ssl := getEnvOrFatal("DB_SSL_MODE")
+var log = logf.Log.WithName("db")
 
-	conn := fmt.Sprintf("host=%v port=%v dbname=%v user=%v password=%v sslmode=%v application_name=Reconciler",
-		host, port, name, user, pass, ssl)
+const maxRetryDelay = 30 * time.Second
 
-	db, err := sql.Open("postgres", conn)
+// Open validates the config, opens connection pool and waits until the
+// database accepts connections, retrying with exponential backoff up to
+// ConnectTimeout.
+func Open(c Config) (*sql.DB, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
 
+	db, err := sql.Open("postgres", c.DataSourceName())
 	if err != nil {
-		log.Fatalf("[ERROR] %s", err)
+		return nil, errors.Wrap(err, "couldn't open database")
 	}
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
 
-	maxOpen := getIntEnvOrDefault("DB_MAX_OPEN_CONN", "5")
-	maxIdle := getIntEnvOrDefault("DB_MAX_IDLE_CONN", "5")
-
-	db.SetMaxOpenConns(maxOpen)
-	db.SetMaxIdleConns(maxIdle)
-
-	Instance = db
-}
-
-func getEnvOrFatal(key string) string {
-	value, ok := os.LookupEnv(key)
-	if !ok {
-		log.Fatalf("env variable %v is missing", key)
+	if err := ping(db, c.ConnectTimeout); err != nil {
+		_ = db.Close()
+		return nil, errors.Wrapf(err, "couldn't connect to database %v:%v", c.Host, c.Port)
 	}
-	return value
+	return db, nil
 }
 
-func getIntEnvOrDefault(key, defaultValue string) int {
-	strVal := getEnvOrDefault(key, defaultValue)
-	intVal, err := strconv.Atoi(strVal)
-	if err != nil {
-		log.Fatalf("cannot convert env value %v to int", strVal)
+func ping(db *sql.DB, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	delay := time.Second
+	for {
+		err := db.Ping()
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return err
+		}
+		log.Info("database is not available, retrying", "error", err.Error(), "delay", delay.String())
+		time.Sleep(delay)
+		if delay *= 2; delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
 	}
-	return intVal
 }
 
-func getEnvOrDefault(key, defaultValue string) string {
-	if value, ok := os.LookupEnv(key); ok {
-		return value
+// PingCheck returns health check which fails when the database doesn't
+// respond to ping within the timeout.
+func PingCheck(db *sql.DB, timeout time.Duration) func() error {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return db.PingContext(ctx)
 	}
-	return defaultValue
 }
@@ -0,0 +1,159 @@
+// Package leader runs the reconciler under leader election, so that only one
+// of the replicas writes into the database at a time.
+package leader
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+var log = logf.Log.WithName("leader-election")
+
+// Options configures leader election. Lease is kept in config map
+// LeaseName in LeaseNamespace.
+type Options struct {
+	Enabled        bool
+	LeaseNamespace string
+	LeaseName      string
+	LeaseDuration  time.Duration
+	RenewDeadline  time.Duration
+	RetryPeriod    time.Duration
+}
+
+// DefaultOptions returns options with the durations recommended by client-go.
+func DefaultOptions() Options {
+	return Options{
+		LeaseName:     "reconciler-lock",
+		LeaseDuration: 15 * time.Second,
+		RenewDeadline: 10 * time.Second,
+		RetryPeriod:   2 * time.Second,
+	}
+}
+
+// BindFlags registers flags of the options with its current values as defaults.
+func (o *Options) BindFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Enabled, "leader-elect", o.Enabled,
+		"Run the reconciler only while it holds the lease, required to run several replicas")
+	fs.StringVar(&o.LeaseNamespace, "leader-elect-namespace", o.LeaseNamespace,
+		"Namespace of the lease config map, the namespace the reconciler runs in if empty")
+	fs.StringVar(&o.LeaseName, "leader-elect-name", o.LeaseName, "Name of the lease config map")
+	fs.DurationVar(&o.LeaseDuration, "leader-elect-lease-duration", o.LeaseDuration,
+		"How long standby replicas wait before taking over the lease which is not renewed")
+	fs.DurationVar(&o.RenewDeadline, "leader-elect-renew-deadline", o.RenewDeadline,
+		"How long the leader retries to renew the lease before giving it up")
+	fs.DurationVar(&o.RetryPeriod, "leader-elect-retry-period", o.RetryPeriod,
+		"How often replicas try to acquire or renew the lease")
+}
+
+// Elector runs the function while it holds the lease.
+type Elector struct {
+	options Options
+	le      *leaderelection.LeaderElector
+	watch   *leaderelection.HealthzAdaptor
+	run     func(stop <-chan struct{}) error
+	errCh   chan error
+}
+
+// New returns elector which calls run once the lease is acquired.
+// The recorder is used to report lease transitions as events.
+func New(cfg *rest.Config, o Options, recorder record.EventRecorder, run func(stop <-chan struct{}) error) (*Elector, error) {
+	cs, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create kubernetes client")
+	}
+
+	id, err := os.Hostname()
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get hostname")
+	}
+	id = id + "_" + string(uuid.NewUUID())
+
+	lock, err := resourcelock.New(resourcelock.ConfigMapsResourceLock, o.LeaseNamespace, o.LeaseName, cs.CoreV1(),
+		resourcelock.ResourceLockConfig{Identity: id, EventRecorder: recorder})
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create lease lock")
+	}
+
+	e := &Elector{
+		options: o,
+		watch:   leaderelection.NewLeaderHealthzAdaptor(o.LeaseDuration - o.RenewDeadline),
+		run:     run,
+		errCh:   make(chan error, 1),
+	}
+	e.le, err = leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: o.LeaseDuration,
+		RenewDeadline: o.RenewDeadline,
+		RetryPeriod:   o.RetryPeriod,
+		WatchDog:      e.watch,
+		Name:          o.LeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: e.onStartedLeading,
+			OnStoppedLeading: func() {
+				e.fail(errors.New("leader election lost"))
+			},
+			OnNewLeader: func(identity string) {
+				log.Info("New leader has been elected", "identity", identity)
+			},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create leader elector")
+	}
+	return e, nil
+}
+
+// Start campaigns for the lease and blocks until stop channel is closed,
+// the run function fails or the lease is lost.
+func (e *Elector) Start(stop <-chan struct{}) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	log.Info("Trying to acquire the lease", "namespace", e.options.LeaseNamespace, "name", e.options.LeaseName)
+	go e.le.Run(ctx)
+
+	select {
+	case <-stop:
+		return nil
+	case err := <-e.errCh:
+		return err
+	}
+}
+
+func (e *Elector) onStartedLeading(ctx context.Context) {
+	log.Info("The lease has been acquired")
+	err := e.run(ctx.Done())
+	if err == nil && ctx.Err() != nil {
+		err = errors.New("leader election lost")
+	}
+	e.fail(err)
+}
+
+// fail reports the first result of the elector, the rest are dropped.
+func (e *Elector) fail(err error) {
+	select {
+	case e.errCh <- err:
+	default:
+	}
+}
+
+// IsLeader reports whether the elector holds the lease.
+func (e *Elector) IsLeader() bool {
+	return e.le.IsLeader()
+}
+
+// Check fails when the leader hasn't renewed the lease in time.
+func (e *Elector) Check() error {
+	return e.watch.Check(nil)
+}
@@ -0,0 +1,55 @@
+package message
+
+var en = locale{
+	templates: map[string]map[string]string{
+		Codebase: {
+			"codebase_registration":          "Codebase {name} registration",
+			"accept_codebase_registration":   "Accept codebase {name} registration",
+			"gerrit_repository_provisioning": "Gerrit repository for codebase {name} provisioning",
+			"jenkins_configuration":          "CI Jenkins pipelines codebase {name} provisioning",
+			"perf_registration":              "Registration codebase {name} in Perf",
+			"setup_deployment_templates":     "Setup deployment templates for codebase {name}",
+			"put_s2i":                        "Put s2i for {name} codebase",
+			"put_jenkins_folder":             "Put JenkinsFolder CR for {name} codebase",
+			"clean_data":                     "Clean temporary data for {name} codebase",
+			"import_project":                 "Start importing project {name}",
+			"put_version_file":               "Put VERSION file for Go {name} app",
+			"put_gitlab_ci_file":             "Put GitlabCI file for {name} codebase",
+		},
+		CodebaseBranch: {
+			"jenkins_configuration":               "CI Jenkins pipelines for codebase branch {name} provisioning for codebase {codebase}",
+			"codebase_branch_registration":        "Branch {name} for codebase {codebase} registration",
+			"accept_codebase_branch_registration": "Accept branch {name} for codebase {codebase} registration",
+			"put_branch_for_gitlab_ci_codebase":   "Create {name} branch for {codebase} codebase in Git ",
+		},
+		CDPipeline: {
+			"accept_cd_pipeline_registration": "Accept CD Pipeline {name} registration",
+			"jenkins_configuration":           "CI Jenkins pipelines {name} provisioning",
+			"setup_initial_structure":         "Initial structure for CD Pipeline {name} is created",
+			"cd_pipeline_registration":        "CD Pipeline {name} registration",
+			"create_jenkins_directory":        "Create directory in Jenkins for CD Pipeline {name}",
+		},
+		CDStage: {
+			"accept_cd_stage_registration":      "Accept CD Stage {name} registration",
+			"fetching_user_settings_config_map": "Fetch User Settings from config map during CD Stage {name} provision",
+			"platform_project_creation":         "Create Openshift Project for Stage {name}",
+			"jenkins_configuration":             "CI Jenkins pipelines {name} provisioning",
+			"setup_deployment_templates":        "Setup deployment templates for cd_stage {name}",
+			"create_jenkins_pipeline":           "Create Jenkins pipeline for CD Stage {name}",
+		},
+		JenkinsJob: {
+			"platform_project_creation": "Create Platform Project for Stage {name}",
+			"role_binding":              "Create Role Binding for project stage {name}",
+			"create_jenkins_pipeline":   "Create Jenkins pipeline for CD Stage {name}",
+		},
+	},
+	kinds: map[string]string{
+		Codebase:       "codebase",
+		CodebaseBranch: "codebase branch",
+		CDPipeline:     "CD pipeline",
+		CDStage:        "CD stage",
+		JenkinsJob:     "CD stage",
+	},
+	fallback:      "{action} for {kind} {name}",
+	unknownAction: "Unknown action",
+}
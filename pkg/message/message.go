@@ -0,0 +1,226 @@
+// Package message keeps templates of action messages shown in the history
+// of codebases, branches, CD pipelines and stages. Templates refer to
+// arguments by name, e.g. "Codebase {name} registration", so translations
+// can put them in any order.
+package message
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Entity kinds templates are defined for.
+const (
+	Codebase       = "codebase"
+	CodebaseBranch = "codebase_branch"
+	CDPipeline     = "cd_pipeline"
+	CDStage        = "cd_stage"
+	JenkinsJob     = "jenkins_job"
+)
+
+// Built-in locales.
+const (
+	English   = "en"
+	Ukrainian = "uk"
+)
+
+// FallbackKey is the override key suffix of the template used for actions
+// which have no template, e.g. "en.fallback".
+const FallbackKey = "fallback"
+
+var placeholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// Args are named arguments of a template. Every entity has "name" argument,
+// codebase branch has "codebase" as well.
+type Args map[string]string
+
+type locale struct {
+	// templates are keyed by entity kind and action.
+	templates map[string]map[string]string
+	// kinds are names of entity kinds used in fallback message.
+	kinds map[string]string
+	// fallback is the template of actions which have no template. Besides
+	// entity arguments it gets "action" and "kind" ones.
+	fallback string
+	// unknownAction is the action name used in fallback if action is empty.
+	unknownAction string
+}
+
+func (l locale) clone() *locale {
+	c := &locale{
+		templates:     make(map[string]map[string]string, len(l.templates)),
+		kinds:         make(map[string]string, len(l.kinds)),
+		fallback:      l.fallback,
+		unknownAction: l.unknownAction,
+	}
+	for kind, ts := range l.templates {
+		c.templates[kind] = make(map[string]string, len(ts))
+		for a, t := range ts {
+			c.templates[kind][a] = t
+		}
+	}
+	for kind, n := range l.kinds {
+		c.kinds[kind] = n
+	}
+	return c
+}
+
+// Catalogue formats action messages in the selected locale. Templates
+// missing in the locale are taken from English one.
+type Catalogue struct {
+	mu      sync.RWMutex
+	locale  string
+	locales map[string]*locale
+}
+
+// New returns catalogue of the built-in templates in English.
+func New() *Catalogue {
+	return &Catalogue{
+		locale: English,
+		locales: map[string]*locale{
+			English:   en.clone(),
+			Ukrainian: uk.clone(),
+		},
+	}
+}
+
+var catalogue = New()
+
+// Default returns catalogue used by Format.
+func Default() *Catalogue {
+	return catalogue
+}
+
+// Format returns action message of the entity kind formatted by
+// the default catalogue.
+func Format(kind, action string, args Args) string {
+	return catalogue.Format(kind, action, args)
+}
+
+// SetLocale selects locale of the messages. The locale should be either
+// built-in or added by overrides.
+func (c *Catalogue) SetLocale(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.locales[name]; !ok {
+		return fmt.Errorf("unknown message locale %v", name)
+	}
+	c.locale = name
+	return nil
+}
+
+// Override replaces templates by the ones keyed by "<locale>.<kind>.<action>"
+// or "<locale>.fallback". Unknown locales are added, so a new translation
+// may be provided by overrides only. Nothing is replaced if any key is invalid.
+func (c *Catalogue) Override(templates map[string]string) error {
+	for key := range templates {
+		parts := strings.SplitN(key, ".", 3)
+		switch {
+		case len(parts) == 2 && parts[0] != "" && parts[1] == FallbackKey:
+		case len(parts) == 3 && parts[0] != "" && parts[2] != "":
+			if _, ok := en.kinds[parts[1]]; !ok {
+				return fmt.Errorf("unknown entity kind %v in message key %v", parts[1], key)
+			}
+		default:
+			return fmt.Errorf("message key %v should be either <locale>.<kind>.<action> or <locale>.%v",
+				key, FallbackKey)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, t := range templates {
+		parts := strings.SplitN(key, ".", 3)
+		l, ok := c.locales[parts[0]]
+		if !ok {
+			l = &locale{templates: map[string]map[string]string{}, kinds: map[string]string{}}
+			c.locales[parts[0]] = l
+		}
+		if len(parts) == 2 {
+			l.fallback = t
+			continue
+		}
+		if l.templates[parts[1]] == nil {
+			l.templates[parts[1]] = map[string]string{}
+		}
+		l.templates[parts[1]][parts[2]] = t
+	}
+	return nil
+}
+
+// LoadConfigMap overrides templates by data of the config map.
+func (c *Catalogue) LoadConfigMap(r client.Reader, key types.NamespacedName) error {
+	cm := &v1.ConfigMap{}
+	if err := r.Get(context.TODO(), key, cm); err != nil {
+		return err
+	}
+	return c.Override(cm.Data)
+}
+
+// Format returns action message of the entity kind. Actions which have no
+// template get fallback message built from the action name.
+func (c *Catalogue) Format(kind, action string, args Args) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	l := c.locales[c.locale]
+	if t, ok := l.templates[kind][action]; ok {
+		return expand(t, args)
+	}
+	if t, ok := c.locales[English].templates[kind][action]; ok {
+		return expand(t, args)
+	}
+	return c.formatFallback(l, kind, action, args)
+}
+
+func (c *Catalogue) formatFallback(l *locale, kind, action string, args Args) string {
+	en := c.locales[English]
+	a := make(Args, len(args)+2)
+	for k, v := range args {
+		a[k] = v
+	}
+
+	if action == "" {
+		a["action"] = pick(l.unknownAction, en.unknownAction)
+	} else {
+		a["action"] = capitalize(strings.Replace(action, "_", " ", -1))
+	}
+	a["kind"] = pick(l.kinds[kind], en.kinds[kind], strings.Replace(kind, "_", " ", -1))
+	return expand(pick(l.fallback, en.fallback), a)
+}
+
+// expand substitutes placeholders of the template, unknown ones are kept.
+func expand(template string, args Args) string {
+	return placeholder.ReplaceAllStringFunc(template, func(p string) string {
+		if v, ok := args[p[1:len(p)-1]]; ok {
+			return v
+		}
+		return p
+	})
+}
+
+func pick(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
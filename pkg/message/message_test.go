@@ -0,0 +1,73 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat_ShouldKeepEnglishMessages(t *testing.T) {
+	c := New()
+
+	assert.Equal(t, "Codebase fake-name registration",
+		c.Format(Codebase, "codebase_registration", Args{"name": "fake-name"}))
+	assert.Equal(t, "Create master branch for fake-name codebase in Git ",
+		c.Format(CodebaseBranch, "put_branch_for_gitlab_ci_codebase", Args{"name": "master", "codebase": "fake-name"}))
+}
+
+func TestFormat_ShouldUseSelectedLocale(t *testing.T) {
+	c := New()
+	assert.NoError(t, c.SetLocale(Ukrainian))
+
+	assert.Equal(t, "Реєстрація кодової бази fake-name",
+		c.Format(Codebase, "codebase_registration", Args{"name": "fake-name"}))
+}
+
+func TestFormat_ShouldFallBackToEnglishTemplate(t *testing.T) {
+	c := New()
+	assert.NoError(t, c.Override(map[string]string{"de.codebase.codebase_registration": "Registrierung {name}"}))
+	assert.NoError(t, c.SetLocale("de"))
+
+	assert.Equal(t, "Registrierung fake-name", c.Format(Codebase, "codebase_registration", Args{"name": "fake-name"}))
+	assert.Equal(t, "Accept codebase fake-name registration",
+		c.Format(Codebase, "accept_codebase_registration", Args{"name": "fake-name"}))
+}
+
+func TestFormat_ShouldDescribeUnknownAction(t *testing.T) {
+	c := New()
+
+	assert.Equal(t, "Put helm chart for CD pipeline fake-name",
+		c.Format(CDPipeline, "put_helm_chart", Args{"name": "fake-name"}))
+	assert.Equal(t, "Unknown action for codebase branch master",
+		c.Format(CodebaseBranch, "", Args{"name": "master", "codebase": "fake-name"}))
+
+	assert.NoError(t, c.SetLocale(Ukrainian))
+	assert.Equal(t, "Невідома дія: CD стейдж sit", c.Format(CDStage, "", Args{"name": "sit"}))
+}
+
+func TestOverride_ShouldReplaceTemplates(t *testing.T) {
+	c := New()
+	err := c.Override(map[string]string{
+		"en.codebase.codebase_registration": "{name} is registered",
+		"en.fallback":                       "{kind} {name}: {action}",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "fake-name is registered", c.Format(Codebase, "codebase_registration", Args{"name": "fake-name"}))
+	assert.Equal(t, "codebase fake-name: Put helm chart", c.Format(Codebase, "put_helm_chart", Args{"name": "fake-name"}))
+}
+
+func TestOverride_ShouldRejectInvalidKeys(t *testing.T) {
+	c := New()
+	err := c.Override(map[string]string{
+		"en.codebase.codebase_registration": "{name} is registered",
+		"en.library.codebase_registration":  "{name} is registered",
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "Codebase fake-name registration",
+		c.Format(Codebase, "codebase_registration", Args{"name": "fake-name"}))
+
+	assert.Error(t, c.Override(map[string]string{"en.codebase": "{name}"}))
+	assert.Error(t, c.SetLocale("de"))
+}
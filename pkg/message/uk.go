@@ -0,0 +1,55 @@
+package message
+
+var uk = locale{
+	templates: map[string]map[string]string{
+		Codebase: {
+			"codebase_registration":          "Реєстрація кодової бази {name}",
+			"accept_codebase_registration":   "Прийняття реєстрації кодової бази {name}",
+			"gerrit_repository_provisioning": "Створення Gerrit репозиторію для кодової бази {name}",
+			"jenkins_configuration":          "Налаштування CI Jenkins пайплайнів кодової бази {name}",
+			"perf_registration":              "Реєстрація кодової бази {name} у Perf",
+			"setup_deployment_templates":     "Налаштування шаблонів розгортання для кодової бази {name}",
+			"put_s2i":                        "Створення s2i для кодової бази {name}",
+			"put_jenkins_folder":             "Створення JenkinsFolder CR для кодової бази {name}",
+			"clean_data":                     "Очищення тимчасових даних кодової бази {name}",
+			"import_project":                 "Початок імпорту проєкту {name}",
+			"put_version_file":               "Створення файлу VERSION для Go застосунку {name}",
+			"put_gitlab_ci_file":             "Створення файлу GitlabCI для кодової бази {name}",
+		},
+		CodebaseBranch: {
+			"jenkins_configuration":               "Налаштування CI Jenkins пайплайнів гілки {name} кодової бази {codebase}",
+			"codebase_branch_registration":        "Реєстрація гілки {name} кодової бази {codebase}",
+			"accept_codebase_branch_registration": "Прийняття реєстрації гілки {name} кодової бази {codebase}",
+			"put_branch_for_gitlab_ci_codebase":   "Створення гілки {name} кодової бази {codebase} у Git",
+		},
+		CDPipeline: {
+			"accept_cd_pipeline_registration": "Прийняття реєстрації CD пайплайну {name}",
+			"jenkins_configuration":           "Налаштування CI Jenkins пайплайнів {name}",
+			"setup_initial_structure":         "Початкову структуру CD пайплайну {name} створено",
+			"cd_pipeline_registration":        "Реєстрація CD пайплайну {name}",
+			"create_jenkins_directory":        "Створення директорії в Jenkins для CD пайплайну {name}",
+		},
+		CDStage: {
+			"accept_cd_stage_registration":      "Прийняття реєстрації CD стейджу {name}",
+			"fetching_user_settings_config_map": "Отримання налаштувань користувача з config map під час створення CD стейджу {name}",
+			"platform_project_creation":         "Створення Openshift проєкту для стейджу {name}",
+			"jenkins_configuration":             "Налаштування CI Jenkins пайплайнів {name}",
+			"setup_deployment_templates":        "Налаштування шаблонів розгортання для CD стейджу {name}",
+			"create_jenkins_pipeline":           "Створення Jenkins пайплайну для CD стейджу {name}",
+		},
+		JenkinsJob: {
+			"platform_project_creation": "Створення проєкту платформи для стейджу {name}",
+			"role_binding":              "Створення Role Binding для проєкту стейджу {name}",
+			"create_jenkins_pipeline":   "Створення Jenkins пайплайну для CD стейджу {name}",
+		},
+	},
+	kinds: map[string]string{
+		Codebase:       "кодова база",
+		CodebaseBranch: "гілка кодової бази",
+		CDPipeline:     "CD пайплайн",
+		CDStage:        "CD стейдж",
+		JenkinsJob:     "CD стейдж",
+	},
+	fallback:      "{action}: {kind} {name}",
+	unknownAction: "Невідома дія",
+}
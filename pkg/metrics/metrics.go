@@ -0,0 +1,151 @@
+// Package metrics defines Prometheus metrics of the reconciler. They are
+// registered in the controller-runtime registry which is served on /metrics.
+package metrics
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// ErrorTypeDB marks errors returned by the database or its driver.
+	ErrorTypeDB = "db"
+	// ErrorTypeKubernetes marks errors returned by Kubernetes API.
+	ErrorTypeKubernetes = "kubernetes"
+	// ErrorTypeOther marks the rest of errors, e.g. validation ones.
+	ErrorTypeOther = "other"
+)
+
+var (
+	reconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_reconcile_total",
+		Help: "Number of reconciliations by controller and result",
+	}, []string{"controller", "result"})
+
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "reconciler_reconcile_duration_seconds",
+		Help: "Duration of reconciliations by controller",
+	}, []string{"controller"})
+
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_reconcile_errors_total",
+		Help: "Number of reconciliation errors by controller and error type",
+	}, []string{"controller", "type"})
+
+	txDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "reconciler_db_transaction_duration_seconds",
+		Help:    "Duration of DB transactions from begin to commit or rollback",
+		Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"result"})
+
+	txRollbacks = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "reconciler_db_transaction_rollbacks_total",
+		Help: "Number of rolled back DB transactions",
+	})
+
+	requeues = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_requeue_total",
+		Help: "Number of failed reconciliations requeued with backoff by controller and error class",
+	}, []string{"controller", "class"})
+
+	deadLetters = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_dead_letter_total",
+		Help: "Number of requests dead-lettered after running out of attempts by controller and error class",
+	}, []string{"controller", "class"})
+
+	deadLetterRequests = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "reconciler_dead_letter_requests",
+		Help: "Number of requests currently dead-lettered by controller",
+	}, []string{"controller"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(reconcileTotal, reconcileDuration, reconcileErrors, txDuration, txRollbacks,
+		requeues, deadLetters, deadLetterRequests)
+}
+
+// Instrument wraps reconciler to count and time its reconciliations.
+// Errors returned by the reconciler are counted by their type.
+func Instrument(controller string, r reconcile.Reconciler) reconcile.Reconciler {
+	return instrumented{controller: controller, r: r}
+}
+
+type instrumented struct {
+	controller string
+	r          reconcile.Reconciler
+}
+
+func (i instrumented) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	start := time.Now()
+	res, err := i.r.Reconcile(request)
+	reconcileDuration.WithLabelValues(i.controller).Observe(time.Since(start).Seconds())
+
+	switch {
+	case err != nil:
+		reconcileTotal.WithLabelValues(i.controller, "error").Inc()
+		ReconcileError(i.controller, err)
+	case res.Requeue || res.RequeueAfter > 0:
+		reconcileTotal.WithLabelValues(i.controller, "requeue").Inc()
+	default:
+		reconcileTotal.WithLabelValues(i.controller, "success").Inc()
+	}
+	return res, err
+}
+
+// ReconcileError counts the error by its type. Reconcilers call it for
+// errors they log and requeue on instead of returning.
+func ReconcileError(controller string, err error) {
+	reconcileErrors.WithLabelValues(controller, ErrorType(err)).Inc()
+}
+
+// Requeued counts failed reconciliation requeued with backoff.
+func Requeued(controller, class string) {
+	requeues.WithLabelValues(controller, class).Inc()
+}
+
+// DeadLettered counts the request which won't be requeued anymore.
+func DeadLettered(controller, class string) {
+	deadLetters.WithLabelValues(controller, class).Inc()
+	deadLetterRequests.WithLabelValues(controller).Inc()
+}
+
+// Revived counts out dead-lettered request which has been reconciled.
+func Revived(controller string) {
+	deadLetterRequests.WithLabelValues(controller).Dec()
+}
+
+// ErrorType classifies the error by its cause.
+func ErrorType(err error) string {
+	cause := errors.Cause(err)
+	if _, ok := cause.(k8serrors.APIStatus); ok {
+		return ErrorTypeKubernetes
+	}
+	switch cause.(type) {
+	case *pq.Error, pq.Error:
+		return ErrorTypeDB
+	}
+	switch cause {
+	case sql.ErrConnDone, sql.ErrTxDone, driver.ErrBadConn:
+		return ErrorTypeDB
+	}
+	return ErrorTypeOther
+}
+
+// ObserveTx records duration of the transaction started at start
+// and ended by commit or rollback.
+func ObserveTx(start time.Time, rollback bool) {
+	result := "commit"
+	if rollback {
+		result = "rollback"
+		txRollbacks.Inc()
+	}
+	txDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestErrorType(t *testing.T) {
+	notFound := k8serrors.NewNotFound(schema.GroupResource{Resource: "codebases"}, "fake")
+
+	assert.Equal(t, ErrorTypeDB, ErrorType(errors.Wrap(&pq.Error{Code: "23505"}, "couldn't insert")))
+	assert.Equal(t, ErrorTypeDB, ErrorType(errors.Wrap(sql.ErrConnDone, "couldn't begin")))
+	assert.Equal(t, ErrorTypeKubernetes, ErrorType(errors.Wrap(notFound, "couldn't get edp name")))
+	assert.Equal(t, ErrorTypeOther, ErrorType(fmt.Errorf("unknown versioning type")))
+}
+
+type reconcilerFunc func(reconcile.Request) (reconcile.Result, error)
+
+func (f reconcilerFunc) Reconcile(r reconcile.Request) (reconcile.Result, error) {
+	return f(r)
+}
+
+func TestInstrument_CountsResultsAndErrors(t *testing.T) {
+	var err error
+	r := Instrument("fake-controller", reconcilerFunc(func(reconcile.Request) (reconcile.Result, error) {
+		return reconcile.Result{}, err
+	}))
+
+	_, _ = r.Reconcile(reconcile.Request{})
+	err = errors.Wrap(&pq.Error{}, "couldn't update")
+	_, _ = r.Reconcile(reconcile.Request{})
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(reconcileTotal.WithLabelValues("fake-controller", "success")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(reconcileTotal.WithLabelValues("fake-controller", "error")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(reconcileErrors.WithLabelValues("fake-controller", ErrorTypeDB)))
+}
@@ -32,6 +32,22 @@ type ActionLog struct {
 	Result          string
 }
 
+// TimelineEntry is an action log of a codebase or one of its branches.
+type TimelineEntry struct {
+	ActionLog
+	// Branch is empty for action logs of the codebase itself.
+	Branch string
+}
+
+// TimelineFilter narrows down codebase timeline. Zero fields don't filter.
+type TimelineFilter struct {
+	Branch string
+	Action string
+	Result string
+	Since  time.Time
+	Limit  int
+}
+
 func FormatStatus(status string) string {
 	return strings.ToLower(strings.Replace(status, " ", "_", -1))
 }
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	edpv1alpha1 "github.com/epmd-edp/cd-pipeline-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/message"
 	"github.com/epmd-edp/reconciler/v2/pkg/model"
 )
 
@@ -35,14 +36,6 @@ type CDPipeline struct {
 	ApplicationsToPromote []string
 }
 
-var cdPipelineActionMessageMap = map[string]string{
-	"accept_cd_pipeline_registration": "Accept CD Pipeline %v registration",
-	"jenkins_configuration":           "CI Jenkins pipelines %v provisioning",
-	"setup_initial_structure":         "Initial structure for CD Pipeline %v is created",
-	"cd_pipeline_registration":        "CD Pipeline %v registration",
-	"create_jenkins_directory":        "Create directory in Jenkins for CD Pipeline %v",
-}
-
 // ConvertToCDPipeline returns converted to DTO CDPipeline object from K8S.
 // An error occurs if method received nil instead of k8s object
 func ConvertToCDPipeline(k8sObject edpv1alpha1.CDPipeline, edpName string) (*CDPipeline, error) {
@@ -86,6 +79,6 @@ func convertCDPipelineActionLog(cdPipelineName string, status edpv1alpha1.CDPipe
 		return al
 	}
 
-	al.ActionMessage = fmt.Sprintf(cdPipelineActionMessageMap[fmt.Sprint(status.Action)], cdPipelineName)
+	al.ActionMessage = message.Format(message.CDPipeline, fmt.Sprint(status.Action), message.Args{"name": cdPipelineName})
 	return al
 }
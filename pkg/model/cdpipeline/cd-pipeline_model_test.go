@@ -19,6 +19,7 @@ package cdpipeline
 import (
 	"fmt"
 	edpv1alpha1 "github.com/epmd-edp/cd-pipeline-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/message"
 	"github.com/epmd-edp/reconciler/v2/pkg/model"
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -104,7 +105,7 @@ func TestConvertMethodToCDPipeline(t *testing.T) {
 		t.Fatal(fmt.Sprintf("result is incorrect %v", result))
 	}
 
-	actionMessage := fmt.Sprintf(cdPipelineActionMessageMap[cdPipelineAction], name)
+	actionMessage := message.Format(message.CDPipeline, cdPipelineAction, message.Args{"name": name})
 	if cdPipeline.ActionLog.ActionMessage != actionMessage {
 		t.Fatal(fmt.Sprintf("action message is incorrect %v", actionMessage))
 	}
@@ -18,10 +18,13 @@ package codebase
 
 import (
 	"errors"
-	"fmt"
 	edpv1alpha1Codebase "github.com/epmd-edp/codebase-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/message"
 	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -76,19 +79,120 @@ type Perf struct {
 	DataSources []string `json:"dataSources"`
 }
 
-var codebaseActionMessageMap = map[string]string{
-	"codebase_registration":          "Codebase %v registration",
-	"accept_codebase_registration":   "Accept codebase %v registration",
-	"gerrit_repository_provisioning": "Gerrit repository for codebase %v provisioning",
-	"jenkins_configuration":          "CI Jenkins pipelines codebase %v provisioning",
-	"perf_registration":              "Registration codebase %v in Perf",
-	"setup_deployment_templates":     "Setup deployment templates for codebase %v",
-	"put_s2i":                        "Put s2i for %v codebase",
-	"put_jenkins_folder":             "Put JenkinsFolder CR for %v codebase",
-	"clean_data":                     "Clean temporary data for %v codebase",
-	"import_project":                 "Start importing project %v",
-	"put_version_file":               "Put VERSION file for Go %v app",
-	"put_gitlab_ci_file":             "Put GitlabCI file for %v codebase",
+// DataSourceTypes returns sorted types of the data sources in the form they
+// are stored, no data sources are expected if perf is nil.
+func (p *Perf) DataSourceTypes() []string {
+	if p == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	var types []string
+	for _, ds := range p.DataSources {
+		t := strings.ToUpper(ds)
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Change is a column of codebase which value differs from the stored one.
+type Change struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// RepositoryLocation is the git server and the path the codebase repository
+// is hosted at.
+type RepositoryLocation struct {
+	GitServerId *int
+	GitUrlPath  *string
+	// MovedAt is the time the codebase has been moved away from the location.
+	MovedAt time.Time
+}
+
+// Location returns the current location of the codebase repository.
+func (c Codebase) Location() RepositoryLocation {
+	return RepositoryLocation{GitServerId: c.GitServerId, GitUrlPath: c.GitUrlPath}
+}
+
+// Moved tells whether the codebase has been moved to another git server or
+// repository path, old being the stored codebase.
+func Moved(old, new Codebase) bool {
+	return intValue(old.GitServerId) != intValue(new.GitServerId) ||
+		stringValue(old.GitUrlPath) != stringValue(new.GitUrlPath)
+}
+
+type column struct {
+	name  string
+	value string
+}
+
+// Diff returns changes of the columns projected from codebase custom
+// resource, old being the stored codebase. Status and action log are
+// tracked separately, so they aren't compared.
+func Diff(old, new Codebase) []Change {
+	o, n := columns(old), columns(new)
+	var changes []Change
+	for i := range n {
+		if o[i].value != n[i].value {
+			changes = append(changes, Change{Field: n[i].name, Old: o[i].value, New: n[i].value})
+		}
+	}
+	return changes
+}
+
+func columns(c Codebase) []column {
+	var perfServerId *int
+	if c.Perf != nil {
+		perfServerId = c.Perf.Id
+	}
+	return []column{
+		{"type", c.Type},
+		{"language", strings.ToLower(c.Language)},
+		{"framework", stringValue(c.Framework)},
+		{"build_tool", strings.ToLower(c.BuildTool)},
+		{"strategy", strings.ToLower(c.Strategy)},
+		{"repository_url", c.RepositoryUrl},
+		{"route_site", c.RouteSite},
+		{"route_path", c.RoutePath},
+		{"database_kind", c.DatabaseKind},
+		{"database_version", c.DatabaseVersion},
+		{"database_capacity", c.DatabaseCapacity},
+		{"database_storage", c.DatabaseStorage},
+		{"test_report_framework", c.TestReportFramework},
+		{"description", c.Description},
+		{"git_server_id", intValue(c.GitServerId)},
+		{"git_project_path", stringValue(c.GitUrlPath)},
+		{"jenkins_slave_id", intValue(c.JenkinsSlaveId)},
+		{"job_provisioning_id", intValue(c.JobProvisioningId)},
+		{"deployment_script", c.DeploymentScript},
+		{"versioning_type", c.VersioningType},
+		{"start_versioning_from", stringValue(c.StartVersioningFrom)},
+		{"jira_server_id", intValue(c.JiraServerId)},
+		{"commit_message_pattern", stringValue(c.CommitMessagePattern)},
+		{"ticket_name_pattern", stringValue(c.TicketNamePattern)},
+		{"ci_tool", c.CiTool},
+		{"perf_server_id", intValue(perfServerId)},
+		{"default_branch", c.DefaultBranch},
+	}
+}
+
+func stringValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func intValue(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
 }
 
 func Convert(k8sObject edpv1alpha1Codebase.Codebase, edpName string) (*Codebase, error) {
@@ -195,6 +299,6 @@ func convertActionLog(name string, status edpv1alpha1Codebase.CodebaseStatus) *m
 		return al
 	}
 
-	al.ActionMessage = fmt.Sprintf(codebaseActionMessageMap[string(status.Action)], name)
+	al.ActionMessage = message.Format(message.Codebase, string(status.Action), message.Args{"name": name})
 	return al
 }
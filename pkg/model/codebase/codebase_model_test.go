@@ -18,6 +18,7 @@ package codebase
 
 import (
 	edpv1alpha1 "github.com/epmd-edp/codebase-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"testing"
 	"time"
@@ -52,3 +53,27 @@ func TestConvert(t *testing.T) {
 		t.Fatal("name is not fc-ui")
 	}
 }
+
+func TestDiff(t *testing.T) {
+	framework, pattern := "spring-boot", "fake-pattern"
+	serverId, perfId := 1, 2
+	old := Codebase{
+		Name:          "fake-app",
+		Language:      "java",
+		Framework:     &framework,
+		GitServerId:   &serverId,
+		DefaultBranch: "master",
+		Status:        "created",
+	}
+	c := old
+	c.Language = "Java"
+	c.Status = "active"
+	c.CommitMessagePattern = &pattern
+	c.Perf = &Perf{Name: "fake-perf", Id: &perfId}
+
+	assert.Equal(t, []Change{
+		{Field: "commit_message_pattern", Old: "", New: "fake-pattern"},
+		{Field: "perf_server_id", Old: "", New: "2"},
+	}, Diff(old, c))
+	assert.Empty(t, Diff(old, old))
+}
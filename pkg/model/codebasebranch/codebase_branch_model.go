@@ -18,9 +18,10 @@ package codebasebranch
 
 import (
 	"errors"
-	"fmt"
 	edpv1alpha1Codebase "github.com/epmd-edp/codebase-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/message"
 	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"time"
 )
 
 type CodebaseBranch struct {
@@ -31,16 +32,43 @@ type CodebaseBranch struct {
 	Version          *string
 	BuildNumber      *string
 	LastSuccessBuild *string
-	Release          bool
-	Status           string
-	ActionLog        model.ActionLog
+	// LastSuccessBuildAt is the time the last successful build has been
+	// recorded, it is filled by storage only.
+	LastSuccessBuildAt *time.Time
+	Release            bool
+	Status             string
+	ActionLog          model.ActionLog
 }
 
-var codebaseBranchActionMessageMap = map[string]string{
-	"jenkins_configuration":               "CI Jenkins pipelines for codebase branch %v provisioning for codebase %v",
-	"codebase_branch_registration":        "Branch %v for codebase %v registration",
-	"accept_codebase_branch_registration": "Accept branch %v for codebase %v registration",
-	"put_branch_for_gitlab_ci_codebase":   "Create %v branch for %v codebase in Git ",
+// Build is a version and build number pair the branch has been at.
+type Build struct {
+	Version     *string
+	BuildNumber *string
+	CreatedAt   time.Time
+}
+
+// Build returns the version and build number the branch is at.
+func (b CodebaseBranch) Build() Build {
+	return Build{Version: b.Version, BuildNumber: b.BuildNumber}
+}
+
+// Empty tells whether neither version nor build number is set.
+func (b Build) Empty() bool {
+	return b.Version == nil && b.BuildNumber == nil
+}
+
+// BuildChanged tells whether the branch has moved to another version or
+// build number, old being the stored branch.
+func BuildChanged(old, new CodebaseBranch) bool {
+	return stringValue(old.Version) != stringValue(new.Version) ||
+		stringValue(old.BuildNumber) != stringValue(new.BuildNumber)
+}
+
+func stringValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
 }
 
 func ConvertToCodebaseBranch(k8sObject edpv1alpha1Codebase.CodebaseBranch, edpName string) (*CodebaseBranch, error) {
@@ -86,6 +114,7 @@ func convertCodebaseBranchActionLog(brName, cbName string, status edpv1alpha1Cod
 		return al
 	}
 
-	al.ActionMessage = fmt.Sprintf(codebaseBranchActionMessageMap[string(status.Action)], brName, cbName)
+	al.ActionMessage = message.Format(message.CodebaseBranch, string(status.Action),
+		message.Args{"name": brName, "codebase": cbName})
 	return al
 }
@@ -17,6 +17,13 @@ type CodebaseDockerStreamReadDTO struct {
 	CodebaseName           string
 }
 
+// CodebaseBranchDockerStreamDTO is a docker stream built from a codebase branch.
+type CodebaseBranchDockerStreamDTO struct {
+	Id     int
+	Name   string
+	Branch string
+}
+
 type CodebaseBranchIdDTO struct {
 	CodebaseId int
 	BranchId   int
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// Kinds of entities events are recorded for.
+const (
+	CodebaseEvent     = "codebase"
+	BranchEvent       = "codebase_branch"
+	DockerStreamEvent = "codebase_docker_stream"
+	CDPipelineEvent   = "cd_pipeline"
+	CDStageEvent      = "cd_stage"
+)
+
+// Operations on entities.
+const (
+	Created = "created"
+	Updated = "updated"
+	Deleted = "deleted"
+	// Moved is recorded once codebase has been moved to another git server
+	// or repository path.
+	Moved = "moved"
+)
+
+// Event is a change of an entity recorded in the outbox within the same
+// transaction as the change itself.
+type Event struct {
+	Id        int
+	Kind      string
+	Operation string
+	// EntityId is empty if the entity is deleted by name.
+	EntityId *int
+	// Subject is the name of the entity, codebase branches and stages are
+	// prefixed by the name of codebase and CD pipeline, e.g. "app/master".
+	Subject   string
+	Data      map[string]string
+	CreatedAt time.Time
+}
@@ -0,0 +1,66 @@
+package perfdatasource
+
+import (
+	"strings"
+
+	"github.com/epmd-edp/perf-operator/v2/pkg/apis/edp/v1alpha1"
+)
+
+// PerfDataSource is a data source perf server collects metrics of the
+// codebase from.
+type PerfDataSource struct {
+	Name string
+	// Type is upper-cased as codebase data sources are, e.g. "GITLAB".
+	Type string
+	// Codebase is empty if the custom resource doesn't refer to it.
+	Codebase   string
+	PerfServer string
+	Url        string
+	Config     Config
+}
+
+// Config holds settings specific to the type of the data source.
+type Config struct {
+	JobNames     []string `json:"jobNames,omitempty"`
+	ProjectKeys  []string `json:"projectKeys,omitempty"`
+	Repositories []string `json:"repositories,omitempty"`
+	Branches     []string `json:"branches,omitempty"`
+}
+
+func ConvertJenkins(ds v1alpha1.PerfDataSourceJenkins) PerfDataSource {
+	s := ds.Spec
+	return PerfDataSource{
+		Name:       s.Name,
+		Type:       strings.ToUpper(s.Type),
+		Codebase:   s.CodebaseName,
+		PerfServer: s.PerfServerName,
+		Url:        s.Config.Url,
+		Config:     Config{JobNames: s.Config.JobNames},
+	}
+}
+
+func ConvertSonar(ds v1alpha1.PerfDataSourceSonar) PerfDataSource {
+	s := ds.Spec
+	return PerfDataSource{
+		Name:       s.Name,
+		Type:       strings.ToUpper(s.Type),
+		Codebase:   s.CodebaseName,
+		PerfServer: s.PerfServerName,
+		Url:        s.Config.Url,
+		Config:     Config{ProjectKeys: s.Config.ProjectKeys},
+	}
+}
+
+// ConvertGitLab converts GitLab data source, Gerrit ones are exposed by the
+// same custom resource of the "Gerrit" type.
+func ConvertGitLab(ds v1alpha1.PerfDataSourceGitLab) PerfDataSource {
+	s := ds.Spec
+	return PerfDataSource{
+		Name:       s.Name,
+		Type:       strings.ToUpper(s.Type),
+		Codebase:   s.CodebaseName,
+		PerfServer: s.PerfServerName,
+		Url:        s.Config.Url,
+		Config:     Config{Repositories: s.Config.Repositories, Branches: s.Config.Branches},
+	}
+}
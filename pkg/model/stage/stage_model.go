@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/epmd-edp/cd-pipeline-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/message"
 	"github.com/epmd-edp/reconciler/v2/pkg/model"
 	"github.com/pkg/errors"
 )
@@ -60,15 +61,6 @@ type QualityGate struct {
 	BranchName      *string
 }
 
-var cdStageActionMessageMap = map[string]string{
-	"accept_cd_stage_registration":      "Accept CD Stage %v registration",
-	"fetching_user_settings_config_map": "Fetch User Settings from config map during CD Stage %v provision",
-	"platform_project_creation":         "Create Openshift Project for Stage %v",
-	"jenkins_configuration":             "CI Jenkins pipelines %v provisioning",
-	"setup_deployment_templates":        "Setup deployment templates for cd_stage %v",
-	"create_jenkins_pipeline":           "Create Jenkins pipeline for CD Stage %v",
-}
-
 // ConvertToStage returns converted to DTO Stage object from K8S and provided edp name
 // An error occurs if method received nil instead of k8s object
 func ConvertToStage(k8sObject v1alpha1.Stage, edpName string) (*Stage, error) {
@@ -139,6 +131,6 @@ func convertStageActionLog(cdStageName string, status v1alpha1.StageStatus) *mod
 		return al
 	}
 
-	al.ActionMessage = fmt.Sprintf(cdStageActionMessageMap[fmt.Sprint(status.Action)], cdStageName)
+	al.ActionMessage = message.Format(message.CDStage, fmt.Sprint(status.Action), message.Args{"name": cdStageName})
 	return al
 }
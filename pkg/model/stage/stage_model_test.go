@@ -22,6 +22,7 @@ import (
 	"time"
 
 	edpv1alpha1 "github.com/epmd-edp/cd-pipeline-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/message"
 	"github.com/epmd-edp/reconciler/v2/pkg/model"
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -152,7 +153,7 @@ func TestConvertMethodToCDStage(t *testing.T) {
 		t.Fatal(fmt.Sprintf("result is incorrect %v", result))
 	}
 
-	actionMessage := fmt.Sprintf(cdStageActionMessageMap[stageAction], name)
+	actionMessage := message.Format(message.CDStage, stageAction, message.Args{"name": name})
 	if cdStage.ActionLog.ActionMessage != actionMessage {
 		t.Fatal(fmt.Sprintf("action message is incorrect %v", actionMessage))
 	}
@@ -6,38 +6,42 @@ import (
 	"github.com/epmd-edp/reconciler/v2/pkg/model"
 )
 
+// Action log is identified by fingerprint of the entity it belongs to and
+// its content, so replaying the same status doesn't add one more record.
+// Action message is left out as it depends on the configured locale and
+// message templates rather than on the status.
+// Fingerprint is calculated by the database the same way migration
+// calculates it for records created before, keep them in sync.
 const (
-	InsertActionLog = "insert into \"%v\".action_log(detailed_message, username, updated_at, action, action_message, result) " +
-		"VALUES($1, $2, $3, $4, $5, $6) returning id;"
-
-	InsertCodebaseActionLog = "insert into \"%v\".codebase_action_log(codebase_id, action_log_id) " +
-		"values($1, $2);"
+	putCodebaseActionLog = "with al as (" +
+		"insert into \"%[1]v\".action_log(detailed_message, username, updated_at, action, action_message, result, fingerprint) " +
+		"values($2, $3, $4, $5, $6, $7, md5(concat_ws('|', 'codebase', $1::integer, extract(epoch from $4::timestamptz), " +
+		"$5::text, $7::text, $3::text, $2::text))) " +
+		"on conflict (fingerprint) do nothing returning id) " +
+		"insert into \"%[1]v\".codebase_action_log(codebase_id, action_log_id) select $1, id from al;"
 )
 
-func CreateCodebaseAction(txn sql.Tx, codebaseId int, codebaseActionId int, schemaName string) error {
-	stmt, err := txn.Prepare(fmt.Sprintf(InsertCodebaseActionLog, schemaName))
+// PutCodebaseActionLog stores the action log of the codebase unless it has
+// been stored already and reports whether it has been stored.
+func PutCodebaseActionLog(txn sql.Tx, codebaseId int, actionLog model.ActionLog, schemaName string) (bool, error) {
+	return putActionLog(txn, putCodebaseActionLog, codebaseId, actionLog, schemaName)
+}
+
+func putActionLog(txn sql.Tx, query string, entityId int, actionLog model.ActionLog, schemaName string) (bool, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(query, schemaName))
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(codebaseId, codebaseActionId)
+	res, err := stmt.Exec(entityId, actionLog.DetailedMessage, actionLog.Username, actionLog.UpdatedAt,
+		actionLog.Action, actionLog.ActionMessage, actionLog.Result)
 	if err != nil {
-		return err
+		return false, err
 	}
-	return nil
-}
-
-func CreateActionLog(txn sql.Tx, actionLog model.ActionLog, schemaName string) (*int, error) {
-	stmt, err := txn.Prepare(fmt.Sprintf(InsertActionLog, schemaName))
+	n, err := res.RowsAffected()
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	defer stmt.Close()
-
-	var id int
-	err = stmt.QueryRow(actionLog.DetailedMessage, actionLog.Username, actionLog.UpdatedAt,
-		actionLog.Action, actionLog.ActionMessage, actionLog.Result).Scan(&id)
-
-	return &id, err
+	return n > 0, nil
 }
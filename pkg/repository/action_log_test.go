@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestPutActionLog_FingerprintShouldNotDependOnActionMessage(t *testing.T) {
+	fingerprint := regexp.MustCompile(`md5\(concat_ws\((.+?)\)\)\)`)
+	for _, query := range []string{putCodebaseActionLog, putCodebaseBranchActionLog, putCDPipelineActionLog, putCDStageActionLog} {
+		m := fingerprint.FindStringSubmatch(query)
+		if m == nil {
+			t.Fatalf("fingerprint hasn't been found in %v", query)
+		}
+		if regexp.MustCompile(`\$6\b`).MatchString(m[1]) {
+			t.Errorf("fingerprint depends on action message: %v", m[1])
+		}
+	}
+}
@@ -1,14 +1,13 @@
 package repository
 
 import (
-	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/epmd-edp/reconciler/v2/pkg/model"
 	"testing"
 	"time"
 )
 
-func TestCreateEventActionLog(t *testing.T) {
+func TestPutCDPipelineActionLog(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
@@ -16,7 +15,6 @@ func TestCreateEventActionLog(t *testing.T) {
 	defer db.Close()
 
 	log := model.ActionLog{
-		Id:              1,
 		Username:        "fake-username",
 		UpdatedAt:       time.Now(),
 		DetailedMessage: "fake-detailed-message",
@@ -26,22 +24,29 @@ func TestCreateEventActionLog(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectPrepare(`insert into "fake-schema".action_log`).ExpectQuery().
-		WithArgs(log.DetailedMessage, log.Username, log.UpdatedAt, log.Action, log.ActionMessage, log.Result).
-		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(log.Id))
+	mock.ExpectPrepare(`insert into "fake-schema".action_log(.+) on conflict \(fingerprint\) do nothing`).ExpectExec().
+		WithArgs(1, log.DetailedMessage, log.Username, log.UpdatedAt, log.Action, log.ActionMessage, log.Result).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`insert into "fake-schema".action_log`).ExpectExec().
+		WithArgs(1, log.DetailedMessage, log.Username, log.UpdatedAt, log.Action, log.ActionMessage, log.Result).
+		WillReturnResult(sqlmock.NewResult(0, 0))
 
 	tx, err := db.Begin()
 	if err != nil {
 		panic(err)
 	}
 
-	id, err := CreateEventActionLog(*tx, log, "fake-schema")
+	stored, err := PutCDPipelineActionLog(*tx, 1, log, "fake-schema")
+	if err != nil || !stored {
+		t.Fatalf("action log hasn't been stored: %v", err)
+	}
 
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Fatal(err)
+	stored, err = PutCDPipelineActionLog(*tx, 1, log, "fake-schema")
+	if err != nil || stored {
+		t.Fatalf("duplicate action log has been stored: %v", err)
 	}
 
-	if *id != log.Id {
-		t.Fatal(fmt.Sprintf("id is incorrect %v, but expected %v", *id, log.Id))
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
 	}
 }
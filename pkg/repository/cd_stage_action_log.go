@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+)
+
+const (
+	putCDStageActionLog = "with al as (" +
+		"insert into \"%[1]v\".action_log(detailed_message, username, updated_at, action, action_message, result, fingerprint) " +
+		"values($2, $3, $4, $5, $6, $7, md5(concat_ws('|', 'cd_stage', $1::integer, extract(epoch from $4::timestamptz), " +
+		"$5::text, $7::text, $3::text, $2::text))) " +
+		"on conflict (fingerprint) do nothing returning id) " +
+		"insert into \"%[1]v\".cd_stage_action_log(cd_stage_id, action_log_id) select $1, id from al;"
+)
+
+// PutCDStageActionLog stores the action log of CD stage unless it has
+// been stored already and reports whether it has been stored.
+func PutCDStageActionLog(txn sql.Tx, stageId int, actionLog model.ActionLog, schemaName string) (bool, error) {
+	return putActionLog(txn, putCDStageActionLog, stageId, actionLog, schemaName)
+}
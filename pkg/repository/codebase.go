@@ -19,7 +19,19 @@ const (
 	updateCodebaseStatus = "update \"%v\".codebase set status = $1 where id = $2;"
 	selectApplication    = "select id from \"%v\".codebase where name=$1 and type='application';"
 	deleteCodebase       = "delete from \"%v\".codebase where name=$1;"
-	updateCodebase       = "update \"%v\".codebase set commit_message_pattern = $1, ticket_name_pattern = $2 where name = $3;"
+	updateCodebase       = "update \"%v\".codebase set type = $1, language = $2, framework = $3, build_tool = $4, strategy = $5," +
+		" repository_url = $6, route_site = $7, route_path = $8, database_kind = $9, database_version = $10," +
+		" database_capacity = $11, database_storage = $12, test_report_framework = $13, description = $14," +
+		" git_server_id = $15, git_project_path = $16, jenkins_slave_id = $17, job_provisioning_id = $18," +
+		" deployment_script = $19, versioning_type = $20, start_versioning_from = $21, jira_server_id = $22," +
+		" commit_message_pattern = $23, ticket_name_pattern = $24, ci_tool = $25, perf_server_id = $26," +
+		" default_branch = $27 where name = $28;"
+	selectCodebaseColumns = "select type, language, framework, build_tool, strategy, repository_url, route_site, route_path," +
+		" database_kind, database_version, database_capacity, database_storage, test_report_framework, description," +
+		" git_server_id, git_project_path, jenkins_slave_id, job_provisioning_id, deployment_script, versioning_type," +
+		" start_versioning_from, jira_server_id, commit_message_pattern, ticket_name_pattern, ci_tool, perf_server_id," +
+		" default_branch, status from \"%v\".codebase where name=$1;"
+	insertCodebaseChange = "insert into \"%v\".codebase_change_log(codebase_id, field, old_value, new_value) values ($1, $2, $3, $4);"
 )
 
 const (
@@ -148,6 +160,8 @@ func Delete(txn sql.Tx, name, schema string) error {
 	return nil
 }
 
+// Update sets all the columns derived from codebase custom resource except
+// status, which is updated along with action log.
 func Update(txn sql.Tx, c codebase.Codebase, schema string) error {
 	stmt, err := txn.Prepare(fmt.Sprintf(updateCodebase, schema))
 	if err != nil {
@@ -155,6 +169,101 @@ func Update(txn sql.Tx, c codebase.Codebase, schema string) error {
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(getStringOrNil(c.CommitMessagePattern), getStringOrNil(c.TicketNamePattern), c.Name)
+	_, err = stmt.Exec(c.Type, strings.ToLower(c.Language), c.Framework, strings.ToLower(c.BuildTool),
+		strings.ToLower(c.Strategy), c.RepositoryUrl, c.RouteSite, c.RoutePath, c.DatabaseKind, c.DatabaseVersion,
+		c.DatabaseCapacity, c.DatabaseStorage, c.TestReportFramework, c.Description,
+		getIntOrNil(c.GitServerId), getStringOrNil(c.GitUrlPath), getIntOrNil(c.JenkinsSlaveId),
+		getIntOrNil(c.JobProvisioningId), c.DeploymentScript, c.VersioningType, c.StartVersioningFrom,
+		getIntOrNil(c.JiraServerId), getStringOrNil(c.CommitMessagePattern), getStringOrNil(c.TicketNamePattern),
+		c.CiTool, getPerfIdOrNil(c.Perf), c.DefaultBranch, c.Name)
 	return err
 }
+
+// SelectCodebase returns stored columns of the codebase, references are
+// filled with ids only. Nil is returned if the codebase doesn't exist.
+func SelectCodebase(txn sql.Tx, name, schema string) (*codebase.Codebase, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(selectCodebaseColumns, schema))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var language, framework, buildTool, strategy, repositoryUrl, routeSite, routePath, dbKind, dbVersion,
+		dbCapacity, dbStorage, testReportFramework, description, gitUrlPath, deploymentScript, versioningType,
+		startVersioningFrom, commitMessagePattern, ticketNamePattern, ciTool, defaultBranch, status sql.NullString
+	var gitServerId, jenkinsSlaveId, jobProvisioningId, jiraServerId, perfServerId sql.NullInt64
+	c := codebase.Codebase{Name: name, Tenant: schema}
+	err = stmt.QueryRow(name).Scan(&c.Type, &language, &framework, &buildTool, &strategy, &repositoryUrl,
+		&routeSite, &routePath, &dbKind, &dbVersion, &dbCapacity, &dbStorage, &testReportFramework, &description,
+		&gitServerId, &gitUrlPath, &jenkinsSlaveId, &jobProvisioningId, &deploymentScript, &versioningType,
+		&startVersioningFrom, &jiraServerId, &commitMessagePattern, &ticketNamePattern, &ciTool, &perfServerId,
+		&defaultBranch, &status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	c.Language = language.String
+	c.Framework = nullableString(framework)
+	c.BuildTool = buildTool.String
+	c.Strategy = strategy.String
+	c.RepositoryUrl = repositoryUrl.String
+	c.RouteSite = routeSite.String
+	c.RoutePath = routePath.String
+	c.DatabaseKind = dbKind.String
+	c.DatabaseVersion = dbVersion.String
+	c.DatabaseCapacity = dbCapacity.String
+	c.DatabaseStorage = dbStorage.String
+	c.TestReportFramework = testReportFramework.String
+	c.Description = description.String
+	c.GitServerId = nullableInt(gitServerId)
+	c.GitUrlPath = nullableString(gitUrlPath)
+	c.JenkinsSlaveId = nullableInt(jenkinsSlaveId)
+	c.JobProvisioningId = nullableInt(jobProvisioningId)
+	c.DeploymentScript = deploymentScript.String
+	c.VersioningType = versioningType.String
+	c.StartVersioningFrom = nullableString(startVersioningFrom)
+	c.JiraServerId = nullableInt(jiraServerId)
+	c.CommitMessagePattern = nullableString(commitMessagePattern)
+	c.TicketNamePattern = nullableString(ticketNamePattern)
+	c.CiTool = ciTool.String
+	if perfServerId.Valid {
+		c.Perf = &codebase.Perf{Id: nullableInt(perfServerId)}
+	}
+	c.DefaultBranch = defaultBranch.String
+	c.Status = status.String
+	return &c, nil
+}
+
+// InsertCodebaseChanges records changes of the codebase columns to the change log.
+func InsertCodebaseChanges(txn sql.Tx, codebaseId int, changes []codebase.Change, schema string) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(insertCodebaseChange, schema))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, ch := range changes {
+		if _, err := stmt.Exec(codebaseId, ch.Field, ch.Old, ch.New); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func nullableString(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
+
+func nullableInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	i := int(v.Int64)
+	return &i
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+)
+
+const (
+	putCodebaseBranchActionLog = "with al as (" +
+		"insert into \"%[1]v\".action_log(detailed_message, username, updated_at, action, action_message, result, fingerprint) " +
+		"values($2, $3, $4, $5, $6, $7, md5(concat_ws('|', 'codebase_branch', $1::integer, extract(epoch from $4::timestamptz), " +
+		"$5::text, $7::text, $3::text, $2::text))) " +
+		"on conflict (fingerprint) do nothing returning id) " +
+		"insert into \"%[1]v\".codebase_branch_action_log(codebase_branch_id, action_log_id) select $1, id from al;"
+
+	// selectCodebaseTimeline merges action logs of the codebase and its
+	// branches, the newest go first.
+	selectCodebaseTimeline = "select id, detailed_message, username, updated_at, action, action_message, result, branch from (" +
+		"select al.*, '' branch from \"%[1]v\".action_log al " +
+		"join \"%[1]v\".codebase_action_log cal on cal.action_log_id = al.id " +
+		"join \"%[1]v\".codebase c on c.id = cal.codebase_id " +
+		"where c.name = $1 " +
+		"union all " +
+		"select al.*, cb.name branch from \"%[1]v\".action_log al " +
+		"join \"%[1]v\".codebase_branch_action_log cbal on cbal.action_log_id = al.id " +
+		"join \"%[1]v\".codebase_branch cb on cb.id = cbal.codebase_branch_id " +
+		"join \"%[1]v\".codebase c on c.id = cb.codebase_id " +
+		"where c.name = $1) t " +
+		"where ($2 = '' or branch = $2) and ($3 = '' or action = $3) and ($4 = '' or result = $4) and updated_at >= $5 " +
+		"order by updated_at desc, id desc limit $6;"
+)
+
+// PutCodebaseBranchActionLog stores the action log of the codebase branch
+// unless it has been stored already and reports whether it has been stored.
+func PutCodebaseBranchActionLog(txn sql.Tx, branchId int, actionLog model.ActionLog, schemaName string) (bool, error) {
+	return putActionLog(txn, putCodebaseBranchActionLog, branchId, actionLog, schemaName)
+}
+
+// GetCodebaseTimeline returns action logs of the codebase and its branches
+// matching the filter, the newest go first.
+func GetCodebaseTimeline(txn sql.Tx, codebase string, f model.TimelineFilter, schemaName string) ([]model.TimelineEntry, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(selectCodebaseTimeline, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var limit *int
+	if f.Limit > 0 {
+		limit = &f.Limit
+	}
+	rows, err := stmt.Query(codebase, f.Branch, f.Action, f.Result, f.Since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []model.TimelineEntry
+	for rows.Next() {
+		var e model.TimelineEntry
+		var detailedMessage, username, action, actionMessage, result sql.NullString
+		if err := rows.Scan(&e.Id, &detailedMessage, &username, &e.UpdatedAt, &action, &actionMessage,
+			&result, &e.Branch); err != nil {
+			return nil, err
+		}
+		e.DetailedMessage = detailedMessage.String
+		e.Username = username.String
+		e.Action = action.String
+		e.ActionMessage = actionMessage.String
+		e.Result = result.String
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -41,6 +41,12 @@ const (
 	SelectCodebaseDockerStreamBranchId = "select cds.codebase_branch_id from \"%v\".codebase_docker_stream cds where cds.id = $1;"
 )
 
+const selectCodebaseBranchDockerStreams = "select cds.id, cds.oc_image_stream_name, cb.name" +
+	" from \"%[1]v\".codebase_docker_stream cds" +
+	" join \"%[1]v\".codebase_branch cb on cds.codebase_branch_id = cb.id" +
+	" join \"%[1]v\".codebase c on cb.codebase_id = c.id" +
+	" where c.name = $1 order by cds.oc_image_stream_name;"
+
 func CreateCodebaseDockerStream(txn sql.Tx, schemaName string, branchId *int, ocImageStreamName string) (id *int, err error) {
 	stmt, err := txn.Prepare(fmt.Sprintf(CreateCodebaseDockerStreamQuery, schemaName))
 	if err != nil {
@@ -218,3 +224,29 @@ func checkNoRows(err error) (*int, error) {
 	}
 	return nil, err
 }
+
+// GetCodebaseBranchDockerStreams returns docker streams built from branches of
+// the codebase sorted by name.
+func GetCodebaseBranchDockerStreams(txn sql.Tx, codebase, schemaName string) ([]model.CodebaseBranchDockerStreamDTO, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(selectCodebaseBranchDockerStreams, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(codebase)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var streams []model.CodebaseBranchDockerStreamDTO
+	for rows.Next() {
+		s := model.CodebaseBranchDockerStreamDTO{}
+		if err := rows.Scan(&s.Id, &s.Name, &s.Branch); err != nil {
+			return nil, err
+		}
+		streams = append(streams, s)
+	}
+	return streams, rows.Err()
+}
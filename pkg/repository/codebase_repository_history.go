@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebase"
+)
+
+const (
+	insertRepositoryHistory = "insert into \"%v\".codebase_repository_history(codebase_id, git_server_id, git_project_path)" +
+		" values ($1, $2, $3);"
+	// selectRepositoryHistory returns previous locations of the codebase,
+	// the latest go first.
+	selectRepositoryHistory = "select h.git_server_id, h.git_project_path, h.moved_at" +
+		" from \"%[1]v\".codebase_repository_history h" +
+		" join \"%[1]v\".codebase c on c.id = h.codebase_id" +
+		" where c.name = $1 order by h.moved_at desc, h.id desc;"
+)
+
+// InsertRepositoryHistory records the location the codebase has been moved away from.
+func InsertRepositoryHistory(txn sql.Tx, codebaseId int, l codebase.RepositoryLocation, schemaName string) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(insertRepositoryHistory, schemaName))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(codebaseId, getIntOrNil(l.GitServerId), getStringOrNil(l.GitUrlPath))
+	return err
+}
+
+// SelectRepositoryHistory returns previous locations of the codebase, the latest go first.
+func SelectRepositoryHistory(txn sql.Tx, codebaseName, schemaName string) ([]codebase.RepositoryLocation, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(selectRepositoryHistory, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(codebaseName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var locations []codebase.RepositoryLocation
+	for rows.Next() {
+		var serverId sql.NullInt64
+		var path sql.NullString
+		l := codebase.RepositoryLocation{}
+		if err := rows.Scan(&serverId, &path, &l.MovedAt); err != nil {
+			return nil, err
+		}
+		l.GitServerId = nullableInt(serverId)
+		l.GitUrlPath = nullableString(path)
+		locations = append(locations, l)
+	}
+	return locations, rows.Err()
+}
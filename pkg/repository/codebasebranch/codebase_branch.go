@@ -3,19 +3,29 @@ package codebasebranch
 import (
 	"database/sql"
 	"fmt"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebasebranch"
 )
 
 const (
 	SelectCodebaseBranch = "select cb.id as codebase_branch_id from \"%v\".codebase_branch cb" +
 		" left join \"%v\".codebase c on cb.codebase_id = c.id where cb.name=$1 and c.name=$2;"
-	InsertCodebaseBranch = "insert into \"%v\".codebase_branch(name, codebase_id, from_commit, output_codebase_docker_stream_id, status, version, build_number, last_success_build, release)" +
-		" values ($1, $2, $3, $4, $5, $6, $7, $8, $9) returning id;"
+	InsertCodebaseBranch = "insert into \"%v\".codebase_branch(name, codebase_id, from_commit, output_codebase_docker_stream_id, status, version, build_number, last_success_build, release, last_success_build_at)" +
+		" values ($1, $2, $3, $4, $5, $6, $7, $8, $9, case when $8::text is null then null else now() end) returning id;"
 	UpdateCodebaseBranchStatus = "update \"%v\".codebase_branch set status = $1 where id = $2;"
-	UpdateCodebaseBranchValues = "update \"%v\".codebase_branch set version = $1, build_number = $2, last_success_build = $3 where id = $4;"
+	UpdateCodebaseBranchValues = "update \"%v\".codebase_branch set from_commit = $1, release = $2, version = $3, build_number = $4, last_success_build_at = case when last_success_build is distinct from $5 then now() else last_success_build_at end, last_success_build = $5 where id = $6;"
 	deleteCodebaseBranch       = "delete from \"%[1]v\".codebase_branch where \"%[1]v\".codebase_branch.id=(select cb.id from" +
 		" \"%[1]v\".codebase_branch cb left join \"%[1]v\".codebase c on cb.codebase_id = c.id where c.name = $1 and cb.name = $2);"
 )
 
+const (
+	selectCodebaseBranchValues = "select cb.from_commit, cb.release, cb.version, cb.build_number, cb.last_success_build," +
+		" cb.last_success_build_at, cb.status from \"%[1]v\".codebase_branch cb" +
+		" join \"%[1]v\".codebase c on cb.codebase_id = c.id where c.name = $1 and cb.name = $2;"
+	selectCodebaseBranchNames = "select cb.name from \"%[1]v\".codebase_branch cb" +
+		" join \"%[1]v\".codebase c on cb.codebase_id = c.id where c.name = $1 order by cb.name;"
+)
+
 func GetCodebaseBranchId(txn sql.Tx, codebaseName string, codebaseBranchName string, schemaName string) (*int, error) {
 	stmt, err := txn.Prepare(fmt.Sprintf(SelectCodebaseBranch, schemaName, schemaName))
 	if err != nil {
@@ -35,6 +45,31 @@ func GetCodebaseBranchId(txn sql.Tx, codebaseName string, codebaseBranchName str
 	return &id, nil
 }
 
+// GetCodebaseBranchNames returns sorted names of the codebase branches.
+func GetCodebaseBranchNames(txn sql.Tx, codebase, schemaName string) ([]string, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(selectCodebaseBranchNames, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(codebase)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
 func CreateCodebaseBranch(txn sql.Tx, name string, beId int, fromCommit string,
 	schemaName string, streamId *int, status string, version *string, buildNumber *string, lastSuccessBuild *string, release bool) (*int, error) {
 	stmt, err := txn.Prepare(fmt.Sprintf(InsertCodebaseBranch, schemaName))
@@ -63,14 +98,45 @@ func UpdateStatusByCodebaseBranchId(txn sql.Tx, branchId int, status string, sch
 	return err
 }
 
-func UpdateCodebaseBranch(txn sql.Tx, branchId int, version *string, build *string, lastSuccess *string, schemaName string) error {
+// GetCodebaseBranch returns the stored codebase branch, nil if it doesn't exist.
+func GetCodebaseBranch(txn sql.Tx, codebase, branch, schemaName string) (*codebasebranch.CodebaseBranch, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(selectCodebaseBranchValues, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var fromCommit, version, build, lastSuccess, status sql.NullString
+	var lastSuccessAt sql.NullTime
+	b := codebasebranch.CodebaseBranch{Name: branch, AppName: codebase, Tenant: schemaName}
+	err = stmt.QueryRow(codebase, branch).Scan(&fromCommit, &b.Release, &version, &build, &lastSuccess,
+		&lastSuccessAt, &status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	b.FromCommit = fromCommit.String
+	b.Status = status.String
+	b.Version = nullableString(version)
+	b.BuildNumber = nullableString(build)
+	b.LastSuccessBuild = nullableString(lastSuccess)
+	if lastSuccessAt.Valid {
+		b.LastSuccessBuildAt = &lastSuccessAt.Time
+	}
+	return &b, nil
+}
+
+// UpdateCodebaseBranch sets the columns derived from custom resource but status.
+func UpdateCodebaseBranch(txn sql.Tx, branchId int, b codebasebranch.CodebaseBranch, schemaName string) error {
 	stmt, err := txn.Prepare(fmt.Sprintf(UpdateCodebaseBranchValues, schemaName))
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(version, build, lastSuccess, branchId)
+	_, err = stmt.Exec(b.FromCommit, b.Release, b.Version, b.BuildNumber, b.LastSuccessBuild, branchId)
 	return err
 }
 
@@ -80,3 +146,10 @@ func Delete(txn sql.Tx, codebase, branch, schema string) error {
 	}
 	return nil
 }
+
+func nullableString(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
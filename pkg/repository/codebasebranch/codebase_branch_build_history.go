@@ -0,0 +1,58 @@
+package codebasebranch
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebasebranch"
+)
+
+const (
+	insertBuildHistory = "insert into \"%v\".codebase_branch_build_history(codebase_branch_id, version, build_number)" +
+		" values ($1, $2, $3);"
+	// selectBuildHistory returns builds of the codebase branch, the latest go first.
+	selectBuildHistory = "select h.version, h.build_number, h.created_at from \"%[1]v\".codebase_branch_build_history h" +
+		" join \"%[1]v\".codebase_branch cb on cb.id = h.codebase_branch_id" +
+		" join \"%[1]v\".codebase c on c.id = cb.codebase_id" +
+		" where c.name = $1 and cb.name = $2 order by h.created_at desc, h.id desc;"
+)
+
+// InsertBuildHistory records the version and build number the codebase branch has moved to.
+func InsertBuildHistory(txn sql.Tx, branchId int, b codebasebranch.Build, schemaName string) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(insertBuildHistory, schemaName))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(branchId, b.Version, b.BuildNumber)
+	return err
+}
+
+// SelectBuildHistory returns builds of the codebase branch, the latest go first.
+func SelectBuildHistory(txn sql.Tx, codebase, branch, schemaName string) ([]codebasebranch.Build, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(selectBuildHistory, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(codebase, branch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var builds []codebasebranch.Build
+	for rows.Next() {
+		var version, build sql.NullString
+		b := codebasebranch.Build{}
+		if err := rows.Scan(&version, &build, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		b.Version = nullableString(version)
+		b.BuildNumber = nullableString(build)
+		builds = append(builds, b)
+	}
+	return builds, rows.Err()
+}
@@ -0,0 +1,58 @@
+package codebasebranch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebasebranch"
+)
+
+func TestBuildHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	version, build := "1.0.0", "5"
+	createdAt := time.Now()
+	query := `select h.version, h.build_number, h.created_at from "fake-schema".codebase_branch_build_history h` +
+		`(.+) order by h.created_at desc, h.id desc`
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`insert into "fake-schema".codebase_branch_build_history`).ExpectExec().
+		WithArgs(3, version, build).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare(query).ExpectQuery().
+		WithArgs("fake-app", "master").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "build_number", "created_at"}).
+			AddRow(version, build, createdAt).
+			AddRow(version, nil, createdAt.Add(-time.Hour)))
+
+	tx, err := db.Begin()
+	if err != nil {
+		panic(err)
+	}
+
+	if err := InsertBuildHistory(*tx, 3, codebasebranch.Build{Version: &version, BuildNumber: &build}, "fake-schema"); err != nil {
+		t.Fatalf("build hasn't been recorded: %v", err)
+	}
+
+	builds, err := SelectBuildHistory(*tx, "fake-app", "master", "fake-schema")
+	if err != nil {
+		t.Fatalf("build history hasn't been selected: %v", err)
+	}
+	if len(builds) != 2 {
+		t.Fatalf("unexpected build history: %+v", builds)
+	}
+	if *builds[0].Version != version || *builds[0].BuildNumber != build || !builds[0].CreatedAt.Equal(createdAt) {
+		t.Fatalf("unexpected latest build: %+v", builds[0])
+	}
+	if *builds[1].Version != version || builds[1].BuildNumber != nil {
+		t.Fatalf("unexpected previous build: %+v", builds[1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,126 @@
+package codebasebranch
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebasebranch"
+)
+
+func TestCreateCodebaseBranch_ShouldStampLastSuccessBuildOnlyIfItIsSet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	version, build := "1.0.0", "5"
+	query := `insert into "fake-schema".codebase_branch(.+)` +
+		regexp.QuoteMeta(`case when $8::text is null then null else now() end) returning id`)
+	mock.ExpectBegin()
+	mock.ExpectPrepare(query).ExpectQuery().
+		WithArgs("master", 1, "fake-commit", nil, "active", version, build, nil, true).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+	tx, err := db.Begin()
+	if err != nil {
+		panic(err)
+	}
+
+	id, err := CreateCodebaseBranch(*tx, "master", 1, "fake-commit", "fake-schema", nil, "active",
+		&version, &build, nil, true)
+	if err != nil || id == nil || *id != 3 {
+		t.Fatalf("codebase branch hasn't been created: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateCodebaseBranch_ShouldStampLastSuccessBuildOnlyIfItHasChanged(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	version, build, lastSuccess := "1.0.0", "6", "5"
+	query := `update "fake-schema".codebase_branch set (.+)` +
+		regexp.QuoteMeta(`last_success_build_at = case when last_success_build is distinct from $5 `+
+			`then now() else last_success_build_at end, last_success_build = $5 where id = $6`)
+	mock.ExpectBegin()
+	mock.ExpectPrepare(query).ExpectExec().
+		WithArgs("fake-commit", false, version, build, lastSuccess, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	if err != nil {
+		panic(err)
+	}
+
+	b := codebasebranch.CodebaseBranch{
+		FromCommit:       "fake-commit",
+		Version:          &version,
+		BuildNumber:      &build,
+		LastSuccessBuild: &lastSuccess,
+	}
+	if err := UpdateCodebaseBranch(*tx, 3, b, "fake-schema"); err != nil {
+		t.Fatalf("codebase branch hasn't been updated: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetCodebaseBranch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	columns := []string{"from_commit", "release", "version", "build_number", "last_success_build",
+		"last_success_build_at", "status"}
+	lastSuccessAt := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select cb.from_commit, (.+) from "fake-schema".codebase_branch cb`).ExpectQuery().
+		WithArgs("fake-app", "master").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(nil, true, "1.0.0", nil, "5", lastSuccessAt, "active"))
+	mock.ExpectPrepare(`select cb.from_commit, (.+) from "fake-schema".codebase_branch cb`).ExpectQuery().
+		WithArgs("fake-app", "develop").
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	tx, err := db.Begin()
+	if err != nil {
+		panic(err)
+	}
+
+	b, err := GetCodebaseBranch(*tx, "fake-app", "master", "fake-schema")
+	if err != nil || b == nil {
+		t.Fatalf("codebase branch hasn't been found: %v", err)
+	}
+	if b.Name != "master" || b.AppName != "fake-app" || b.FromCommit != "" || !b.Release ||
+		b.Status != "active" || b.BuildNumber != nil {
+		t.Fatalf("unexpected codebase branch: %+v", b)
+	}
+	if b.Version == nil || *b.Version != "1.0.0" || b.LastSuccessBuild == nil || *b.LastSuccessBuild != "5" {
+		t.Fatalf("unexpected builds of codebase branch: %+v", b)
+	}
+	if b.LastSuccessBuildAt == nil || !b.LastSuccessBuildAt.Equal(lastSuccessAt) {
+		t.Fatalf("unexpected time of last success build: %v", b.LastSuccessBuildAt)
+	}
+
+	b, err = GetCodebaseBranch(*tx, "fake-app", "develop", "fake-schema")
+	if err != nil || b != nil {
+		t.Fatalf("missing codebase branch has been found: %+v, %v", b, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,7 +2,10 @@ package codebaseperfdatasource
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/model/perfdatasource"
 )
 
 const (
@@ -11,6 +14,17 @@ const (
 	deleteCodebasePerfDataSource = "delete from \"%v\".codebase_perf_data_sources where codebase_id=$1;"
 )
 
+const (
+	selectCodebasePerfDataSources = "select pds.type from \"%[1]v\".codebase_perf_data_sources cpds" +
+		" join \"%[1]v\".perf_data_sources pds on pds.id = cpds.data_source_id where cpds.codebase_id=$1 order by pds.type;"
+	deleteCodebasePerfDataSourceByType = "delete from \"%[1]v\".codebase_perf_data_sources where codebase_id=$1" +
+		" and data_source_id = (select id from \"%[1]v\".perf_data_sources where type=$2);"
+)
+
+const updateCodebasePerfDataSourceConfig = "update \"%[1]v\".codebase_perf_data_sources set name=$3, perf_server=$4," +
+	" url=$5, config=$6, updated_at=now() where codebase_id=$1" +
+	" and data_source_id = (select id from \"%[1]v\".perf_data_sources where type=$2);"
+
 func InsertCodebasePerfDataSource(txn sql.Tx, codebaseId, dsId int, tenant string) error {
 	stmt, err := txn.Prepare(fmt.Sprintf(insertPerfDataSource, tenant))
 	if err != nil {
@@ -45,3 +59,41 @@ func DeleteCodebasePerfDataSourceRecord(txn sql.Tx, codebaseId int, schema strin
 	}
 	return nil
 }
+
+// SelectCodebasePerfDataSources returns types of the data sources the codebase is linked to.
+func SelectCodebasePerfDataSources(txn sql.Tx, codebaseId int, schema string) ([]string, error) {
+	rows, err := txn.Query(fmt.Sprintf(selectCodebasePerfDataSources, schema), codebaseId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var types []string
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, rows.Err()
+}
+
+func DeleteCodebasePerfDataSource(txn sql.Tx, codebaseId int, dsType, schema string) error {
+	if _, err := txn.Exec(fmt.Sprintf(deleteCodebasePerfDataSourceByType, schema), codebaseId, dsType); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateCodebasePerfDataSourceConfig records configuration of the data source
+// the codebase is linked to.
+func UpdateCodebasePerfDataSourceConfig(txn sql.Tx, codebaseId int, ds perfdatasource.PerfDataSource, schema string) error {
+	config, err := json.Marshal(ds.Config)
+	if err != nil {
+		return err
+	}
+	_, err = txn.Exec(fmt.Sprintf(updateCodebasePerfDataSourceConfig, schema), codebaseId, ds.Type, ds.Name,
+		ds.PerfServer, ds.Url, string(config))
+	return err
+}
@@ -0,0 +1,87 @@
+package outbox
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/lib/pq"
+)
+
+const (
+	insertEvent = "insert into \"%v\".outbox(kind, operation, entity_id, subject, data) " +
+		"values ($1, $2, $3, $4, $5) returning id;"
+	// selectPending locks the events, so they aren't delivered twice by
+	// concurrent publishers.
+	selectPending = "select id, kind, operation, entity_id, subject, data, created_at from \"%v\".outbox " +
+		"order by id limit $1 for update skip locked;"
+	deleteEvents = "delete from \"%v\".outbox where id = any($1);"
+)
+
+// InsertEvent records the event in the outbox and returns its id.
+func InsertEvent(txn sql.Tx, e model.Event, schemaName string) (*int, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(insertEvent, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return nil, err
+	}
+	var id int
+	if err := stmt.QueryRow(e.Kind, e.Operation, e.EntityId, e.Subject, string(data)).Scan(&id); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// SelectPending returns up to limit events in order they have been recorded.
+func SelectPending(txn sql.Tx, limit int, schemaName string) ([]model.Event, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(selectPending, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []model.Event
+	for rows.Next() {
+		var e model.Event
+		var entityId sql.NullInt64
+		var data []byte
+		if err := rows.Scan(&e.Id, &e.Kind, &e.Operation, &entityId, &e.Subject, &data, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if entityId.Valid {
+			id := int(entityId.Int64)
+			e.EntityId = &id
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &e.Data); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// DeleteEvents removes delivered events from the outbox.
+func DeleteEvents(txn sql.Tx, ids []int, schemaName string) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(deleteEvents, schemaName))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(pq.Array(ids))
+	return err
+}
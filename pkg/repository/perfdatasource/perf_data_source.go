@@ -3,16 +3,12 @@ package perfdatasource
 import (
 	"database/sql"
 	"fmt"
-	"strings"
 )
 
 const (
-	perfDataSourceExists         = "select exists(select 1 from \"%v\".perf_data_sources where type=$1);"
-	insertPerfDataSource         = "insert into \"%v\".perf_data_sources(type) values ($1) returning id;"
-	selectPerfDataSource         = "select id from \"%v\".perf_data_sources where type = $1;"
-	deleteCodebasePerfDataSource = "delete from \"%[1]v\".codebase_perf_data_sources cpds where cpds.data_source_id =" +
-		" (select pds.id from \"%[1]v\".perf_data_sources pds where pds.type = $1) " +
-		"and cpds.codebase_id= (select c.id from \"%[1]v\".codebase c where c.name= $2);"
+	perfDataSourceExists = "select exists(select 1 from \"%v\".perf_data_sources where type=$1);"
+	insertPerfDataSource = "insert into \"%v\".perf_data_sources(type) values ($1) returning id;"
+	selectPerfDataSource = "select id from \"%v\".perf_data_sources where type = $1;"
 )
 
 func PerfDataSourceExists(txn sql.Tx, dsType, tenant string) (bool, error) {
@@ -56,10 +52,3 @@ func GetDataSourceId(txn sql.Tx, dsType, tenant string) (*int, error) {
 	}
 	return &id, err
 }
-
-func RemoveCodebaseDataSource(txn sql.Tx, codebase, dataSource, schema string) error {
-	if _, err := txn.Exec(fmt.Sprintf(deleteCodebasePerfDataSource, schema), strings.ToUpper(dataSource), codebase); err != nil {
-		return err
-	}
-	return nil
-}
@@ -0,0 +1,86 @@
+package resync
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Queries return keys of projected rows in the same format the resync
+// controller builds them from custom resources.
+const (
+	SelectCodebaseKeys       = "select name from \"%[1]v\".codebase;"
+	SelectCodebaseBranchKeys = "select c.name || '/' || cb.name from \"%[1]v\".codebase_branch cb " +
+		"left join \"%[1]v\".codebase c on cb.codebase_id = c.id;"
+	SelectCDPipelineKeys = "select name from \"%[1]v\".cd_pipeline;"
+	SelectStageKeys      = "select cp.name || '/' || cs.name from \"%[1]v\".cd_stage cs " +
+		"left join \"%[1]v\".cd_pipeline cp on cs.cd_pipeline_id = cp.id;"
+	SelectGitServerKeys    = "select name from \"%[1]v\".git_server;"
+	SelectJiraServerKeys   = "select name from \"%[1]v\".jira_server;"
+	SelectPerfServerKeys   = "select name from \"%[1]v\".perf_server;"
+	SelectServiceKeys      = "select name from \"%[1]v\".third_party_service;"
+	SelectEDPComponentKeys = "select type from \"%[1]v\".edp_component;"
+)
+
+// Queries return key of projected row followed by the fields drift detector
+// compares with custom resources. All fields are selected as text.
+const (
+	SelectCodebaseFields = "select name, coalesce(status, ''), coalesce(commit_message_pattern, ''), " +
+		"coalesce(ticket_name_pattern, ''), coalesce(default_branch, '') from \"%[1]v\".codebase;"
+	SelectCodebaseBranchFields = "select c.name || '/' || cb.name, coalesce(cb.status, '') from \"%[1]v\".codebase_branch cb " +
+		"left join \"%[1]v\".codebase c on cb.codebase_id = c.id;"
+	SelectCDPipelineFields = "select name, coalesce(status, '') from \"%[1]v\".cd_pipeline;"
+	SelectStageFields      = "select cp.name || '/' || cs.name, coalesce(cs.status, '') from \"%[1]v\".cd_stage cs " +
+		"left join \"%[1]v\".cd_pipeline cp on cs.cd_pipeline_id = cp.id;"
+	SelectGitServerFields    = "select name, coalesce(hostname, '') from \"%[1]v\".git_server;"
+	SelectJiraServerFields   = "select name, available::text from \"%[1]v\".jira_server;"
+	SelectPerfServerFields   = "select name, available::text from \"%[1]v\".perf_server;"
+	SelectServiceFields      = "select name, coalesce(version, ''), coalesce(url, '') from \"%[1]v\".third_party_service;"
+	SelectEDPComponentFields = "select type, coalesce(url, ''), visible::text from \"%[1]v\".edp_component;"
+)
+
+func SelectKeys(txn sql.Tx, query, schemaName string) ([]string, error) {
+	rows, err := txn.Query(fmt.Sprintf(query, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// SelectRows returns rows keyed by the first column, the rest columns are
+// returned as values in the order they are selected.
+func SelectRows(txn sql.Tx, query, schemaName string) (map[string][]string, error) {
+	rows, err := txn.Query(fmt.Sprintf(query, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string][]string{}
+	for rows.Next() {
+		vals := make([]string, len(cols))
+		dest := make([]interface{}, len(cols))
+		for i := range vals {
+			dest[i] = &vals[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		res[vals[0]] = vals[1:]
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,137 @@
+package retention
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// selectExpired returns action logs ranked beyond $1 latest ones of their
+	// entity, updated before $2 or not belonging to any entity, limited by $3.
+	selectExpired = "with linked as (" +
+		"select action_log_id, 'codebase' kind, codebase_id entity_id from \"%[1]v\".codebase_action_log " +
+		"union all select action_log_id, 'codebase_branch', codebase_branch_id from \"%[1]v\".codebase_branch_action_log " +
+		"union all select action_log_id, 'cd_pipeline', cd_pipeline_id from \"%[1]v\".cd_pipeline_action_log " +
+		"union all select action_log_id, 'cd_stage', cd_stage_id from \"%[1]v\".cd_stage_action_log), " +
+		"ranked as (select al.id, l.kind, l.entity_id, row_number() over " +
+		"(partition by l.kind, l.entity_id order by al.updated_at desc nulls last, al.id desc) rn " +
+		"from \"%[1]v\".action_log al left join linked l on l.action_log_id = al.id) " +
+		"select al.id, coalesce(r.kind, ''), r.entity_id, coalesce(al.detailed_message, ''), coalesce(al.username, ''), " +
+		"al.updated_at, coalesce(al.action, ''), coalesce(al.action_message, ''), coalesce(al.result, '') " +
+		"from ranked r join \"%[1]v\".action_log al on al.id = r.id " +
+		"where r.kind is null or ($1 > 0 and r.rn > $1) or al.updated_at < $2 " +
+		"order by al.id limit $3;"
+	insertArchive = "insert into \"%v\".action_log_archive(id, entity_kind, entity_id, detailed_message, username, " +
+		"updated_at, action, action_message, result) values ($1, $2, $3, $4, $5, $6, $7, $8, $9) on conflict (id) do nothing;"
+	deleteActionLogLinks = "delete from \"%v\".%v where action_log_id = any($1);"
+	deleteActionLogs     = "delete from \"%v\".action_log where id = any($1);"
+)
+
+// linkTables relate action logs to entities.
+var linkTables = []string{
+	"codebase_action_log",
+	"codebase_branch_action_log",
+	"cd_pipeline_action_log",
+	"cd_stage_action_log",
+}
+
+// ActionLog is an action log along with the entity it belongs to. Kind is
+// empty if the entity has been removed.
+type ActionLog struct {
+	Id              int        `json:"id"`
+	EntityKind      string     `json:"entityKind,omitempty"`
+	EntityId        *int       `json:"entityId,omitempty"`
+	DetailedMessage string     `json:"detailedMessage"`
+	Username        string     `json:"username"`
+	UpdatedAt       *time.Time `json:"updatedAt"`
+	Action          string     `json:"action"`
+	ActionMessage   string     `json:"actionMessage"`
+	Result          string     `json:"result"`
+}
+
+// SelectExpired returns up to limit action logs which are out of retention
+// policy. Cutoff is ignored if it's zero, so is keepLast.
+func SelectExpired(txn sql.Tx, keepLast int, cutoff time.Time, limit int, schemaName string) ([]ActionLog, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(selectExpired, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var c *time.Time
+	if !cutoff.IsZero() {
+		c = &cutoff
+	}
+	rows, err := stmt.Query(keepLast, c, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []ActionLog
+	for rows.Next() {
+		var l ActionLog
+		var entityId sql.NullInt64
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&l.Id, &l.EntityKind, &entityId, &l.DetailedMessage, &l.Username, &updatedAt,
+			&l.Action, &l.ActionMessage, &l.Result); err != nil {
+			return nil, err
+		}
+		if entityId.Valid {
+			id := int(entityId.Int64)
+			l.EntityId = &id
+		}
+		if updatedAt.Valid {
+			l.UpdatedAt = &updatedAt.Time
+		}
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
+
+// InsertArchive copies action logs into archive table.
+func InsertArchive(txn sql.Tx, logs []ActionLog, schemaName string) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(insertArchive, schemaName))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, l := range logs {
+		var kind *string
+		if l.EntityKind != "" {
+			kind = &l.EntityKind
+		}
+		if _, err := stmt.Exec(l.Id, kind, l.EntityId, l.DetailedMessage, l.Username, l.UpdatedAt,
+			l.Action, l.ActionMessage, l.Result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteActionLogs removes relations of action logs to entities and then the
+// action logs. Relations are removed explicitly, as tables created before the
+// migrations may lack cascading foreign keys.
+func DeleteActionLogs(txn sql.Tx, ids []int, schemaName string) error {
+	for _, t := range linkTables {
+		if err := exec(txn, fmt.Sprintf(deleteActionLogLinks, schemaName, t), ids); err != nil {
+			return err
+		}
+	}
+	return exec(txn, fmt.Sprintf(deleteActionLogs, schemaName), ids)
+}
+
+func exec(txn sql.Tx, query string, ids []int) error {
+	stmt, err := txn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(pq.Array(ids))
+	return err
+}
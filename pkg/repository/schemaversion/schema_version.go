@@ -0,0 +1,70 @@
+package schemaversion
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+const (
+	acquireLock              = "select pg_advisory_xact_lock(hashtext($1));"
+	createSchema             = "create schema if not exists \"%v\";"
+	createSchemaVersionTable = "create table if not exists \"%v\".schema_version(" +
+		"version integer primary key, " +
+		"description text not null, " +
+		"checksum text not null, " +
+		"installed_on timestamp with time zone not null default now());"
+	selectAppliedVersions = "select version, checksum from \"%v\".schema_version order by version;"
+	insertSchemaVersion   = "insert into \"%v\".schema_version(version, description, checksum) values ($1, $2, $3);"
+)
+
+// AppliedVersion is a row of schema_version table.
+type AppliedVersion struct {
+	Version  int
+	Checksum string
+}
+
+// AcquireLock takes transaction level advisory lock bound to schema name,
+// so concurrent reconciler instances apply migrations one after another.
+func AcquireLock(txn sql.Tx, schemaName string) error {
+	_, err := txn.Exec(acquireLock, fmt.Sprintf("schema_version.%v", schemaName))
+	return err
+}
+
+func CreateSchema(txn sql.Tx, schemaName string) error {
+	_, err := txn.Exec(fmt.Sprintf(createSchema, schemaName))
+	return err
+}
+
+func CreateSchemaVersionTable(txn sql.Tx, schemaName string) error {
+	_, err := txn.Exec(fmt.Sprintf(createSchemaVersionTable, schemaName))
+	return err
+}
+
+func GetAppliedVersions(txn sql.Tx, schemaName string) ([]AppliedVersion, error) {
+	rows, err := txn.Query(fmt.Sprintf(selectAppliedVersions, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []AppliedVersion
+	for rows.Next() {
+		v := AppliedVersion{}
+		if err := rows.Scan(&v.Version, &v.Checksum); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+func InsertSchemaVersion(txn sql.Tx, schemaName string, version int, description, checksum string) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(insertSchemaVersion, schemaName))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(version, description, checksum)
+	return err
+}
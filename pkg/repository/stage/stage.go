@@ -52,7 +52,7 @@ const (
 		"left join \"%[1]v\".stage_codebase_docker_stream scds on cds.id = scds.output_codebase_docker_stream_id " +
 		"left join \"%[1]v\".cd_stage cs on scds.cd_stage_id = cs.id " +
 		"left join \"%[1]v\".cd_pipeline cp on cs.cd_pipeline_id = cp.id " +
-		"where cp.name = $1 );"
+		"where cp.name = $1 ) returning cds.id;"
 	scope = "cd"
 )
 
@@ -227,9 +227,22 @@ func DeleteCodebaseDockerStream(txn sql.Tx, id int, schema string) error {
 	return nil
 }
 
-func DeleteCodebaseDockerStreams(txn sql.Tx, pipeName, schema string) error {
-	if _, err := txn.Exec(fmt.Sprintf(deleteCodebaseDockerStreamIds, schema), pipeName); err != nil {
-		return err
+// DeleteCodebaseDockerStreams removes output streams of all stages of CD
+// pipeline and returns their ids.
+func DeleteCodebaseDockerStreams(txn sql.Tx, pipeName, schema string) ([]int, error) {
+	rows, err := txn.Query(fmt.Sprintf(deleteCodebaseDockerStreamIds, schema), pipeName)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
 }
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+const (
+	// HealthPath is the path of liveness probe.
+	HealthPath = "/healthz"
+	// ReadyPath is the path of readiness probe.
+	ReadyPath = "/readyz"
+)
+
+// Check returns error when the checked component is not healthy.
+type Check func() error
+
+// checks is a named set of checks served as a probe endpoint.
+type checks struct {
+	mu     sync.RWMutex
+	checks map[string]Check
+}
+
+func (c *checks) add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.checks == nil {
+		c.checks = map[string]Check{}
+	}
+	c.checks[name] = check
+}
+
+// ServeHTTP runs all the checks and responds with 503 listing the failed
+// ones if there are any.
+func (c *checks) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	c.mu.RLock()
+	var failed []string
+	for name, check := range c.checks {
+		if err := check(); err != nil {
+			failed = append(failed, fmt.Sprintf("%v: %v", name, err))
+		}
+	}
+	c.mu.RUnlock()
+
+	if len(failed) != 0 {
+		sort.Strings(failed)
+		log.Info("Health check failed", "failed", failed)
+		http.Error(w, strings.Join(failed, "\n"), http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
+
+// AddHealthCheck adds check served on HealthPath. Failing it makes
+// Kubernetes restart the pod.
+func (s *Server) AddHealthCheck(name string, check Check) {
+	s.health.add(name, check)
+}
+
+// AddReadyCheck adds check served on ReadyPath.
+func (s *Server) AddReadyCheck(name string, check Check) {
+	s.ready.add(name, check)
+}
+
+// CacheSync is a manager runnable which tracks whether informer cache
+// of the manager has been synced.
+type CacheSync struct {
+	cache  cache.Cache
+	synced int32
+}
+
+func NewCacheSync(c cache.Cache) *CacheSync {
+	return &CacheSync{cache: c}
+}
+
+// Start waits for the cache to sync and blocks until stop channel is closed.
+func (c *CacheSync) Start(stop <-chan struct{}) error {
+	if c.cache.WaitForCacheSync(stop) {
+		atomic.StoreInt32(&c.synced, 1)
+	}
+	<-stop
+	return nil
+}
+
+// Check fails until the cache is synced.
+func (c *CacheSync) Check() error {
+	if atomic.LoadInt32(&c.synced) == 0 {
+		return errors.New("cache is not synced")
+	}
+	return nil
+}
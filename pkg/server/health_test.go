@@ -0,0 +1,26 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_ReadyReportsFailedChecks(t *testing.T) {
+	s := New(":0")
+	s.AddHealthCheck("db", func() error { return nil })
+	s.AddReadyCheck("db", func() error { return nil })
+	s.AddReadyCheck("cache", func() error { return errors.New("cache is not synced") })
+
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadyPath, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "cache: cache is not synced")
+
+	rec = httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
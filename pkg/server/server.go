@@ -0,0 +1,71 @@
+// Package server implements HTTP server which exposes reconciler endpoints
+// such as drift report, health probes and metrics.
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+var log = logf.Log.WithName("http-server")
+
+const shutdownTimeout = 5 * time.Second
+
+// MetricsPath is the path Prometheus metrics are served on.
+const MetricsPath = "/metrics"
+
+type Server struct {
+	addr   string
+	mux    *http.ServeMux
+	health *checks
+	ready  *checks
+}
+
+// New returns server which serves health probes and metrics registered
+// in the controller-runtime registry.
+func New(addr string) *Server {
+	s := &Server{
+		addr:   addr,
+		mux:    http.NewServeMux(),
+		health: &checks{},
+		ready:  &checks{},
+	}
+	s.mux.Handle(HealthPath, s.health)
+	s.mux.Handle(ReadyPath, s.ready)
+	s.mux.Handle(MetricsPath, promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.HTTPErrorOnError,
+	}))
+	return s
+}
+
+// Handle registers handler for the given pattern.
+func (s *Server) Handle(pattern string, h http.Handler) {
+	s.mux.Handle(pattern, h)
+}
+
+// Start serves registered handlers until stop channel is closed.
+func (s *Server) Start(stop <-chan struct{}) error {
+	srv := &http.Server{Addr: s.addr, Handler: s.mux}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Info("Starting HTTP server", "address", s.addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	select {
+	case <-stop:
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return srv.Shutdown(ctx)
+	case err := <-errCh:
+		return err
+	}
+}
@@ -1,16 +1,15 @@
 package cd_pipeline
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
 	"github.com/epmd-edp/reconciler/v2/pkg/model"
 	"github.com/epmd-edp/reconciler/v2/pkg/model/cdpipeline"
 	"github.com/epmd-edp/reconciler/v2/pkg/model/stage"
 	"github.com/epmd-edp/reconciler/v2/pkg/platform"
-	"github.com/epmd-edp/reconciler/v2/pkg/repository"
-	sr "github.com/epmd-edp/reconciler/v2/pkg/repository/stage"
 	stageService "github.com/epmd-edp/reconciler/v2/pkg/service/stage"
-	"github.com/epmd-edp/reconciler/v2/pkg/service/thirdpartyservice"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
 	"github.com/pkg/errors"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 	"sort"
@@ -19,61 +18,63 @@ import (
 var log = logf.Log.WithName("cd_pipeline_service")
 
 type CdPipelineService struct {
-	DB                *sql.DB
-	ClientSet         platform.ClientSet
-	ThirdPartyService thirdpartyservice.ThirdPartyService
+	Storage   storage.Storage
+	ClientSet platform.ClientSet
 }
 
 func (s CdPipelineService) PutCDPipeline(cdPipeline cdpipeline.CDPipeline) error {
 	log.V(2).Info("start CD Pipeline creation", "name", cdPipeline.Name)
-	txn, err := s.DB.Begin()
-	if err != nil {
-		return errors.New("an error has occurred while opening transaction")
-	}
-	schemaName := cdPipeline.Tenant
+	err := storage.WithTx(context.TODO(), s.Storage, func(txn storage.Tx) error {
+		schemaName := cdPipeline.Tenant
 
-	cdPipelineDb, err := s.getCDPipelineOrCreate(txn, cdPipeline, schemaName)
-	if err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "couldn't get/create cd pipeline %v", cdPipeline.Name)
-	}
-	log.Info("Id of CD Pipeline to be updated: %v", cdPipelineDb.Id)
+		cdPipelineDb, created, err := s.getCDPipelineOrCreate(txn, cdPipeline, schemaName)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't get/create cd pipeline %v", cdPipeline.Name)
+		}
+		log.Info("Id of CD Pipeline to be updated: %v", cdPipelineDb.Id)
 
-	if err := updateCDPipelineStatus(txn, *cdPipelineDb, cdPipeline.Status, schemaName); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred while updating %v CD Pipeline Status", cdPipelineDb.Name)
-	}
+		if err := s.updateCDPipelineStatus(txn, *cdPipelineDb, cdPipeline.Status, schemaName); err != nil {
+			return errors.Wrapf(err, "an error has occurred while updating %v CD Pipeline Status", cdPipelineDb.Name)
+		}
 
-	if err := updateActionLog(txn, cdPipeline, cdPipelineDb.Id, schemaName); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred while updating CD Pipelin %ve Action Event Log", cdPipeline.Name)
-	}
+		stored, err := s.updateActionLog(txn, cdPipeline, cdPipelineDb.Id, schemaName)
+		if err != nil {
+			return errors.Wrapf(err, "an error has occurred while updating CD Pipelin %ve Action Event Log", cdPipeline.Name)
+		}
 
-	if err := txn.Commit(); err != nil {
-		return errors.Wrap(err, "an error has occurred while closing transaction")
+		if created || stored {
+			if err := s.Storage.Outbox().Put(txn, pipelineEvent(cdPipelineDb.Id, created, cdPipeline), schemaName); err != nil {
+				return errors.Wrapf(err, "an error has occurred while recording event of CD Pipeline %v", cdPipeline.Name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-	log.Info("CD Pipeline has been saved successfully", "name", cdPipelineDb.Name)
+	log.Info("CD Pipeline has been saved successfully", "name", cdPipeline.Name)
 	return nil
 }
 
-func (s CdPipelineService) getCDPipelineOrCreate(txn *sql.Tx, cdPipeline cdpipeline.CDPipeline, schemaName string) (*model.CDPipelineDTO, error) {
+// getCDPipelineOrCreate reports whether CD pipeline has been created along with it.
+func (s CdPipelineService) getCDPipelineOrCreate(txn storage.Tx, cdPipeline cdpipeline.CDPipeline, schemaName string) (*model.CDPipelineDTO, bool, error) {
 	log.V(2).Info("start retrieving CD Pipeline", "name", cdPipeline.Name)
-	cdPipelineReadModel, err := repository.GetCDPipeline(*txn, cdPipeline.Name, schemaName)
+	cdPipelineReadModel, err := s.Storage.CDPipeline().Get(txn, cdPipeline.Name, schemaName)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	if cdPipelineReadModel != nil {
-		if err := repository.DeleteCDPipelineDockerStreams(*txn, cdPipelineReadModel.Id, schemaName); err != nil {
-			return nil, errors.Wrap(err, "an error has occurred while deleting pipeline's docker streams")
+		if err := s.Storage.CDPipeline().RemoveDockerStreams(txn, cdPipelineReadModel.Id, schemaName); err != nil {
+			return nil, false, errors.Wrap(err, "an error has occurred while deleting pipeline's docker streams")
 		}
 
-		if err := createCDPipelineDockerStream(txn, cdPipelineReadModel.Id, cdPipeline.InputDockerStreams, schemaName); err != nil {
-			return nil, err
+		if err := s.createCDPipelineDockerStream(txn, cdPipelineReadModel.Id, cdPipeline.InputDockerStreams, schemaName); err != nil {
+			return nil, false, err
 		}
 
-		stages, err := getStages(txn, cdPipelineReadModel.Name, schemaName)
+		stages, err := s.getStages(txn, cdPipelineReadModel.Name, schemaName)
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 
 		sort.SliceStable(stages, func(i, j int) bool {
@@ -85,68 +86,63 @@ func (s CdPipelineService) getCDPipelineOrCreate(txn *sql.Tx, cdPipeline cdpipel
 		}
 
 		if err := s.updateStageCodebaseDockerStream(txn, stages, cdPipelineReadModel.Name, schemaName); err != nil {
-			return nil, err
+			return nil, false, err
 		}
 
-		if err := updateApplicationsToPromote(txn, cdPipelineReadModel.Id, cdPipeline.ApplicationsToPromote, schemaName); err != nil {
-			return nil, err
+		if err := s.updateApplicationsToPromote(txn, cdPipelineReadModel.Id, cdPipeline.ApplicationsToPromote, schemaName); err != nil {
+			return nil, false, err
 		}
 
-		return cdPipelineReadModel, nil
+		return cdPipelineReadModel, false, nil
 	}
 	log.V(2).Info("record for CD Pipeline has not been found", "name", cdPipeline.Name)
 
-	cdPipelineDTO, err := createCDPipeline(txn, cdPipeline, schemaName)
+	cdPipelineDTO, err := s.createCDPipeline(txn, cdPipeline, schemaName)
 	if err != nil {
-		_ = txn.Rollback()
-		return nil, err
+		return nil, false, err
 	}
 
-	if err := createCDPipelineDockerStream(txn, cdPipelineDTO.Id, cdPipeline.InputDockerStreams, schemaName); err != nil {
-		_ = txn.Rollback()
-		return nil, err
+	if err := s.createCDPipelineDockerStream(txn, cdPipelineDTO.Id, cdPipeline.InputDockerStreams, schemaName); err != nil {
+		return nil, false, err
 	}
 
 	if cdPipeline.ThirdPartyServices != nil && len(cdPipeline.ThirdPartyServices) != 0 {
 		log.V(2).Info("try to create records in ThirdPartyServices", "values", cdPipeline.ThirdPartyServices)
-		servicesId, err := s.ThirdPartyService.GetServicesId(txn, cdPipeline.ThirdPartyServices, schemaName)
+		servicesId, err := s.getServicesId(txn, cdPipeline.ThirdPartyServices, schemaName)
 		if err != nil {
-			_ = txn.Rollback()
-			return nil, errors.Wrap(err, "an error has occurred while getting services id:")
+			return nil, false, errors.Wrap(err, "an error has occurred while getting services id:")
 		}
 
-		if err := createCDPipelineThirdPartyService(txn, cdPipelineDTO.Id, servicesId, schemaName); err != nil {
-			_ = txn.Rollback()
-			return nil, errors.Wrap(err, "an error has occurred while inserting record into cd_pipeline_third_party_service")
+		if err := s.createCDPipelineThirdPartyService(txn, cdPipelineDTO.Id, servicesId, schemaName); err != nil {
+			return nil, false, errors.Wrap(err, "an error has occurred while inserting record into cd_pipeline_third_party_service")
 		}
 	}
 
-	if err := createApplicationToPromoteRow(txn, cdPipelineDTO.Id, cdPipeline.ApplicationsToPromote, schemaName); err != nil {
-		_ = txn.Rollback()
-		return nil, errors.Wrap(err, "an error has occurred while inserting record into applications_to_promote")
+	if err := s.createApplicationToPromoteRow(txn, cdPipelineDTO.Id, cdPipeline.ApplicationsToPromote, schemaName); err != nil {
+		return nil, false, errors.Wrap(err, "an error has occurred while inserting record into applications_to_promote")
 	}
-	return cdPipelineDTO, nil
+	return cdPipelineDTO, true, nil
 }
 
-func updateApplicationsToPromote(tx *sql.Tx, cdPipelineId int, applicationsToPromote []string, schemaName string) error {
-	if err := repository.RemoveApplicationsToPromote(*tx, cdPipelineId, schemaName); err != nil {
+func (s CdPipelineService) updateApplicationsToPromote(tx storage.Tx, cdPipelineId int, applicationsToPromote []string, schemaName string) error {
+	if err := s.Storage.CDPipeline().RemoveApplicationsToPromote(tx, cdPipelineId, schemaName); err != nil {
 		return errors.Wrapf(err, "an error has occurred while removing Application To Promote records for Stage %v", cdPipelineId)
 	}
-	if err := createApplicationToPromoteRow(tx, cdPipelineId, applicationsToPromote, schemaName); err != nil {
+	if err := s.createApplicationToPromoteRow(tx, cdPipelineId, applicationsToPromote, schemaName); err != nil {
 		return fmt.Errorf("an error has occurred while creating Application To Promote record for %v Stage: %v", cdPipelineId, err)
 	}
 	return nil
 }
 
-func createApplicationToPromoteRow(txn *sql.Tx, cdPipelineId int, applicationsToPromote []string, schemaName string) error {
+func (s CdPipelineService) createApplicationToPromoteRow(txn storage.Tx, cdPipelineId int, applicationsToPromote []string, schemaName string) error {
 	log.V(2).Info("try to create record in ApplicationToPromote table", "applicationsToPromote", applicationsToPromote)
 	for _, appToPromote := range applicationsToPromote {
-		id, err := repository.GetApplicationId(*txn, appToPromote, schemaName)
+		id, err := s.Storage.Codebase().GetApplicationId(txn, appToPromote, schemaName)
 		if err != nil {
 			return err
 		}
 
-		if err := repository.CreateApplicationsToPromote(*txn, cdPipelineId, *id, schemaName); err != nil {
+		if err := s.Storage.CDPipeline().AddApplicationToPromote(txn, cdPipelineId, *id, schemaName); err != nil {
 			return err
 		}
 	}
@@ -154,8 +150,9 @@ func createApplicationToPromoteRow(txn *sql.Tx, cdPipelineId int, applicationsTo
 	return nil
 }
 
-func (s CdPipelineService) updateStageCodebaseDockerStreamRelations(txn *sql.Tx, stages []stage.Stage, pipelineName string, schemaName string) error {
+func (s CdPipelineService) updateStageCodebaseDockerStreamRelations(txn storage.Tx, stages []stage.Stage, pipelineName string, schemaName string) error {
 	log.V(2).Info("try to update Stage Codebase Docker Streams relations for stages", "stages", stages)
+	ss := stageService.StageService{Storage: s.Storage}
 	for i := range stages {
 		stages[i].Tenant = schemaName
 		stages[i].CdPipelineName = pipelineName
@@ -165,7 +162,7 @@ func (s CdPipelineService) updateStageCodebaseDockerStreamRelations(txn *sql.Tx,
 			return err
 		}
 
-		if err := stageService.UpdateSingleStageCodebaseDockerStreamRelations(txn, stages[i].Id, stages[i], pipelineCR.Spec.ApplicationsToPromote); err != nil {
+		if err := ss.UpdateSingleStageCodebaseDockerStreamRelations(txn, stages[i].Id, stages[i], pipelineCR.Spec.ApplicationsToPromote); err != nil {
 			return err
 		}
 	}
@@ -173,8 +170,8 @@ func (s CdPipelineService) updateStageCodebaseDockerStreamRelations(txn *sql.Tx,
 	return nil
 }
 
-func getStages(txn *sql.Tx, cdPipelineName string, schemaName string) ([]stage.Stage, error) {
-	stages, err := sr.GetStages(*txn, cdPipelineName, schemaName)
+func (s CdPipelineService) getStages(txn storage.Tx, cdPipelineName string, schemaName string) ([]stage.Stage, error) {
+	stages, err := s.Storage.Stage().GetStages(txn, cdPipelineName, schemaName)
 	if err != nil {
 		return nil, errors.Wrapf(err, "an error has occurred while getting Stages for CD Pipeline %v", cdPipelineName)
 	}
@@ -182,12 +179,12 @@ func getStages(txn *sql.Tx, cdPipelineName string, schemaName string) ([]stage.S
 	return stages, nil
 }
 
-func deleteStageCodebaseDockerStream(txn *sql.Tx, stages []stage.Stage, schemaName string) ([]int, error) {
+func (s CdPipelineService) deleteStageCodebaseDockerStream(txn storage.Tx, stages []stage.Stage, schemaName string) ([]int, error) {
 	var outputStreamIdsToRemove []int
 	var stagesToLog []string
 
 	for _, stage := range stages {
-		outputStreamIds, err := repository.DeleteStageCodebaseDockerStream(*txn, stage.Id, schemaName)
+		outputStreamIds, err := s.Storage.DockerStream().UnlinkStage(txn, stage.Id, schemaName)
 		outputStreamIdsToRemove = append(outputStreamIdsToRemove, outputStreamIds...)
 		if err != nil {
 			return nil, errors.Wrap(err, "an error has occurred while deleting stage codebase docker stream row")
@@ -198,14 +195,14 @@ func deleteStageCodebaseDockerStream(txn *sql.Tx, stages []stage.Stage, schemaNa
 	return outputStreamIdsToRemove, nil
 }
 
-func (s CdPipelineService) updateStageCodebaseDockerStream(txn *sql.Tx, stages []stage.Stage, pipelineName string, schemaName string) error {
+func (s CdPipelineService) updateStageCodebaseDockerStream(txn storage.Tx, stages []stage.Stage, pipelineName string, schemaName string) error {
 	if stages == nil {
 		log.V(2).Info("There're no stages for CD Pipeline. Updating of Codebase Docker stream will not be executed.",
 			"pipe", pipelineName)
 		return nil
 	}
 
-	if _, err := deleteStageCodebaseDockerStream(txn, stages, schemaName); err != nil {
+	if _, err := s.deleteStageCodebaseDockerStream(txn, stages, schemaName); err != nil {
 		return err
 	}
 
@@ -216,9 +213,9 @@ func (s CdPipelineService) updateStageCodebaseDockerStream(txn *sql.Tx, stages [
 	return nil
 }
 
-func createCDPipeline(txn *sql.Tx, cdPipeline cdpipeline.CDPipeline, schemaName string) (*model.CDPipelineDTO, error) {
+func (s CdPipelineService) createCDPipeline(txn storage.Tx, cdPipeline cdpipeline.CDPipeline, schemaName string) (*model.CDPipelineDTO, error) {
 	log.V(2).Info("start insertion cd_pipeline to table", "name", cdPipeline.Name)
-	cdPipelineDto, err := repository.CreateCDPipeline(*txn, cdPipeline, cdPipeline.Status, schemaName)
+	cdPipelineDto, err := s.Storage.CDPipeline().Create(txn, cdPipeline, schemaName)
 	if err != nil {
 		return nil, err
 	}
@@ -226,35 +223,51 @@ func createCDPipeline(txn *sql.Tx, cdPipeline cdpipeline.CDPipeline, schemaName
 	return cdPipelineDto, nil
 }
 
-func updateActionLog(txn *sql.Tx, cdPipeline cdpipeline.CDPipeline, pipelineId int, schemaName string) error {
+// updateActionLog reports whether the action log has been stored.
+func (s CdPipelineService) updateActionLog(txn storage.Tx, cdPipeline cdpipeline.CDPipeline, pipelineId int, schemaName string) (bool, error) {
 	log.V(2).Info("start updating status of CD Pipeline", "name", cdPipeline.Name)
-	actionLogId, err := repository.CreateEventActionLog(*txn, cdPipeline.ActionLog, schemaName)
+	stored, err := s.Storage.ActionLog().PutToCDPipeline(txn, pipelineId, cdPipeline.ActionLog, schemaName)
 	if err != nil {
-		return errors.Wrapf(err, "cannot insert status %v", cdPipeline)
+		return false, errors.Wrapf(err, "cannot insert status %v", cdPipeline)
 	}
+	log.Info("cd_pipeline_action has been updated", "stored", stored)
+	return stored, nil
+}
 
-	log.V(2).Info("start updating cd_pipeline_codebase_action status of code pipeline entity...")
-	if err := repository.CreateCDPipelineActionLog(*txn, pipelineId, *actionLogId, schemaName); err != nil {
-		return errors.Wrapf(err, "cannot create cd_pipeline_action entity %v", cdPipeline)
+// pipelineEvent describes creation of CD pipeline or its update by the action
+// the pipeline status is set by.
+func pipelineEvent(id int, created bool, p cdpipeline.CDPipeline) model.Event {
+	op := model.Updated
+	if created {
+		op = model.Created
+	}
+	return model.Event{
+		Kind:      model.CDPipelineEvent,
+		Operation: op,
+		EntityId:  &id,
+		Subject:   p.Name,
+		Data: map[string]string{
+			"status": p.Status,
+			"action": p.ActionLog.Action,
+			"result": p.ActionLog.Result,
+		},
 	}
-	log.Info("cd_pipeline_action has been updated")
-	return nil
 }
 
-func updateCDPipelineStatus(txn *sql.Tx, cdPipelineDb model.CDPipelineDTO, status string, schemaName string) error {
+func (s CdPipelineService) updateCDPipelineStatus(txn storage.Tx, cdPipelineDb model.CDPipelineDTO, status string, schemaName string) error {
 	if cdPipelineDb.Status != status {
 		log.V(2).Info("start updating status of cd pipeline",
 			"pipe name", cdPipelineDb.Name, "status", status)
-		if err := repository.UpdateCDPipelineStatus(*txn, cdPipelineDb.Id, status, schemaName); err != nil {
+		if err := s.Storage.CDPipeline().UpdateStatus(txn, cdPipelineDb.Id, status, schemaName); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func createCDPipelineThirdPartyService(txn *sql.Tx, cdPipelineId int, servicesId []int, schemaName string) error {
+func (s CdPipelineService) createCDPipelineThirdPartyService(txn storage.Tx, cdPipelineId int, servicesId []int, schemaName string) error {
 	for _, serviceId := range servicesId {
-		err := repository.CreateCDPipelineThirdPartyService(*txn, cdPipelineId, serviceId, schemaName)
+		err := s.Storage.CDPipeline().AddThirdPartyService(txn, cdPipelineId, serviceId, schemaName)
 		if err != nil {
 			return err
 		}
@@ -262,29 +275,44 @@ func createCDPipelineThirdPartyService(txn *sql.Tx, cdPipelineId int, servicesId
 	return nil
 }
 
-func createCDPipelineDockerStream(txn *sql.Tx, cdPipelineId int, dockerStreams []string, schemaName string) error {
+func (s CdPipelineService) getServicesId(txn storage.Tx, serviceNames []string, schema string) ([]int, error) {
+	var servicesId []int
+	for _, name := range serviceNames {
+		id, err := s.Storage.Server().GetServiceId(txn, name, schema)
+		if err != nil {
+			return nil, err
+		}
+		if id == nil {
+			return nil, backoff.AsDependency(fmt.Errorf("third party service %v has not been found", name))
+		}
+		servicesId = append(servicesId, *id)
+	}
+	return servicesId, nil
+}
+
+func (s CdPipelineService) createCDPipelineDockerStream(txn storage.Tx, cdPipelineId int, dockerStreams []string, schemaName string) error {
 	var dockerStreamIds []int
 	for _, dockerStream := range dockerStreams {
-		id, err := repository.GetCodebaseDockerStreamId(*txn, dockerStream, schemaName)
+		id, err := s.Storage.DockerStream().GetId(txn, dockerStream, schemaName)
 		if err != nil {
 			return errors.Wrapf(err, "an error has occurred while getting id of docker stream %v", dockerStream)
 		}
 		if id == nil {
-			return fmt.Errorf("cannot find docker stream by name: %v in the schema: %v", dockerStream, schemaName)
+			return backoff.AsDependency(fmt.Errorf("cannot find docker stream by name: %v in the schema: %v", dockerStream, schemaName))
 		}
 		dockerStreamIds = append(dockerStreamIds, *id)
 	}
 
-	if err := insertCDPipelineDockerStream(txn, cdPipelineId, dockerStreamIds, schemaName); err != nil {
+	if err := s.insertCDPipelineDockerStream(txn, cdPipelineId, dockerStreamIds, schemaName); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func insertCDPipelineDockerStream(txn *sql.Tx, cdPipelineId int, dockerStreams []int, schemaName string) error {
+func (s CdPipelineService) insertCDPipelineDockerStream(txn storage.Tx, cdPipelineId int, dockerStreams []int, schemaName string) error {
 	for _, id := range dockerStreams {
-		if err := repository.CreateCDPipelineDockerStream(*txn, cdPipelineId, id, schemaName); err != nil {
+		if err := s.Storage.CDPipeline().AddDockerStream(txn, cdPipelineId, id, schemaName); err != nil {
 			return errors.Wrapf(err, "an error has occurred while inserting CD Pipeline Docker Stream row %v", id)
 		}
 	}
@@ -293,22 +321,30 @@ func insertCDPipelineDockerStream(txn *sql.Tx, cdPipelineId int, dockerStreams [
 
 func (s CdPipelineService) DeleteCDPipeline(pipeName, schema string) error {
 	log.V(2).Info("start deleting cd pipeline", "name", pipeName)
-	txn, err := s.DB.Begin()
-	if err != nil {
-		return err
-	}
-
-	if err := sr.DeleteCodebaseDockerStreams(*txn, pipeName, schema); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "couldn't delete codebase docker streams for %v cd pipeline", pipeName)
-	}
+	err := storage.WithTx(context.TODO(), s.Storage, func(txn storage.Tx) error {
+		streamIds, err := s.Storage.DockerStream().DeletePipelineStreams(txn, pipeName, schema)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't delete codebase docker streams for %v cd pipeline", pipeName)
+		}
 
-	if err := repository.DeleteCDPipeline(*txn, pipeName, schema); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "couldn't delete cd pipeline %v", pipeName)
-	}
+		if err := s.Storage.CDPipeline().Delete(txn, pipeName, schema); err != nil {
+			return errors.Wrapf(err, "couldn't delete cd pipeline %v", pipeName)
+		}
 
-	if err := txn.Commit(); err != nil {
+		var events []model.Event
+		for i := range streamIds {
+			events = append(events, model.Event{Kind: model.DockerStreamEvent, Operation: model.Deleted,
+				EntityId: &streamIds[i], Data: map[string]string{"cdPipeline": pipeName}})
+		}
+		events = append(events, model.Event{Kind: model.CDPipelineEvent, Operation: model.Deleted, Subject: pipeName})
+		for _, e := range events {
+			if err := s.Storage.Outbox().Put(txn, e, schema); err != nil {
+				return errors.Wrapf(err, "couldn't record event of deleted cd pipeline %v", pipeName)
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 	log.Info("cd pipeline has been deleted", "pipe name", pipeName)
@@ -1,138 +1,338 @@
 package service
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"github.com/epmd-edp/codebase-operator/v2/pkg/apis/edp/v1alpha1"
-	codebaseperfdatasourceRepo "github.com/epmd-edp/reconciler/v2/pkg/repository/codebaseperfdatasource"
-	"github.com/epmd-edp/reconciler/v2/pkg/service/codebaseperfdatasource"
-	"github.com/epmd-edp/reconciler/v2/pkg/service/perfdatasource"
-	"github.com/epmd-edp/reconciler/v2/pkg/service/perfserver"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
 	"log"
+	"strings"
 
 	"github.com/epmd-edp/reconciler/v2/pkg/model/codebase"
-	"github.com/epmd-edp/reconciler/v2/pkg/repository"
-	"github.com/epmd-edp/reconciler/v2/pkg/repository/jenkins-slave"
-	jiraserver "github.com/epmd-edp/reconciler/v2/pkg/repository/jira-server"
-	jp "github.com/epmd-edp/reconciler/v2/pkg/repository/job-provisioning"
 	"github.com/pkg/errors"
 )
 
 type CodebaseService struct {
-	DB                *sql.DB
-	DataSourceService perfdatasource.PerfDataSourceService
-	PerfService       perfserver.PerfServerService
-	CodebaseDsService codebaseperfdatasource.CodebasePerfDataSourceService
+	Storage storage.Storage
+}
+
+// GetTimeline returns action logs of the codebase and its branches matching
+// the filter, the newest go first.
+func (s CodebaseService) GetTimeline(name, schemaName string, f model.TimelineFilter) ([]model.TimelineEntry, error) {
+	var entries []model.TimelineEntry
+	err := storage.WithTx(context.TODO(), s.Storage, func(txn storage.Tx) error {
+		var err error
+		entries, err = s.Storage.ActionLog().GetCodebaseTimeline(txn, name, f, schemaName)
+		if err != nil {
+			return errors.Wrapf(err, "an error has occurred during reading timeline of codebase: %v", name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
 }
 
 func (s CodebaseService) PutCodebase(c codebase.Codebase) error {
 	log.Printf("Start creation of business entity %v...", c)
-	log.Println("Start transaction...")
-	txn, err := s.DB.Begin()
+	err := storage.WithTx(context.TODO(), s.Storage, func(txn storage.Tx) error {
+		id, created, changes, err := s.putCodebase(txn, c, c.Tenant)
+		if err != nil {
+			return errors.Wrapf(err, "an error has occurred during get Codebase id or create: %v", c.Name)
+		}
+		log.Printf("Id of BE to be updated: %v", *id)
+
+		log.Println("Start update status of codebase...")
+		stored, err := s.Storage.ActionLog().PutToCodebase(txn, *id, c.ActionLog, c.Tenant)
+		if err != nil {
+			return errors.Wrapf(err, "an error has occurred during status creation: %v", c.Name)
+		}
+		if stored {
+			log.Println("ActionLog has been saved into the repository")
+		} else {
+			log.Println("ActionLog has been saved into the repository already")
+		}
+
+		if created || stored || len(changes) > 0 {
+			if err := s.Storage.Outbox().Put(txn, codebaseEvent(*id, created, c, changes), c.Tenant); err != nil {
+				return errors.Wrapf(err, "an error has occurred during recording event of codebase: %v", c.Name)
+			}
+		}
+
+		if err := s.Storage.Codebase().UpdateStatus(txn, *id, c.Status, c.Tenant); err != nil {
+			log.Printf("Error has occurred during the update of codebase: %v", err)
+			return errors.Wrapf(err, "an error has occurred during the update of codebase: %v", c.Name)
+		}
+		return nil
+	})
 	if err != nil {
-		return errors.Wrapf(err, "an error has occurred during opening transaction: %v", c.Name)
+		return err
 	}
+	log.Printf("Codebase %v has been saved successfully", c.Name)
+	return nil
+}
 
-	id, err := s.putCodebase(txn, c, c.Tenant)
+// putCodebase creates or updates the codebase and reports whether it has
+// been created along with the columns changed by update.
+func (s CodebaseService) putCodebase(txn storage.Tx, c codebase.Codebase, schema string) (*int, bool, []codebase.Change, error) {
+	log.Printf("Start retrieving Codebase by name, tenant and type: %v", c)
+	id, err := s.Storage.Codebase().GetId(txn, c.Name, schema)
 	if err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred during get Codebase id or create: %v", c.Name)
+		return nil, false, nil, err
 	}
-	log.Printf("Id of BE to be updated: %v", *id)
+	if id == nil {
+		log.Printf("Record for Codebase %v has not been found", c)
+		id, err := s.createBE(txn, c, schema)
+		return id, true, nil, err
+	}
+	changes, err := s.updateCodebase(txn, *id, c, schema)
+	return id, false, changes, err
+}
 
-	log.Println("Start update status of codebase...")
-	codebaseActionId, err := repository.CreateActionLog(*txn, c.ActionLog, c.Tenant)
+// codebaseEvent describes creation of the codebase or its update by
+// the action the codebase status is set by or by the changed columns.
+func codebaseEvent(id int, created bool, c codebase.Codebase, changes []codebase.Change) model.Event {
+	op := model.Updated
+	if created {
+		op = model.Created
+	}
+	e := model.Event{
+		Kind:      model.CodebaseEvent,
+		Operation: op,
+		EntityId:  &id,
+		Subject:   c.Name,
+		Data: map[string]string{
+			"type":   c.Type,
+			"status": c.Status,
+			"action": c.ActionLog.Action,
+			"result": c.ActionLog.Result,
+		},
+	}
+	if len(changes) > 0 {
+		fields := make([]string, len(changes))
+		for i, ch := range changes {
+			fields[i] = ch.Field
+		}
+		e.Data["changed"] = strings.Join(fields, ",")
+	}
+	return e
+}
+
+// updateCodebase brings all the columns of the stored codebase to the state
+// of custom resource and records the changed ones to the change log.
+func (s CodebaseService) updateCodebase(txn storage.Tx, id int, c codebase.Codebase, schema string) ([]codebase.Change, error) {
+	log.Printf("start updating codebase %v", c.Name)
+	if err := s.resolveReferences(txn, &c, schema); err != nil {
+		return nil, err
+	}
+
+	stored, err := s.Storage.Codebase().Get(txn, c.Name, schema)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't get codebase %v", c.Name)
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("codebase %v has been deleted concurrently", c.Name)
+	}
+	changes := codebase.Diff(*stored, c)
+	dsChange, err := s.reconcilePerfDataSources(txn, id, c.Perf, schema)
 	if err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred during status creation: %v", c.Name)
+		return nil, errors.Wrapf(err, "couldn't update perf data sources of codebase %v", c.Name)
+	}
+	if dsChange != nil {
+		changes = append(changes, *dsChange)
+	}
+	if len(changes) == 0 {
+		log.Printf("codebase %v is up to date", c.Name)
+		return nil, nil
 	}
-	log.Println("ActionLog has been saved into the repository")
 
-	log.Println("Start update codebase_action status of codebase...")
-	if err := repository.CreateCodebaseAction(*txn, *id, *codebaseActionId, c.Tenant); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred during codebase_action creation: %v", c.Name)
+	if err := s.Storage.Codebase().Update(txn, c, schema); err != nil {
+		return nil, errors.Wrapf(err, "couldn't update codebase %v", c.Name)
+	}
+	if err := s.Storage.Codebase().LogChanges(txn, id, changes, schema); err != nil {
+		return nil, errors.Wrapf(err, "couldn't record changes of codebase %v", c.Name)
+	}
+	if codebase.Moved(*stored, c) {
+		if err := s.recordMove(txn, id, *stored, c, schema); err != nil {
+			return nil, errors.Wrapf(err, "couldn't record move of codebase %v", c.Name)
+		}
 	}
-	log.Println("codebase_action has been updated")
+	log.Printf("codebase %v has been updated, %v columns changed", c.Name, len(changes))
+	return changes, nil
+}
 
-	if err := repository.UpdateStatusByCodebaseId(*txn, *id, c.Status, c.Tenant); err != nil {
-		log.Printf("Error has occurred during the update of codebase: %v", err)
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred during the update of codebase: %v", c.Name)
+// recordMove keeps the location the codebase has been moved away from for
+// audit and notifies about the move.
+func (s CodebaseService) recordMove(txn storage.Tx, id int, old, c codebase.Codebase, schema string) error {
+	log.Printf("codebase %v has been moved to %v git server", c.Name, c.GitServer)
+	if err := s.Storage.Codebase().AddPreviousLocation(txn, id, old.Location(), schema); err != nil {
+		return err
+	}
+	e := model.Event{
+		Kind:      model.CodebaseEvent,
+		Operation: model.Moved,
+		EntityId:  &id,
+		Subject:   c.Name,
+		Data:      map[string]string{"gitServer": c.GitServer},
+	}
+	if c.GitUrlPath != nil {
+		e.Data["gitUrlPath"] = *c.GitUrlPath
 	}
+	if old.GitUrlPath != nil {
+		e.Data["previousGitUrlPath"] = *old.GitUrlPath
+	}
+	return s.Storage.Outbox().Put(txn, e, schema)
+}
 
-	if err := txn.Commit(); err != nil {
-		return errors.Wrapf(err, "An error has occurred while ending transaction: %v", c.Name)
+// GetRepositoryHistory returns locations the codebase has been moved away
+// from, the latest go first.
+func (s CodebaseService) GetRepositoryHistory(name, schemaName string) ([]codebase.RepositoryLocation, error) {
+	var locations []codebase.RepositoryLocation
+	err := storage.WithTx(context.TODO(), s.Storage, func(txn storage.Tx) error {
+		var err error
+		locations, err = s.Storage.Codebase().GetPreviousLocations(txn, name, schemaName)
+		if err != nil {
+			return errors.Wrapf(err, "an error has occurred during reading repository history of codebase: %v", name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	log.Printf("Codebase %v has been saved successfully", c.Name)
+	return locations, nil
+}
+
+func (s CodebaseService) createBE(txn storage.Tx, c codebase.Codebase, schemaName string) (*int, error) {
+	log.Println("Start insertion in the repository business entity...")
 
-	if err := s.DataSourceService.InsertPerfDataSources(c.Perf, c.Tenant); err != nil {
-		return errors.Wrap(err, "an error has occurred during filling perf data source table")
+	if err := s.resolveReferences(txn, &c, schemaName); err != nil {
+		return nil, err
 	}
 
-	if err := s.CodebaseDsService.InsertCodebasePerfDataSources(*id, c.Perf, c.Tenant); err != nil {
-		return errors.Wrapf(err, "couldn't create CodebasePerfDataSource record. codebase id %v", id)
+	id, err := s.Storage.Codebase().Create(txn, c, schemaName)
+	if err != nil {
+		log.Printf("Error has occurred during business entity creation: %v", err)
+		return nil, errors.New(fmt.Sprintf("cannot create business entity %v", c))
 	}
-	return nil
+	log.Printf("Id of the newly created business entity is %v", *id)
+
+	if _, err := s.reconcilePerfDataSources(txn, *id, c.Perf, schemaName); err != nil {
+		return nil, errors.Wrapf(err, "couldn't add perf data sources of codebase %v", c.Name)
+	}
+	return id, nil
 }
 
-func (s CodebaseService) putCodebase(txn *sql.Tx, c codebase.Codebase, schema string) (*int, error) {
-	log.Printf("Start retrieving Codebase by name, tenant and type: %v", c)
-	id, err := repository.GetCodebaseId(*txn, c.Name, schema)
+// reconcilePerfDataSources links the codebase to the data sources of its perf
+// configuration only and reports the change of the links if there is one.
+// Every link is changed in a savepoint, so a data source which fails doesn't
+// prevent projecting the codebase and the others, it is retried by the next
+// reconciliation.
+func (s CodebaseService) reconcilePerfDataSources(txn storage.Tx, id int, perf *codebase.Perf, schemaName string) (*codebase.Change, error) {
+	existing, err := s.Storage.Codebase().GetPerfDataSources(txn, id, schemaName)
 	if err != nil {
 		return nil, err
 	}
-	if id == nil {
-		log.Printf("Record for Codebase %v has not been found", c)
-		return s.createBE(txn, c, schema)
+	desired := perf.DataSourceTypes()
+
+	changed := false
+	for _, t := range desired {
+		if contains(existing, t) {
+			continue
+		}
+		err := storage.Nested(txn, "add_perf_data_source", func() error {
+			return s.Storage.Codebase().AddPerfDataSource(txn, id, t, schemaName)
+		})
+		if err != nil {
+			log.Printf("couldn't add %v data source to codebase with id %v: %v", t, id, err)
+			continue
+		}
+		changed = true
+	}
+	for _, t := range existing {
+		if contains(desired, t) {
+			continue
+		}
+		err := storage.Nested(txn, "remove_perf_data_source", func() error {
+			return s.Storage.Codebase().RemovePerfDataSource(txn, id, t, schemaName)
+		})
+		if err != nil {
+			log.Printf("couldn't remove %v data source from codebase with id %v: %v", t, id, err)
+			continue
+		}
+		changed = true
+	}
+	if !changed {
+		return nil, nil
 	}
-	return id, updateCodebase(txn, c, schema)
-}
 
-func updateCodebase(txn *sql.Tx, c codebase.Codebase, schema string) error {
-	log.Printf("start updating codebase %v", c.Name)
-	if err := repository.Update(*txn, c, schema); err != nil {
-		return errors.Wrapf(err, "couldn't update codebase %v", c.Name)
+	linked, err := s.Storage.Codebase().GetPerfDataSources(txn, id, schemaName)
+	if err != nil {
+		return nil, err
 	}
-	log.Printf("codebase %v has been updated", c.Name)
-	return nil
+	log.Printf("perf data sources of codebase with id %v have been changed from %v to %v", id, existing, linked)
+	return &codebase.Change{
+		Field: "perf_data_sources",
+		Old:   strings.Join(existing, ","),
+		New:   strings.Join(linked, ","),
+	}, nil
 }
 
-func (s CodebaseService) createBE(txn *sql.Tx, c codebase.Codebase, schemaName string) (*int, error) {
-	log.Println("Start insertion in the repository business entity...")
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
 
-	serverId, err := getGitServerId(txn, c.GitServer, schemaName)
+// resolveReferences sets ids of the servers and other entities the codebase
+// refers to by name.
+func (s CodebaseService) resolveReferences(txn storage.Tx, c *codebase.Codebase, schemaName string) error {
+	serverId, err := s.getGitServerId(txn, c.GitServer, schemaName)
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("cannot get git server: %v", c.GitServer))
+		return errors.Wrapf(err, "cannot get git server: %v", c.GitServer)
 	}
 	log.Printf("GitServer is fetched: %v", serverId)
 	if serverId == nil {
-		return nil, fmt.Errorf("git server has not been found for %v", c.GitServer)
+		return backoff.AsDependency(fmt.Errorf("git server has not been found for %v", c.GitServer))
 	}
 	c.GitServerId = serverId
 
-	id, err := getJiraServerId(txn, c.JiraServer, schemaName)
+	id, err := s.getJiraServerId(txn, c.JiraServer, schemaName)
 	if err != nil {
-		return nil, errors.Wrapf(err, "couldn't get Jira server id by %v name", *c.JiraServer)
+		return errors.Wrapf(err, "couldn't get Jira server id by %v name", *c.JiraServer)
 	}
-	if id != nil {
-		c.JiraServerId = id
+	if id == nil && c.JiraServer != nil && *c.JiraServer != "" {
+		return backoff.AsDependency(fmt.Errorf("jira server has not been found for %v", *c.JiraServer))
 	}
+	c.JiraServerId = id
 
+	c.JenkinsSlaveId = nil
 	if c.JenkinsSlave != nil && *c.JenkinsSlave != "" {
-		jsId, err := jenkins_slave.SelectJenkinsSlave(*txn, *c.JenkinsSlave, schemaName)
-		if err != nil || jsId == nil {
-			return nil, errors.New(fmt.Sprintf("couldn't get jenkins slave id: %v", c.JenkinsSlave))
+		jsId, err := s.Storage.Server().GetJenkinsSlaveId(txn, *c.JenkinsSlave, schemaName)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't get jenkins slave id: %v", *c.JenkinsSlave)
+		}
+		if jsId == nil {
+			return backoff.AsDependency(fmt.Errorf("jenkins slave has not been found for %v", *c.JenkinsSlave))
 		}
 		log.Printf("Jenkins Slave Id for %v codebase is %v", c.Name, *jsId)
 
 		c.JenkinsSlaveId = jsId
 	}
 
+	c.JobProvisioningId = nil
 	if c.JobProvisioning != nil && *c.JobProvisioning != "" {
-		jpId, err := jp.SelectJobProvision(*txn, *c.JobProvisioning, "ci", schemaName)
-		if err != nil || jpId == nil {
-			return nil, errors.New(fmt.Sprintf("couldn't get job provisioning id: %v", c.JobProvisioning))
+		jpId, err := s.Storage.Server().GetJobProvisioningId(txn, *c.JobProvisioning, "ci", schemaName)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't get job provisioning id: %v", *c.JobProvisioning)
+		}
+		if jpId == nil {
+			return backoff.AsDependency(fmt.Errorf("job provisioning has not been found for %v", *c.JobProvisioning))
 		}
 
 		log.Printf("Job Probisioning Id for %v codebase is %v", c.Name, *jpId)
@@ -140,40 +340,33 @@ func (s CodebaseService) createBE(txn *sql.Tx, c codebase.Codebase, schemaName s
 		c.JobProvisioningId = jpId
 	}
 
-	if err := s.setPerfServerIdToCodebaseDto(c.Perf, schemaName); err != nil {
-		return nil, errors.Wrapf(err, "couldn't set %v perf server id", c.Perf.Name)
-	}
-
-	id, err = repository.CreateCodebase(*txn, c, schemaName)
-	if err != nil {
-		log.Printf("Error has occurred during business entity creation: %v", err)
-		return nil, errors.New(fmt.Sprintf("cannot create business entity %v", c))
+	if err := s.setPerfServerIdToCodebaseDto(txn, c.Perf, schemaName); err != nil {
+		return errors.Wrapf(err, "couldn't set %v perf server id", c.Perf.Name)
 	}
-	log.Printf("Id of the newly created business entity is %v", *id)
-	return id, nil
+	return nil
 }
 
-func (s CodebaseService) setPerfServerIdToCodebaseDto(perf *codebase.Perf, tenant string) error {
+func (s CodebaseService) setPerfServerIdToCodebaseDto(txn storage.Tx, perf *codebase.Perf, tenant string) error {
 	if perf == nil {
 		return nil
 	}
 
-	id, err := s.PerfService.GetPerfServerId(perf.Name, tenant)
+	id, err := s.Storage.Server().GetPerfServerId(txn, perf.Name, tenant)
 	if err != nil {
 		return err
 	}
 
 	if id == nil {
-		return fmt.Errorf("%v perf server record doesn't exist", perf.Name)
+		return backoff.AsDependency(fmt.Errorf("%v perf server record doesn't exist", perf.Name))
 	}
 	perf.Id = id
 	return nil
 }
 
-func getGitServerId(txn *sql.Tx, gitServerName string, schemaName string) (*int, error) {
+func (s CodebaseService) getGitServerId(txn storage.Tx, gitServerName string, schemaName string) (*int, error) {
 	log.Println("Fetching GitServer Id to set relation into codebase...")
 
-	serverId, err := repository.SelectGitServer(*txn, gitServerName, schemaName)
+	serverId, err := s.Storage.Server().GetGitServerId(txn, gitServerName, schemaName)
 	if err != nil {
 		return nil, err
 	}
@@ -181,13 +374,13 @@ func getGitServerId(txn *sql.Tx, gitServerName string, schemaName string) (*int,
 	return serverId, nil
 }
 
-func getJiraServerId(txn *sql.Tx, name *string, schemaName string) (*int, error) {
-	if name == nil {
+func (s CodebaseService) getJiraServerId(txn storage.Tx, name *string, schemaName string) (*int, error) {
+	if name == nil || *name == "" {
 		return nil, nil
 	}
-	log.Printf("Fetching JiraServer Id by %v name to set relation into codebase...", name)
+	log.Printf("Fetching JiraServer Id by %v name to set relation into codebase...", *name)
 
-	id, err := jiraserver.SelectJiraServer(*txn, *name, schemaName)
+	id, err := s.Storage.Server().GetJiraServerId(txn, *name, schemaName)
 	if err != nil {
 		return nil, err
 	}
@@ -196,33 +389,66 @@ func getJiraServerId(txn *sql.Tx, name *string, schemaName string) (*int, error)
 
 func (s CodebaseService) Delete(perf *v1alpha1.Perf, name, schema string) error {
 	log.Printf("start deleting %v codebase", name)
-	txn, err := s.DB.Begin()
-	if err != nil {
-		return errors.Wrapf(err, "couldn't open transaction while deleting codebase %v", name)
-	}
+	err := storage.WithTx(context.TODO(), s.Storage, func(txn storage.Tx) error {
+		if err := s.deleteCodebasePerfDataSourceRecord(txn, perf, name, schema); err != nil {
+			return err
+		}
 
-	if err := deleteCodebasePerfDataSourceRecord(txn, perf, name, schema); err != nil {
-		return err
-	}
+		// branches and their docker streams are removed by cascade, so they
+		// are selected beforehand to record their events
+		branches, err := s.Storage.CodebaseBranch().GetNames(txn, name, schema)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't get branches of codebase %v", name)
+		}
+		streams, err := s.Storage.DockerStream().GetCodebaseStreams(txn, name, schema)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't get docker streams of codebase %v", name)
+		}
 
-	if err := repository.Delete(*txn, name, schema); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "couldn't delete codebase %v", name)
-	}
+		if err := s.Storage.Codebase().Delete(txn, name, schema); err != nil {
+			return errors.Wrapf(err, "couldn't delete codebase %v", name)
+		}
 
-	if err := txn.Commit(); err != nil {
-		return errors.Wrapf(err, "couldn't close transaction while deleting codebase %v", name)
+		var events []model.Event
+		for _, ds := range streams {
+			id := ds.Id
+			events = append(events, model.Event{
+				Kind:      model.DockerStreamEvent,
+				Operation: model.Deleted,
+				EntityId:  &id,
+				Subject:   ds.Name,
+				Data:      map[string]string{"codebase": name, "codebaseBranch": ds.Branch},
+			})
+		}
+		for _, b := range branches {
+			events = append(events, model.Event{
+				Kind:      model.BranchEvent,
+				Operation: model.Deleted,
+				Subject:   name + "/" + b,
+				Data:      map[string]string{"codebase": name},
+			})
+		}
+		events = append(events, model.Event{Kind: model.CodebaseEvent, Operation: model.Deleted, Subject: name})
+		for _, e := range events {
+			if err := s.Storage.Outbox().Put(txn, e, schema); err != nil {
+				return errors.Wrapf(err, "couldn't record event of deleted codebase %v", name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 	log.Printf("end deleting %v codebase", name)
 	return nil
 }
 
-func deleteCodebasePerfDataSourceRecord(txn *sql.Tx, perf *v1alpha1.Perf, name, schema string) error {
+func (s CodebaseService) deleteCodebasePerfDataSourceRecord(txn storage.Tx, perf *v1alpha1.Perf, name, schema string) error {
 	if perf == nil {
 		return nil
 	}
 
-	id, err := repository.GetApplicationId(*txn, name, schema)
+	id, err := s.Storage.Codebase().GetApplicationId(txn, name, schema)
 	if err != nil {
 		return errors.Wrapf(err, "couldn't get %v codebase id", name)
 	}
@@ -230,8 +456,7 @@ func deleteCodebasePerfDataSourceRecord(txn *sql.Tx, perf *v1alpha1.Perf, name,
 		return nil
 	}
 
-	if err := codebaseperfdatasourceRepo.DeleteCodebasePerfDataSourceRecord(*txn, *id, schema); err != nil {
-		_ = txn.Rollback()
+	if err := s.Storage.Codebase().DeletePerfDataSources(txn, *id, schema); err != nil {
 		return errors.Wrapf(err, "couldn't delete codebase perf data source record for codebase %v", name)
 	}
 	return nil
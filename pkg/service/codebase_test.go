@@ -0,0 +1,461 @@
+package service
+
+import (
+	"errors"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
+	"github.com/epmd-edp/reconciler/v2/pkg/message"
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebase"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebasebranch"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const schema = "fake-schema"
+
+func createGitServer(t *testing.T, store *memory.Storage, name string) {
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	_, err = store.Server().CreateGitServer(tx, name, "fake-host", true, schema)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+}
+
+func TestPutCodebase_ShouldCreateCodebase(t *testing.T) {
+	store := memory.New()
+	createGitServer(t, store, "gerrit")
+	store.AddJenkinsSlave(schema, "maven")
+	store.AddJobProvisioning(schema, "default", "ci")
+	slave, provisioning := "maven", "default"
+
+	s := CodebaseService{Storage: store}
+	err := s.PutCodebase(codebase.Codebase{
+		Name:            "fake-app",
+		Tenant:          schema,
+		Type:            string(codebase.Application),
+		Status:          "created",
+		GitServer:       "gerrit",
+		JenkinsSlave:    &slave,
+		JobProvisioning: &provisioning,
+		ActionLog:       model.ActionLog{Action: "codebase_registration"},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "created", store.Status(schema, "codebase", "fake-app"))
+	assert.Equal(t, []string{"codebase_registration"}, store.ActionLogs(schema))
+}
+
+func TestPutCodebase_ShouldNotDuplicateReplayedActionLog(t *testing.T) {
+	store := memory.New()
+	createGitServer(t, store, "gerrit")
+	s := CodebaseService{Storage: store}
+	c := codebase.Codebase{
+		Name:      "fake-app",
+		Tenant:    schema,
+		GitServer: "gerrit",
+		Status:    "created",
+		ActionLog: model.ActionLog{Action: "codebase_registration", Result: "success", UpdatedAt: time.Now()},
+	}
+
+	assert.NoError(t, s.PutCodebase(c))
+	assert.NoError(t, s.PutCodebase(c))
+	c.ActionLog.Action = "gerrit_repository_provisioning"
+	assert.NoError(t, s.PutCodebase(c))
+
+	assert.Equal(t, []string{"codebase_registration", "gerrit_repository_provisioning"}, store.ActionLogs(schema))
+}
+
+func TestPutCodebase_ShouldNotDuplicateActionLogFormattedInAnotherLocale(t *testing.T) {
+	store := memory.New()
+	createGitServer(t, store, "gerrit")
+	s := CodebaseService{Storage: store}
+	uk := message.New()
+	assert.NoError(t, uk.SetLocale(message.Ukrainian))
+	c := codebase.Codebase{
+		Name:      "fake-app",
+		Tenant:    schema,
+		GitServer: "gerrit",
+		Status:    "created",
+		ActionLog: model.ActionLog{Action: "codebase_registration", Result: "success", UpdatedAt: time.Now()},
+	}
+
+	c.ActionLog.ActionMessage = message.New().Format(message.Codebase, c.ActionLog.Action, message.Args{"name": c.Name})
+	assert.NoError(t, s.PutCodebase(c))
+	c.ActionLog.ActionMessage = uk.Format(message.Codebase, c.ActionLog.Action, message.Args{"name": c.Name})
+	assert.NoError(t, s.PutCodebase(c))
+
+	assert.Equal(t, []string{"codebase_registration"}, store.ActionLogs(schema))
+}
+
+func TestGetTimeline_ShouldMergeCodebaseAndBranchActionLogs(t *testing.T) {
+	store := memory.New()
+	createGitServer(t, store, "gerrit")
+	s := CodebaseService{Storage: store}
+	now := time.Now()
+	assert.NoError(t, s.PutCodebase(codebase.Codebase{
+		Name:      "fake-app",
+		Tenant:    schema,
+		GitServer: "gerrit",
+		ActionLog: model.ActionLog{Action: "codebase_registration", Result: "success", UpdatedAt: now.Add(-time.Hour)},
+	}))
+
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	cbId, err := store.Codebase().GetId(tx, "fake-app", schema)
+	assert.NoError(t, err)
+	brId, err := store.CodebaseBranch().Create(tx, codebasebranch.CodebaseBranch{Name: "master", AppName: "fake-app"}, *cbId, nil, schema)
+	assert.NoError(t, err)
+	_, err = store.ActionLog().PutToCodebaseBranch(tx, *brId,
+		model.ActionLog{Action: "codebase_branch_registration", Result: "error", UpdatedAt: now}, schema)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+
+	entries, err := s.GetTimeline("fake-app", schema, model.TimelineFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "codebase_branch_registration", entries[0].Action)
+	assert.Equal(t, "master", entries[0].Branch)
+	assert.Equal(t, "codebase_registration", entries[1].Action)
+	assert.Empty(t, entries[1].Branch)
+
+	entries, err = s.GetTimeline("fake-app", schema, model.TimelineFilter{Result: "success"})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "codebase_registration", entries[0].Action)
+
+	entries, err = s.GetTimeline("fake-app", schema, model.TimelineFilter{Since: now.Add(-time.Minute)})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "master", entries[0].Branch)
+}
+
+func TestPutCodebase_ShouldReturnErrorWhenGitServerDoesNotExist(t *testing.T) {
+	store := memory.New()
+
+	s := CodebaseService{Storage: store}
+	err := s.PutCodebase(codebase.Codebase{Name: "fake-app", Tenant: schema, GitServer: "gerrit"})
+	assert.Error(t, err)
+	assert.Empty(t, store.Status(schema, "codebase", "fake-app"))
+}
+
+func TestPutCodebase_ShouldReturnErrorWhenJobProvisioningDoesNotExist(t *testing.T) {
+	store := memory.New()
+	createGitServer(t, store, "gerrit")
+	provisioning := "default"
+
+	s := CodebaseService{Storage: store}
+	err := s.PutCodebase(codebase.Codebase{
+		Name:            "fake-app",
+		Tenant:          schema,
+		GitServer:       "gerrit",
+		JobProvisioning: &provisioning,
+	})
+	assert.Error(t, err)
+	assert.Empty(t, store.ActionLogs(schema))
+}
+
+func TestPutCodebase_ShouldWaitForUnresolvedReferences(t *testing.T) {
+	name := "fake-name"
+	tests := []struct {
+		name string
+		set  func(c *codebase.Codebase)
+	}{
+		{"jira server", func(c *codebase.Codebase) { c.JiraServer = &name }},
+		{"jenkins slave", func(c *codebase.Codebase) { c.JenkinsSlave = &name }},
+		{"job provisioning", func(c *codebase.Codebase) { c.JobProvisioning = &name }},
+		{"perf server", func(c *codebase.Codebase) { c.Perf = &codebase.Perf{Name: name} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.New()
+			createGitServer(t, store, "gerrit")
+			s := CodebaseService{Storage: store}
+			c := codebase.Codebase{Name: "fake-app", Tenant: schema, GitServer: "gerrit"}
+			tt.set(&c)
+
+			err := s.PutCodebase(c)
+			assert.Error(t, err)
+			assert.Equal(t, backoff.Dependency, backoff.Classify(err))
+			assert.Contains(t, err.Error(), name)
+			assert.Empty(t, store.Status(schema, "codebase", "fake-app"))
+		})
+	}
+}
+
+func TestDelete_ShouldRemoveCodebase(t *testing.T) {
+	store := memory.New()
+	createGitServer(t, store, "gerrit")
+	s := CodebaseService{Storage: store}
+	assert.NoError(t, s.PutCodebase(codebase.Codebase{Name: "fake-app", Tenant: schema, GitServer: "gerrit", Status: "created"}))
+
+	assert.NoError(t, s.Delete(nil, "fake-app", schema))
+	assert.Empty(t, store.Status(schema, "codebase", "fake-app"))
+}
+
+func TestDelete_ShouldRecordEventsOfCascadedBranchesAndDockerStreams(t *testing.T) {
+	store := memory.New()
+	createGitServer(t, store, "gerrit")
+	s := CodebaseService{Storage: store}
+	assert.NoError(t, s.PutCodebase(codebase.Codebase{Name: "fake-app", Tenant: schema, GitServer: "gerrit"}))
+
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	cbId, err := store.Codebase().GetId(tx, "fake-app", schema)
+	assert.NoError(t, err)
+	for _, b := range []string{"master", "develop"} {
+		brId, err := store.CodebaseBranch().Create(tx, codebasebranch.CodebaseBranch{Name: b, AppName: "fake-app"}, *cbId, nil, schema)
+		assert.NoError(t, err)
+		_, err = store.DockerStream().Create(tx, brId, "fake-app-"+b, schema)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tx.Commit())
+
+	assert.NoError(t, s.Delete(nil, "fake-app", schema))
+
+	var events []string
+	for _, e := range store.Events(schema)[1:] {
+		events = append(events, e.Kind+"."+e.Operation+" "+e.Subject)
+	}
+	assert.Equal(t, []string{
+		"codebase_docker_stream.deleted fake-app-develop",
+		"codebase_docker_stream.deleted fake-app-master",
+		"codebase_branch.deleted fake-app/develop",
+		"codebase_branch.deleted fake-app/master",
+		"codebase.deleted fake-app",
+	}, events)
+
+	tx, err = store.Begin()
+	assert.NoError(t, err)
+	defer tx.Commit()
+	id, err := store.DockerStream().GetId(tx, "fake-app-master", schema)
+	assert.NoError(t, err)
+	assert.Nil(t, id)
+}
+
+func TestPutCodebase_ShouldRecordEventsOfChanges(t *testing.T) {
+	store := memory.New()
+	createGitServer(t, store, "gerrit")
+	s := CodebaseService{Storage: store}
+	c := codebase.Codebase{
+		Name:      "fake-app",
+		Tenant:    schema,
+		GitServer: "gerrit",
+		Status:    "created",
+		ActionLog: model.ActionLog{Action: "codebase_registration", Result: "success", UpdatedAt: time.Now()},
+	}
+
+	assert.NoError(t, s.PutCodebase(c))
+	assert.NoError(t, s.PutCodebase(c))
+	c.ActionLog.Action = "gerrit_repository_provisioning"
+	assert.NoError(t, s.PutCodebase(c))
+	assert.NoError(t, s.Delete(nil, "fake-app", schema))
+
+	var ops []string
+	for _, e := range store.Events(schema) {
+		assert.Equal(t, model.CodebaseEvent, e.Kind)
+		assert.Equal(t, "fake-app", e.Subject)
+		ops = append(ops, e.Operation)
+	}
+	assert.Equal(t, []string{model.Created, model.Updated, model.Deleted}, ops)
+	assert.Equal(t, "gerrit_repository_provisioning", store.Events(schema)[1].Data["action"])
+}
+
+func TestPutCodebase_ShouldUpdateAllColumnsAndRecordChanges(t *testing.T) {
+	store := memory.New()
+	createGitServer(t, store, "gerrit")
+	store.AddJenkinsSlave(schema, "maven")
+	slaveId := store.AddJenkinsSlave(schema, "gradle")
+	slave := "maven"
+	s := CodebaseService{Storage: store}
+	c := codebase.Codebase{
+		Name:          "fake-app",
+		Tenant:        schema,
+		GitServer:     "gerrit",
+		Status:        "created",
+		JenkinsSlave:  &slave,
+		DefaultBranch: "master",
+		ActionLog:     model.ActionLog{Action: "codebase_registration", Result: "success", UpdatedAt: time.Now()},
+	}
+	assert.NoError(t, s.PutCodebase(c))
+	assert.NoError(t, s.PutCodebase(c))
+	assert.Empty(t, store.Changes(schema, "fake-app"))
+
+	gradle := "gradle"
+	c.JenkinsSlave = &gradle
+	c.Description = "fake description"
+	c.RouteSite = "fake-site"
+	assert.NoError(t, s.PutCodebase(c))
+
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	stored, err := store.Codebase().Get(tx, "fake-app", schema)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.Equal(t, "fake description", stored.Description)
+	assert.Equal(t, "fake-site", stored.RouteSite)
+	assert.Equal(t, slaveId, *stored.JenkinsSlaveId)
+	assert.Equal(t, "created", stored.Status)
+
+	var fields []string
+	for _, ch := range store.Changes(schema, "fake-app") {
+		fields = append(fields, ch.Field)
+	}
+	assert.Equal(t, []string{"route_site", "description", "jenkins_slave_id"}, fields)
+	events := store.Events(schema)
+	assert.Equal(t, "route_site,description,jenkins_slave_id", events[len(events)-1].Data["changed"])
+}
+
+func TestPutCodebase_ShouldFailUpdateWhenReferenceDoesNotExist(t *testing.T) {
+	store := memory.New()
+	createGitServer(t, store, "gerrit")
+	s := CodebaseService{Storage: store}
+	c := codebase.Codebase{Name: "fake-app", Tenant: schema, GitServer: "gerrit", Status: "created"}
+	assert.NoError(t, s.PutCodebase(c))
+
+	provisioning := "default"
+	c.JobProvisioning = &provisioning
+	c.Description = "fake description"
+	assert.Error(t, s.PutCodebase(c))
+	assert.Empty(t, store.Changes(schema, "fake-app"))
+}
+
+func TestPutCodebase_ShouldKeepHistoryOfRepositoryLocations(t *testing.T) {
+	store := memory.New()
+	createGitServer(t, store, "gerrit")
+	createGitServer(t, store, "github")
+	s := CodebaseService{Storage: store}
+	path := "/fake/app"
+	c := codebase.Codebase{Name: "fake-app", Tenant: schema, GitServer: "gerrit", Strategy: "import", GitUrlPath: &path}
+	assert.NoError(t, s.PutCodebase(c))
+
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	gerritId, err := store.Server().GetGitServerId(tx, "gerrit", schema)
+	assert.NoError(t, err)
+	githubId, err := store.Server().GetGitServerId(tx, "github", schema)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+
+	newPath := "/fake/moved-app"
+	c.GitServer = "github"
+	c.GitUrlPath = &newPath
+	assert.NoError(t, s.PutCodebase(c))
+	c.Description = "fake description"
+	assert.NoError(t, s.PutCodebase(c))
+
+	history, err := s.GetRepositoryHistory("fake-app", schema)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, *gerritId, *history[0].GitServerId)
+	assert.Equal(t, path, *history[0].GitUrlPath)
+
+	tx, err = store.Begin()
+	assert.NoError(t, err)
+	stored, err := store.Codebase().Get(tx, "fake-app", schema)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.Equal(t, *githubId, *stored.GitServerId)
+	assert.Equal(t, newPath, *stored.GitUrlPath)
+
+	var moved []model.Event
+	for _, e := range store.Events(schema) {
+		if e.Operation == model.Moved {
+			moved = append(moved, e)
+		}
+	}
+	assert.Len(t, moved, 1)
+	assert.Equal(t, map[string]string{"gitServer": "github", "gitUrlPath": newPath, "previousGitUrlPath": path}, moved[0].Data)
+}
+
+func TestPutCodebase_ShouldReconcilePerfDataSources(t *testing.T) {
+	store := memory.New()
+	createGitServer(t, store, "gerrit")
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, store.Server().CreatePerfServer(tx, "fake-perf", true, schema))
+	assert.NoError(t, store.Server().CreatePerfServer(tx, "other-perf", true, schema))
+	assert.NoError(t, tx.Commit())
+
+	s := CodebaseService{Storage: store}
+	c := codebase.Codebase{Name: "fake-app", Tenant: schema, GitServer: "gerrit",
+		Perf: &codebase.Perf{Name: "fake-perf", DataSources: []string{"Sonar", "Jenkins"}}}
+	assert.NoError(t, s.PutCodebase(c))
+	assert.Equal(t, []string{"JENKINS", "SONAR"}, perfDataSources(t, store))
+
+	c.Perf = &codebase.Perf{Name: "other-perf", DataSources: []string{"Jenkins", "GitLab"}}
+	assert.NoError(t, s.PutCodebase(c))
+	assert.Equal(t, []string{"GITLAB", "JENKINS"}, perfDataSources(t, store))
+
+	c.Perf = nil
+	assert.NoError(t, s.PutCodebase(c))
+	assert.Empty(t, perfDataSources(t, store))
+
+	var changes []codebase.Change
+	for _, ch := range store.Changes(schema, "fake-app") {
+		if ch.Field == "perf_data_sources" {
+			changes = append(changes, ch)
+		}
+	}
+	assert.Equal(t, []codebase.Change{
+		{Field: "perf_data_sources", Old: "JENKINS,SONAR", New: "GITLAB,JENKINS"},
+		{Field: "perf_data_sources", Old: "GITLAB,JENKINS", New: ""},
+	}, changes)
+	assert.Equal(t, "perf_server_id", store.Changes(schema, "fake-app")[0].Field)
+}
+
+// failingDataSources fails to link the codebase to data sources of the type.
+type failingDataSources struct {
+	*memory.Storage
+	dsType string
+}
+
+func (s failingDataSources) Codebase() storage.CodebaseRepository {
+	return failingCodebaseRepository{CodebaseRepository: s.Storage.Codebase(), dsType: s.dsType}
+}
+
+type failingCodebaseRepository struct {
+	storage.CodebaseRepository
+	dsType string
+}
+
+func (r failingCodebaseRepository) AddPerfDataSource(tx storage.Tx, id int, dsType, schema string) error {
+	if dsType == r.dsType {
+		return errors.New("fake error")
+	}
+	return r.CodebaseRepository.AddPerfDataSource(tx, id, dsType, schema)
+}
+
+func TestPutCodebase_ShouldLinkOtherPerfDataSourcesIfOneFails(t *testing.T) {
+	store := memory.New()
+	createGitServer(t, store, "gerrit")
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, store.Server().CreatePerfServer(tx, "fake-perf", true, schema))
+	assert.NoError(t, tx.Commit())
+
+	s := CodebaseService{Storage: failingDataSources{Storage: store, dsType: "SONAR"}}
+	c := codebase.Codebase{Name: "fake-app", Tenant: schema, GitServer: "gerrit",
+		Perf: &codebase.Perf{Name: "fake-perf", DataSources: []string{"Sonar", "Jenkins"}}}
+	assert.NoError(t, s.PutCodebase(c))
+	assert.Equal(t, []string{"JENKINS"}, perfDataSources(t, store))
+
+	s.Storage = store
+	assert.NoError(t, s.PutCodebase(c))
+	assert.Equal(t, []string{"JENKINS", "SONAR"}, perfDataSources(t, store))
+	assert.Contains(t, store.Changes(schema, "fake-app"),
+		codebase.Change{Field: "perf_data_sources", Old: "JENKINS", New: "JENKINS,SONAR"})
+}
+
+func perfDataSources(t *testing.T, store *memory.Storage) []string {
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	defer tx.Commit()
+	id, err := store.Codebase().GetId(tx, "fake-app", schema)
+	assert.NoError(t, err)
+	types, err := store.Codebase().GetPerfDataSources(tx, *id, schema)
+	assert.NoError(t, err)
+	return types
+}
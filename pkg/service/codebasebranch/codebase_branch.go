@@ -1,12 +1,13 @@
 package codebasebranch
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
 	"github.com/epmd-edp/reconciler/v2/pkg/model/codebase"
 	"github.com/epmd-edp/reconciler/v2/pkg/model/codebasebranch"
-	"github.com/epmd-edp/reconciler/v2/pkg/repository"
-	cbs "github.com/epmd-edp/reconciler/v2/pkg/repository/codebasebranch"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
 	"github.com/pkg/errors"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 )
@@ -14,96 +15,92 @@ import (
 var log = logf.Log.WithName("codebase-branch-service")
 
 type CodebaseBranchService struct {
-	DB *sql.DB
+	Storage storage.Storage
 }
 
 func (s CodebaseBranchService) PutCodebaseBranch(codebaseBranch codebasebranch.CodebaseBranch) error {
 	log.V(2).Info("start creation of codebase branch", "name", codebaseBranch.Name)
-	txn, err := s.DB.Begin()
-	if err != nil {
-		return errors.Wrap(err, "an error has occurred while opening transaction")
-	}
-	schemaName := codebaseBranch.Tenant
-
-	id, err := getCodebaseBranchIdOrCreate(txn, codebaseBranch, schemaName)
-	if err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred while getting Codebase Branch id or create %v",
-			"branch %v")
-	}
-
-	if err := updateCodebaseBranch(txn, codebaseBranch, *id, schemaName); err != nil {
-		_ = txn.Rollback()
-		return errors.New(fmt.Sprintf("cannot insert codebaseBranch update %v", codebaseBranch))
-	}
-	log.V(2).Info("CodebaseBranch has been updated", "name", codebaseBranch.Name)
+	err := storage.WithTx(context.TODO(), s.Storage, func(txn storage.Tx) error {
+		schemaName := codebaseBranch.Tenant
 
-	log.V(2).Info("start update status of codebase branch...")
-	actionLogId, err := repository.CreateActionLog(*txn, codebaseBranch.ActionLog, schemaName)
-	if err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred during status creation %v", "name %v")
-	}
-	log.V(2).Info("ActionLog has been saved into the repository")
+		id, created, err := s.getCodebaseBranchIdOrCreate(txn, codebaseBranch, schemaName)
+		if err != nil {
+			return errors.Wrapf(err, "an error has occurred while getting Codebase Branch id or create %v",
+				"branch %v")
+		}
 
-	log.V(2).Info("Start update codebase_branch_action status of code branch entity...")
-	cbId, err := repository.GetCodebaseId(*txn, codebaseBranch.AppName, schemaName)
-	if err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred during retrieving codebase id %v", "id %v")
-	}
+		if err := s.updateCodebaseBranch(txn, codebaseBranch, *id, created, schemaName); err != nil {
+			return errors.Wrapf(err, "cannot update codebase branch %v", codebaseBranch.Name)
+		}
+		log.V(2).Info("CodebaseBranch has been updated", "name", codebaseBranch.Name)
 
-	if err := repository.CreateCodebaseAction(*txn, *cbId, *actionLogId, schemaName); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrap(err, "an error has occurred during codebase_branch_action")
-	}
-	log.V(2).Info("codebase_action has been updated")
+		log.V(2).Info("start update status of codebase branch...")
+		stored, err := s.Storage.ActionLog().PutToCodebaseBranch(txn, *id, codebaseBranch.ActionLog, schemaName)
+		if err != nil {
+			return errors.Wrap(err, "an error has occurred during codebase_branch_action")
+		}
+		log.V(2).Info("ActionLog has been saved into the repository", "stored", stored)
 
-	if err := cbs.UpdateStatusByCodebaseBranchId(*txn, *id, codebaseBranch.Status, codebaseBranch.Tenant); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred during the update of codebase branch %v", codebaseBranch.Name)
-	}
+		if created || stored {
+			if err := s.Storage.Outbox().Put(txn, branchEvent(*id, created, codebaseBranch), schemaName); err != nil {
+				return errors.Wrapf(err, "an error has occurred while recording event of codebase branch %v", codebaseBranch.Name)
+			}
+		}
 
-	if err := txn.Commit(); err != nil {
+		if err := s.Storage.CodebaseBranch().UpdateStatus(txn, *id, codebaseBranch.Status, codebaseBranch.Tenant); err != nil {
+			return errors.Wrapf(err, "an error has occurred during the update of codebase branch %v", codebaseBranch.Name)
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 	log.Info("Codebase Branch has been saved successfully", "name", codebaseBranch.Name)
 	return nil
 }
 
-func createCodebaseBranch(txn *sql.Tx, codebaseBranch codebasebranch.CodebaseBranch, schemaName string) (*int, error) {
+func (s CodebaseBranchService) createCodebaseBranch(txn storage.Tx, codebaseBranch codebasebranch.CodebaseBranch, schemaName string) (*int, error) {
 	log.V(2).Info("start codebase_branch insertion", "name", codebaseBranch.Name)
 	var streamId *int = nil
-	beId, err := repository.GetCodebaseId(*txn, codebaseBranch.AppName, schemaName)
+	beId, err := s.Storage.Codebase().GetId(txn, codebaseBranch.AppName, schemaName)
 	if err != nil {
 		return nil, err
 	}
 	if beId == nil {
-		return nil, fmt.Errorf("%v codebase record has not been found", codebaseBranch.AppName)
+		return nil, backoff.AsDependency(fmt.Errorf("%v codebase record has not been found", codebaseBranch.AppName))
 	}
 
-	cbType, err := repository.GetCodebaseTypeById(*txn, *beId, schemaName)
+	cbType, err := s.Storage.Codebase().GetType(txn, *beId, schemaName)
 	if err != nil {
 		return nil, err
 	}
 
 	if *cbType == string(codebase.Application) {
 		ocImageStreamName := fmt.Sprintf("%v-%v", codebaseBranch.AppName, codebaseBranch.Name)
-		streamId, err = repository.CreateCodebaseDockerStream(*txn, schemaName, nil, ocImageStreamName)
+		streamId, err = s.Storage.DockerStream().Create(txn, nil, ocImageStreamName, schemaName)
 		if err != nil {
 			return nil, err
 		}
 		log.V(2).Info("codebase docker stream has been created", "id", streamId)
+
+		e := model.Event{
+			Kind:      model.DockerStreamEvent,
+			Operation: model.Created,
+			EntityId:  streamId,
+			Subject:   ocImageStreamName,
+			Data:      map[string]string{"codebase": codebaseBranch.AppName, "codebaseBranch": codebaseBranch.Name},
+		}
+		if err := s.Storage.Outbox().Put(txn, e, schemaName); err != nil {
+			return nil, err
+		}
 	}
-	id, err := cbs.CreateCodebaseBranch(*txn, codebaseBranch.Name, *beId,
-		codebaseBranch.FromCommit, schemaName, streamId, codebaseBranch.Status, codebaseBranch.Version,
-		codebaseBranch.BuildNumber, codebaseBranch.LastSuccessBuild, codebaseBranch.Release)
+	id, err := s.Storage.CodebaseBranch().Create(txn, codebaseBranch, *beId, streamId, schemaName)
 	if err != nil {
 		return nil, err
 	}
 
 	if *cbType == string(codebase.Application) {
-		if err := repository.UpdateBranchIdCodebaseDockerStream(*txn, *streamId, *id, schemaName); err != nil {
+		if err := s.Storage.DockerStream().SetBranchId(txn, *streamId, *id, schemaName); err != nil {
 			return nil, err
 		}
 	}
@@ -111,42 +108,135 @@ func createCodebaseBranch(txn *sql.Tx, codebaseBranch codebasebranch.CodebaseBra
 	return id, nil
 }
 
-func getCodebaseBranchIdOrCreate(txn *sql.Tx, codebaseBranch codebasebranch.CodebaseBranch, schemaName string) (*int, error) {
+// getCodebaseBranchIdOrCreate reports whether the branch has been created
+// along with its id.
+func (s CodebaseBranchService) getCodebaseBranchIdOrCreate(txn storage.Tx, codebaseBranch codebasebranch.CodebaseBranch, schemaName string) (*int, bool, error) {
 	log.V(2).Info("start retrieving Codebase Branch",
 		"codebase", codebaseBranch.AppName, "branch", codebaseBranch.Name)
-	id, err := cbs.GetCodebaseBranchId(*txn, codebaseBranch.AppName, codebaseBranch.Name, schemaName)
+	id, err := s.Storage.CodebaseBranch().GetId(txn, codebaseBranch.AppName, codebaseBranch.Name, schemaName)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	if id == nil {
 		log.V(2).Info("record for Codebase Branch has not been found", "branch", codebaseBranch.Name)
-		return createCodebaseBranch(txn, codebaseBranch, schemaName)
+		id, err := s.createCodebaseBranch(txn, codebaseBranch, schemaName)
+		return id, true, err
+	}
+	return id, false, nil
+}
+
+// branchEvent describes creation of the branch or its update by the action
+// the branch status is set by.
+func branchEvent(id int, created bool, b codebasebranch.CodebaseBranch) model.Event {
+	op := model.Updated
+	if created {
+		op = model.Created
+	}
+	return model.Event{
+		Kind:      model.BranchEvent,
+		Operation: op,
+		EntityId:  &id,
+		Subject:   b.AppName + "/" + b.Name,
+		Data: map[string]string{
+			"codebase": b.AppName,
+			"status":   b.Status,
+			"action":   b.ActionLog.Action,
+			"result":   b.ActionLog.Result,
+		},
 	}
-	return id, nil
 }
 
-func updateCodebaseBranch(txn *sql.Tx, codebaseBranch codebasebranch.CodebaseBranch, id int, schemaName string) error {
+// updateCodebaseBranch updates the branch and appends its build to the build
+// history if the branch has been created or moved to another build.
+func (s CodebaseBranchService) updateCodebaseBranch(txn storage.Tx, codebaseBranch codebasebranch.CodebaseBranch, id int,
+	created bool, schemaName string) error {
 	log.V(2).Info("start updating CodebaseBranch by id", "id", id)
-	err := cbs.UpdateCodebaseBranch(*txn, id, codebaseBranch.Version, codebaseBranch.BuildNumber,
-		codebaseBranch.LastSuccessBuild, schemaName)
+	stored, err := s.Storage.CodebaseBranch().Get(txn, codebaseBranch.AppName, codebaseBranch.Name, schemaName)
 	if err != nil {
 		return err
 	}
-	return nil
+	if err := s.Storage.CodebaseBranch().Update(txn, id, codebaseBranch, schemaName); err != nil {
+		return err
+	}
+
+	build := codebaseBranch.Build()
+	if build.Empty() || (!created && stored != nil && !codebasebranch.BuildChanged(*stored, codebaseBranch)) {
+		return nil
+	}
+	log.V(2).Info("recording build of CodebaseBranch", "id", id, "version", codebaseBranch.Version,
+		"build", codebaseBranch.BuildNumber)
+	return s.Storage.CodebaseBranch().AddBuild(txn, id, build, schemaName)
+}
+
+// GetBuildHistory returns builds of the branch, the latest go first.
+func (s CodebaseBranchService) GetBuildHistory(codebase, branch, schemaName string) ([]codebasebranch.Build, error) {
+	var builds []codebasebranch.Build
+	err := storage.WithTx(context.TODO(), s.Storage, func(txn storage.Tx) error {
+		var err error
+		builds, err = s.Storage.CodebaseBranch().GetBuilds(txn, codebase, branch, schemaName)
+		if err != nil {
+			return errors.Wrapf(err, "an error has occurred during reading build history of codebase branch: %v/%v",
+				codebase, branch)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return builds, nil
 }
 
 func (s *CodebaseBranchService) Delete(codebase, branch, schema string) error {
 	log.V(2).Info("start deleting codebase branch", "codebase", codebase, "branch", branch)
-	txn, err := s.DB.Begin()
+	err := storage.WithTx(context.TODO(), s.Storage, func(txn storage.Tx) error {
+		id, err := s.Storage.CodebaseBranch().GetId(txn, codebase, branch, schema)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't get id of %v codebase branch", codebase)
+		}
+		if id == nil {
+			log.Info("codebase branch doesn't exist, nothing to delete", "codebase", codebase, "branch", branch)
+			return nil
+		}
+
+		streams, err := s.Storage.DockerStream().GetCodebaseStreams(txn, codebase, schema)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't get docker streams of %v codebase", codebase)
+		}
+
+		if err := s.Storage.CodebaseBranch().Delete(txn, codebase, branch, schema); err != nil {
+			return errors.Wrapf(err, "couldn't delete %v codebase branch", codebase)
+		}
+
+		var events []model.Event
+		for _, ds := range streams {
+			if ds.Branch != branch {
+				continue
+			}
+			id := ds.Id
+			events = append(events, model.Event{
+				Kind:      model.DockerStreamEvent,
+				Operation: model.Deleted,
+				EntityId:  &id,
+				Subject:   ds.Name,
+				Data:      map[string]string{"codebase": codebase, "codebaseBranch": branch},
+			})
+		}
+		events = append(events, model.Event{
+			Kind:      model.BranchEvent,
+			Operation: model.Deleted,
+			Subject:   codebase + "/" + branch,
+			Data:      map[string]string{"codebase": codebase},
+		})
+		for _, e := range events {
+			if err := s.Storage.Outbox().Put(txn, e, schema); err != nil {
+				return errors.Wrapf(err, "couldn't record event of deleted %v codebase branch", codebase)
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	if err := cbs.Delete(*txn, codebase, branch, schema); err != nil {
-		return errors.Wrapf(err, "couldn't delete %v codebase branch", codebase)
-	}
-	if err := txn.Commit(); err != nil {
-		return err
-	}
 	log.Info("codebase branch has been deleted", "codebase", codebase, "branch", branch)
 	return nil
 }
@@ -0,0 +1,205 @@
+package codebasebranch
+
+import (
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebase"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebasebranch"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const schema = "fake-schema"
+
+func createCodebase(t *testing.T, store *memory.Storage, name string, cbType codebase.CodebaseType) {
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	_, err = store.Codebase().Create(tx, codebase.Codebase{Name: name, Type: string(cbType)}, schema)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+}
+
+func TestPutCodebaseBranch_ShouldCreateBranchWithDockerStream(t *testing.T) {
+	store := memory.New()
+	createCodebase(t, store, "fake-app", codebase.Application)
+
+	s := CodebaseBranchService{Storage: store}
+	err := s.PutCodebaseBranch(codebasebranch.CodebaseBranch{
+		Name:      "master",
+		Tenant:    schema,
+		AppName:   "fake-app",
+		Status:    "active",
+		ActionLog: model.ActionLog{Action: "codebase_branch_registration"},
+	})
+	assert.NoError(t, err)
+
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	defer tx.Commit()
+
+	id, err := store.CodebaseBranch().GetId(tx, "fake-app", "master", schema)
+	assert.NoError(t, err)
+	assert.NotNil(t, id)
+
+	streamId, err := store.DockerStream().GetId(tx, "fake-app-master", schema)
+	assert.NoError(t, err)
+	assert.NotNil(t, streamId)
+
+	branchId, err := store.DockerStream().GetBranchId(tx, *streamId, schema)
+	assert.NoError(t, err)
+	assert.Equal(t, id, branchId)
+}
+
+func TestPutCodebaseBranch_ShouldNotCreateDockerStreamForLibrary(t *testing.T) {
+	store := memory.New()
+	createCodebase(t, store, "fake-lib", codebase.Library)
+
+	s := CodebaseBranchService{Storage: store}
+	err := s.PutCodebaseBranch(codebasebranch.CodebaseBranch{Name: "master", Tenant: schema, AppName: "fake-lib"})
+	assert.NoError(t, err)
+
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	defer tx.Commit()
+
+	streamId, err := store.DockerStream().GetId(tx, "fake-lib-master", schema)
+	assert.NoError(t, err)
+	assert.Nil(t, streamId)
+}
+
+func TestPutCodebaseBranch_ShouldUpdateExistingBranch(t *testing.T) {
+	store := memory.New()
+	createCodebase(t, store, "fake-app", codebase.Application)
+	s := CodebaseBranchService{Storage: store}
+	b := codebasebranch.CodebaseBranch{Name: "master", Tenant: schema, AppName: "fake-app", Status: "active"}
+
+	assert.NoError(t, s.PutCodebaseBranch(b))
+	b.Status = "inactive"
+	b.ActionLog = model.ActionLog{Action: "put_branch_for_gerrit"}
+	assert.NoError(t, s.PutCodebaseBranch(b))
+
+	assert.Equal(t, "inactive", store.Status(schema, "codebase_branch", "fake-app/master"))
+	assert.Len(t, store.ActionLogs(schema), 2)
+}
+
+func TestPutCodebaseBranch_ShouldNotDuplicateReplayedActionLog(t *testing.T) {
+	store := memory.New()
+	createCodebase(t, store, "fake-app", codebase.Application)
+	s := CodebaseBranchService{Storage: store}
+	b := codebasebranch.CodebaseBranch{
+		Name:      "master",
+		Tenant:    schema,
+		AppName:   "fake-app",
+		Status:    "active",
+		ActionLog: model.ActionLog{Action: "put_branch_for_gerrit", Result: "success", UpdatedAt: time.Now()},
+	}
+
+	assert.NoError(t, s.PutCodebaseBranch(b))
+	assert.NoError(t, s.PutCodebaseBranch(b))
+
+	assert.Equal(t, []string{"put_branch_for_gerrit"}, store.ActionLogs(schema))
+}
+
+func TestPutCodebaseBranch_ShouldReturnErrorWhenCodebaseDoesNotExist(t *testing.T) {
+	store := memory.New()
+
+	s := CodebaseBranchService{Storage: store}
+	err := s.PutCodebaseBranch(codebasebranch.CodebaseBranch{Name: "master", Tenant: schema, AppName: "fake-app"})
+	assert.Error(t, err)
+	assert.Empty(t, store.ActionLogs(schema))
+}
+
+func TestDelete_ShouldRemoveBranch(t *testing.T) {
+	store := memory.New()
+	createCodebase(t, store, "fake-app", codebase.Application)
+	s := CodebaseBranchService{Storage: store}
+	assert.NoError(t, s.PutCodebaseBranch(codebasebranch.CodebaseBranch{Name: "master", Tenant: schema, AppName: "fake-app"}))
+
+	assert.NoError(t, s.Delete("fake-app", "master", schema))
+
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	defer tx.Commit()
+	id, err := store.CodebaseBranch().GetId(tx, "fake-app", "master", schema)
+	assert.NoError(t, err)
+	assert.Nil(t, id)
+}
+
+func TestPutCodebaseBranch_ShouldRecordEventsOfBranchAndDockerStream(t *testing.T) {
+	store := memory.New()
+	createCodebase(t, store, "fake-app", codebase.Application)
+	s := CodebaseBranchService{Storage: store}
+	b := codebasebranch.CodebaseBranch{
+		Name:      "master",
+		Tenant:    schema,
+		AppName:   "fake-app",
+		Status:    "active",
+		ActionLog: model.ActionLog{Action: "codebase_branch_registration"},
+	}
+
+	assert.NoError(t, s.PutCodebaseBranch(b))
+	assert.NoError(t, s.PutCodebaseBranch(b))
+	assert.NoError(t, s.Delete("fake-app", "master", schema))
+
+	var events []string
+	for _, e := range store.Events(schema) {
+		events = append(events, e.Kind+"."+e.Operation+" "+e.Subject)
+	}
+	assert.Equal(t, []string{
+		"codebase_docker_stream.created fake-app-master",
+		"codebase_branch.created fake-app/master",
+		"codebase_docker_stream.deleted fake-app-master",
+		"codebase_branch.deleted fake-app/master",
+	}, events)
+}
+
+func TestDelete_ShouldNotRecordEventOfMissingBranch(t *testing.T) {
+	store := memory.New()
+	createCodebase(t, store, "fake-app", codebase.Application)
+	s := CodebaseBranchService{Storage: store}
+
+	assert.NoError(t, s.Delete("fake-app", "master", schema))
+	assert.Empty(t, store.Events(schema))
+}
+
+func TestPutCodebaseBranch_ShouldUpdateReleaseAndKeepBuildHistory(t *testing.T) {
+	store := memory.New()
+	createCodebase(t, store, "fake-app", codebase.Application)
+	s := CodebaseBranchService{Storage: store}
+	version, firstBuild, secondBuild := "1.0.0-SNAPSHOT", "1", "2"
+	b := codebasebranch.CodebaseBranch{
+		Name:        "release-1.0",
+		Tenant:      schema,
+		AppName:     "fake-app",
+		FromCommit:  "abc",
+		Version:     &version,
+		BuildNumber: &firstBuild,
+	}
+
+	assert.NoError(t, s.PutCodebaseBranch(b))
+	assert.NoError(t, s.PutCodebaseBranch(b))
+	b.FromCommit = "def"
+	b.Release = true
+	b.BuildNumber = &secondBuild
+	b.LastSuccessBuild = &firstBuild
+	assert.NoError(t, s.PutCodebaseBranch(b))
+
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	stored, err := store.CodebaseBranch().Get(tx, "fake-app", "release-1.0", schema)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.Equal(t, "def", stored.FromCommit)
+	assert.True(t, stored.Release)
+	assert.Equal(t, &firstBuild, stored.LastSuccessBuild)
+	assert.NotNil(t, stored.LastSuccessBuildAt)
+
+	builds, err := s.GetBuildHistory("fake-app", "release-1.0", schema)
+	assert.NoError(t, err)
+	if assert.Len(t, builds, 2) {
+		assert.Equal(t, &secondBuild, builds[0].BuildNumber)
+		assert.Equal(t, &firstBuild, builds[1].BuildNumber)
+		assert.Equal(t, &version, builds[1].Version)
+	}
+}
@@ -1,10 +1,12 @@
 package edp_component
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/epmd-edp/reconciler/v2/pkg/model"
 	ec "github.com/epmd-edp/reconciler/v2/pkg/repository/edp-component"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
 	"github.com/pkg/errors"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 	"strings"
@@ -19,33 +21,26 @@ type EDPComponentService struct {
 func (s EDPComponentService) PutEDPComponent(component model.EDPComponent, schemaName string) error {
 	log.Info("Start executing PutEDPComponent method...", "type", component.Type)
 
-	t, err := s.DB.Begin()
-	if err != nil {
-		return err
-	}
-
-	id, err := ec.SelectEDPComponent(*t, component.Type, schemaName)
-	if err != nil {
-		_ = t.Rollback()
-		return errors.Wrap(err, "rollback while executing SelectEDPComponent method")
-	}
+	err := postgres.WithTx(context.TODO(), s.DB, func(t *sql.Tx) error {
+		id, err := ec.SelectEDPComponent(*t, component.Type, schemaName)
+		if err != nil {
+			return errors.Wrap(err, "rollback while executing SelectEDPComponent method")
+		}
 
-	if id != nil {
-		log.Info("Component already exists in DB. Skip insert", "type", component.Type)
-		_ = t.Rollback()
-		return nil
-	}
-
-	tryToModifyUrl(&component)
+		if id != nil {
+			log.Info("Component already exists in DB. Skip insert", "type", component.Type)
+			return nil
+		}
 
-	err = ec.CreateEDPComponent(*t, component, schemaName)
-	if err != nil {
-		_ = t.Rollback()
-		return errors.Wrapf(err, "an error has occurred while creating edp component with type %v", component.Type)
-	}
-	log.Info("EDP component is added", "type", component.Type, "url", component.Url)
+		tryToModifyUrl(&component)
 
-	err = t.Commit()
+		err = ec.CreateEDPComponent(*t, component, schemaName)
+		if err != nil {
+			return errors.Wrapf(err, "an error has occurred while creating edp component with type %v", component.Type)
+		}
+		log.Info("EDP component is added", "type", component.Type, "url", component.Url)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
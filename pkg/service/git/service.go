@@ -1,10 +1,10 @@
 package git
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"github.com/epmd-edp/reconciler/v2/pkg/model/gitserver"
-	"github.com/epmd-edp/reconciler/v2/pkg/repository"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
 	"github.com/pkg/errors"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 )
@@ -12,7 +12,7 @@ import (
 var log = logf.Log.WithName("git-server-service")
 
 type GitServerService struct {
-	DB *sql.DB
+	Storage storage.Storage
 }
 
 // PutGitServer creates record in persistent storage, if corresponding git server does not exist already or updates
@@ -20,36 +20,29 @@ type GitServerService struct {
 func (s GitServerService) PutGitServer(gitServer gitserver.GitServer) error {
 	log.Info("Start PutGitServer method", "Git host", gitServer.GitHost)
 
-	txn, err := s.DB.Begin()
-	if err != nil {
-		return err
-	}
-
-	id, err := repository.SelectGitServer(*txn, gitServer.Name, gitServer.Tenant)
-	if err != nil {
-		_ = txn.Rollback()
-		return errors.Wrap(err, fmt.Sprintf("an error has occurred while fetching Git Server Record %v", gitServer.Name))
-	}
-
-	if id != nil {
-		log.Info("Start updating Git Server", "record", gitServer.Name)
-
-		err = repository.UpdateGitServer(*txn, id, gitServer.ActionLog.Result == "success", gitServer.Tenant)
+	err := storage.WithTx(context.TODO(), s.Storage, func(txn storage.Tx) error {
+		id, err := s.Storage.Server().GetGitServerId(txn, gitServer.Name, gitServer.Tenant)
 		if err != nil {
-			_ = txn.Rollback()
-			return errors.Wrap(err, fmt.Sprintf("an error has occurred while updating Git Server Record %v", gitServer.Name))
+			return errors.Wrap(err, fmt.Sprintf("an error has occurred while fetching Git Server Record %v", gitServer.Name))
 		}
-	} else {
-		log.Info("Start creating Git Server", "record", gitServer.Name)
 
-		_, err = repository.CreateGitServer(*txn, gitServer.Name, gitServer.GitHost, gitServer.ActionLog.Result == "success", gitServer.Tenant)
-		if err != nil {
-			_ = txn.Rollback()
-			return errors.Wrap(err, fmt.Sprintf("an error has occurred while creating Git Server Record %v", gitServer.GitHost))
-		}
-	}
+		if id != nil {
+			log.Info("Start updating Git Server", "record", gitServer.Name)
+
+			err = s.Storage.Server().UpdateGitServer(txn, *id, gitServer.ActionLog.Result == "success", gitServer.Tenant)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("an error has occurred while updating Git Server Record %v", gitServer.Name))
+			}
+		} else {
+			log.Info("Start creating Git Server", "record", gitServer.Name)
 
-	err = txn.Commit()
+			_, err = s.Storage.Server().CreateGitServer(txn, gitServer.Name, gitServer.GitHost, gitServer.ActionLog.Result == "success", gitServer.Tenant)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("an error has occurred while creating Git Server Record %v", gitServer.GitHost))
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
@@ -1,9 +1,11 @@
 package infrastructure
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/epmd-edp/reconciler/v2/pkg/repository"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
 	"github.com/pkg/errors"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 )
@@ -18,17 +20,15 @@ type InfrastructureDbService struct {
 func (s InfrastructureDbService) DoesSchemaExist(schema string) (bool, error) {
 	log.Info("Start check schema ...")
 
-	txn, err := s.DB.Begin()
-	if err != nil {
-		return false, err
-	}
-
-	isSchemaExist, err := repository.DoesSchemaExist(*txn, schema)
-	if err != nil {
-		return false, errors.Wrap(err, fmt.Sprintf("an error has occurred while checking existing of %v schema", schema))
-	}
-
-	err = txn.Commit()
+	var isSchemaExist bool
+	err := postgres.WithTx(context.TODO(), s.DB, func(txn *sql.Tx) error {
+		var err error
+		isSchemaExist, err = repository.DoesSchemaExist(*txn, schema)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("an error has occurred while checking existing of %v schema", schema))
+		}
+		return nil
+	})
 	if err != nil {
 		return false, err
 	}
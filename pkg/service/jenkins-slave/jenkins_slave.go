@@ -1,9 +1,11 @@
 package jenkins_slave
 
 import (
+	"context"
 	"database/sql"
 	jenkinsV2Api "github.com/epmd-edp/jenkins-operator/v2/pkg/apis/v2/v1alpha1"
 	"github.com/epmd-edp/reconciler/v2/pkg/repository/jenkins-slave"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 )
 
@@ -16,31 +18,25 @@ type JenkinsSlaveService struct {
 func (s JenkinsSlaveService) CreateSlavesOrDoNothing(slaves []jenkinsV2Api.Slave, schemaName string) error {
 	log.Info("Start executing CreateSlavesOrDoNothing method... ")
 
-	txn, err := s.DB.Begin()
-	if err != nil {
-		return err
-	}
-
-	for _, s := range slaves {
-		id, err := jenkins_slave.SelectJenkinsSlave(*txn, s.Name, schemaName)
-		if err != nil {
-			_ = txn.Rollback()
-			return err
-		}
-
-		if id != nil {
-			log.Info("Jenkins Slave already exists. Skip adding into db", "name", s)
-			continue
-		}
-
-		err = jenkins_slave.CreateJenkinsSlave(*txn, s.Name, schemaName)
-		if err != nil {
-			_ = txn.Rollback()
-			return err
+	err := postgres.WithTx(context.TODO(), s.DB, func(txn *sql.Tx) error {
+		for _, s := range slaves {
+			id, err := jenkins_slave.SelectJenkinsSlave(*txn, s.Name, schemaName)
+			if err != nil {
+				return err
+			}
+
+			if id != nil {
+				log.Info("Jenkins Slave already exists. Skip adding into db", "name", s)
+				continue
+			}
+
+			err = jenkins_slave.CreateJenkinsSlave(*txn, s.Name, schemaName)
+			if err != nil {
+				return err
+			}
 		}
-	}
-
-	err = txn.Commit()
+		return nil
+	})
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,186 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"github.com/epmd-edp/reconciler/v2/pkg/repository"
+	"github.com/epmd-edp/reconciler/v2/pkg/repository/schemaversion"
+	"github.com/epmd-edp/reconciler/v2/pkg/service/infrastructure"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
+	"github.com/pkg/errors"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"strings"
+)
+
+var log = logf.Log.WithName("migration-service")
+
+// Migration is a single versioned change of tenant schema.
+type Migration struct {
+	Version     int
+	Description string
+	Script      string
+}
+
+// Checksum returns sha256 of the migration script template, it's used to
+// detect scripts which were changed after they had been applied.
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Script))
+	return hex.EncodeToString(sum[:])
+}
+
+type MigrationService struct {
+	DB *sql.DB
+}
+
+// BootstrapResult describes what has been done with tenant schema.
+type BootstrapResult struct {
+	Created bool
+	Applied int
+}
+
+// Migrations returns ordered list of known migrations.
+func Migrations() []Migration {
+	return migrations
+}
+
+// MigrateTenants upgrades every existing tenant schema from the list.
+// Tenants without schema are skipped. Failure of a tenant doesn't stop
+// migration of the others, failed tenants are listed in the error.
+func (s MigrationService) MigrateTenants(tenants []string) error {
+	is := infrastructure.InfrastructureDbService{DB: s.DB}
+	var failed []string
+	for _, t := range tenants {
+		exists, err := is.DoesSchemaExist(t)
+		if err != nil {
+			log.Error(err, "Couldn't check schema existence", "tenant", t)
+			failed = append(failed, t)
+			continue
+		}
+		if !exists {
+			log.Info("Schema doesn't exist. Skip migration", "tenant", t)
+			continue
+		}
+		if err := s.Migrate(t); err != nil {
+			log.Error(err, "Couldn't migrate schema", "tenant", t)
+			failed = append(failed, t)
+		}
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf("couldn't migrate tenants %v", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// Migrate applies pending migrations to the schema in a single transaction
+// guarded by advisory lock.
+func (s MigrationService) Migrate(schemaName string) error {
+	_, err := s.apply(schemaName, false, migrations)
+	return err
+}
+
+// Bootstrap creates tenant schema if it doesn't exist yet and brings it
+// to the latest version including seed data.
+func (s MigrationService) Bootstrap(schemaName string) (*BootstrapResult, error) {
+	return s.apply(schemaName, true, migrations)
+}
+
+func (s MigrationService) apply(schemaName string, create bool, ms []Migration) (*BootstrapResult, error) {
+	log.Info("Start migrating schema", "schema", schemaName)
+
+	var res *BootstrapResult
+	err := postgres.WithTx(context.TODO(), s.DB, func(txn *sql.Tx) error {
+		var err error
+		res, err = s.migrate(*txn, schemaName, create, ms)
+		if err != nil {
+			return errors.Wrapf(err, "an error has occurred while migrating %v schema", schemaName)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Schema has been migrated", "schema", schemaName, "created", res.Created, "applied", res.Applied)
+	return res, nil
+}
+
+func (s MigrationService) migrate(txn sql.Tx, schemaName string, create bool, ms []Migration) (*BootstrapResult, error) {
+	if err := schemaversion.AcquireLock(txn, schemaName); err != nil {
+		return nil, errors.Wrap(err, "couldn't acquire migration lock")
+	}
+
+	res := &BootstrapResult{}
+	if create {
+		exists, err := repository.DoesSchemaExist(txn, schemaName)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			log.Info("Schema doesn't exist. Creating", "schema", schemaName)
+			if err := schemaversion.CreateSchema(txn, schemaName); err != nil {
+				return nil, errors.Wrap(err, "couldn't create schema")
+			}
+			res.Created = true
+		}
+	}
+
+	if err := schemaversion.CreateSchemaVersionTable(txn, schemaName); err != nil {
+		return nil, errors.Wrap(err, "couldn't create schema_version table")
+	}
+
+	versions, err := schemaversion.GetAppliedVersions(txn, schemaName)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't read applied versions")
+	}
+
+	pending, err := pendingMigrations(ms, versions)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range pending {
+		log.Info("Applying migration", "schema", schemaName, "version", m.Version, "description", m.Description)
+		if _, err := txn.Exec(fmt.Sprintf(m.Script, schemaName)); err != nil {
+			return nil, errors.Wrapf(err, "couldn't apply migration %v", m.Version)
+		}
+		if err := schemaversion.InsertSchemaVersion(txn, schemaName, m.Version, m.Description, m.Checksum()); err != nil {
+			return nil, errors.Wrapf(err, "couldn't save version %v", m.Version)
+		}
+	}
+	res.Applied = len(pending)
+	return res, nil
+}
+
+func pendingMigrations(ms []Migration, applied []schemaversion.AppliedVersion) ([]Migration, error) {
+	checksums := make(map[int]string, len(applied))
+	for _, v := range applied {
+		checksums[v.Version] = v.Checksum
+	}
+
+	var pending []Migration
+	last := 0
+	for _, m := range ms {
+		if m.Version <= last {
+			return nil, fmt.Errorf("migrations are not ordered: version %v goes after %v", m.Version, last)
+		}
+		last = m.Version
+
+		checksum, ok := checksums[m.Version]
+		if !ok {
+			pending = append(pending, m)
+			continue
+		}
+		if checksum != m.Checksum() {
+			return nil, fmt.Errorf("checksum mismatch for applied migration %v (%v)", m.Version, m.Description)
+		}
+		delete(checksums, m.Version)
+	}
+
+	for v := range checksums {
+		return nil, fmt.Errorf("schema has version %v which is unknown to reconciler", v)
+	}
+	return pending, nil
+}
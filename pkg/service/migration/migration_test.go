@@ -0,0 +1,105 @@
+package migration
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/epmd-edp/reconciler/v2/pkg/repository/schemaversion"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+)
+
+func TestMigrate_ShouldApplyOnlyPendingMigrations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ms := []Migration{
+		{Version: 1, Description: "baseline", Script: `create table if not exists "%[1]v".codebase(id serial primary key);`},
+		{Version: 2, Description: "codebase name", Script: `alter table "%[1]v".codebase add column if not exists name text;`},
+	}
+	rows := sqlmock.NewRows([]string{"version", "checksum"}).AddRow(ms[0].Version, ms[0].Checksum())
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("select pg_advisory_xact_lock(hashtext($1));")).
+		WithArgs("schema_version.fake-schema").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`create table if not exists "fake-schema".schema_version`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`select version, checksum from "fake-schema".schema_version`).
+		WillReturnRows(rows)
+	mock.ExpectExec(`alter table "fake-schema".codebase add column if not exists name`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare(`insert into "fake-schema".schema_version`).ExpectExec().
+		WithArgs(ms[1].Version, ms[1].Description, ms[1].Checksum()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	res, err := MigrationService{DB: db}.apply("fake-schema", false, ms)
+	assert.NoError(t, err)
+	assert.False(t, res.Created)
+	assert.Equal(t, 1, res.Applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPendingMigrations_ShouldFailOnChecksumMismatch(t *testing.T) {
+	ms := []Migration{{Version: 1, Description: "baseline", Script: "select 1;"}}
+	applied := []schemaversion.AppliedVersion{{Version: 1, Checksum: "changed"}}
+
+	_, err := pendingMigrations(ms, applied)
+	assert.Error(t, err)
+}
+
+func TestPendingMigrations_ShouldFailOnUnknownVersion(t *testing.T) {
+	ms := []Migration{{Version: 1, Description: "baseline", Script: "select 1;"}}
+	applied := []schemaversion.AppliedVersion{
+		{Version: 1, Checksum: ms[0].Checksum()},
+		{Version: 2, Checksum: "newer"},
+	}
+
+	_, err := pendingMigrations(ms, applied)
+	assert.Error(t, err)
+}
+
+func TestPendingMigrations_ShouldFailOnUnorderedList(t *testing.T) {
+	ms := []Migration{{Version: 2, Script: "select 2;"}, {Version: 1, Script: "select 1;"}}
+
+	_, err := pendingMigrations(ms, nil)
+	assert.Error(t, err)
+}
+
+func TestBootstrap_ShouldCreateMissingSchema(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("select pg_advisory_xact_lock(hashtext($1));")).
+		WithArgs("schema_version.fake-schema").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("select exists(select 1 from pg_namespace where nspname = $1);")).
+		WithArgs("fake-schema").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`create schema if not exists "fake-schema"`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`create table if not exists "fake-schema".schema_version`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`select version, checksum from "fake-schema".schema_version`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "checksum"}))
+	for _, m := range Migrations() {
+		mock.ExpectExec(`.+`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(`insert into "fake-schema".schema_version`).ExpectExec().
+			WithArgs(m.Version, m.Description, m.Checksum()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	res, err := MigrationService{DB: db}.Bootstrap("fake-schema")
+	assert.NoError(t, err)
+	assert.True(t, res.Created)
+	assert.Equal(t, len(Migrations()), res.Applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,391 @@
+package migration
+
+// migrations is the ordered list of tenant schema changes. Every script is
+// rendered with the tenant schema name as %[1]v and must stay idempotent, so
+// it is safe to apply it on top of schemas created before versioning was
+// introduced. Never edit an applied migration, add a new one instead.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "baseline",
+		Script: `
+create table if not exists "%[1]v".git_server(
+	id serial primary key,
+	name text not null unique,
+	hostname text,
+	available boolean not null default true);
+
+create table if not exists "%[1]v".jenkins_slave(
+	id serial primary key,
+	name text not null unique);
+
+create table if not exists "%[1]v".job_provisioning(
+	id serial primary key,
+	name text not null,
+	scope text not null,
+	unique (name, scope));
+
+create table if not exists "%[1]v".codebase(
+	id serial primary key,
+	name text not null unique,
+	type text not null,
+	language text,
+	framework text,
+	build_tool text,
+	strategy text,
+	repository_url text,
+	route_site text,
+	route_path text,
+	database_kind text,
+	database_version text,
+	database_capacity text,
+	database_storage text,
+	status text,
+	test_report_framework text,
+	description text,
+	git_server_id integer references "%[1]v".git_server(id),
+	git_project_path text,
+	jenkins_slave_id integer references "%[1]v".jenkins_slave(id),
+	job_provisioning_id integer references "%[1]v".job_provisioning(id),
+	deployment_script text,
+	project_status text,
+	versioning_type text,
+	start_versioning_from text);
+
+create table if not exists "%[1]v".codebase_branch(
+	id serial primary key,
+	name text not null,
+	codebase_id integer not null references "%[1]v".codebase(id) on delete cascade,
+	from_commit text,
+	output_codebase_docker_stream_id integer,
+	status text,
+	version text,
+	build_number text,
+	last_success_build text,
+	release boolean not null default false);
+
+create table if not exists "%[1]v".codebase_docker_stream(
+	id serial primary key,
+	codebase_branch_id integer references "%[1]v".codebase_branch(id) on delete cascade,
+	oc_image_stream_name text not null);
+
+create table if not exists "%[1]v".action_log(
+	id serial primary key,
+	event text,
+	detailed_message text,
+	username text,
+	updated_at timestamp with time zone,
+	action text,
+	action_message text,
+	result text);
+
+create table if not exists "%[1]v".codebase_action_log(
+	codebase_id integer not null references "%[1]v".codebase(id) on delete cascade,
+	action_log_id integer not null references "%[1]v".action_log(id) on delete cascade);
+
+create table if not exists "%[1]v".third_party_service(
+	id serial primary key,
+	name text not null unique,
+	description text,
+	version text,
+	url text,
+	icon text);
+
+create table if not exists "%[1]v".cd_pipeline(
+	id serial primary key,
+	name text not null unique,
+	status text);
+
+create table if not exists "%[1]v".cd_pipeline_action_log(
+	cd_pipeline_id integer not null references "%[1]v".cd_pipeline(id) on delete cascade,
+	action_log_id integer not null references "%[1]v".action_log(id) on delete cascade);
+
+create table if not exists "%[1]v".cd_pipeline_third_party_service(
+	cd_pipeline_id integer not null references "%[1]v".cd_pipeline(id) on delete cascade,
+	third_party_service_id integer not null references "%[1]v".third_party_service(id));
+
+create table if not exists "%[1]v".cd_pipeline_docker_stream(
+	cd_pipeline_id integer not null references "%[1]v".cd_pipeline(id) on delete cascade,
+	codebase_docker_stream_id integer not null references "%[1]v".codebase_docker_stream(id) on delete cascade);
+
+create table if not exists "%[1]v".applications_to_promote(
+	cd_pipeline_id integer not null references "%[1]v".cd_pipeline(id) on delete cascade,
+	codebase_id integer not null references "%[1]v".codebase(id) on delete cascade);
+
+create table if not exists "%[1]v".cd_stage(
+	id serial primary key,
+	name text not null,
+	cd_pipeline_id integer not null references "%[1]v".cd_pipeline(id) on delete cascade,
+	description text,
+	trigger_type text,
+	"order" integer not null,
+	status text,
+	codebase_branch_id integer references "%[1]v".codebase_branch(id),
+	job_provisioning_id integer references "%[1]v".job_provisioning(id),
+	unique (cd_pipeline_id, name));
+
+create table if not exists "%[1]v".stage_codebase_docker_stream(
+	cd_stage_id integer not null references "%[1]v".cd_stage(id) on delete cascade,
+	input_codebase_docker_stream_id integer not null references "%[1]v".codebase_docker_stream(id) on delete cascade,
+	output_codebase_docker_stream_id integer not null references "%[1]v".codebase_docker_stream(id) on delete cascade);
+
+create table if not exists "%[1]v".quality_gate_stage(
+	id serial primary key,
+	quality_gate text not null,
+	step_name text not null,
+	cd_stage_id integer not null references "%[1]v".cd_stage(id) on delete cascade,
+	codebase_id integer references "%[1]v".codebase(id),
+	codebase_branch_id integer references "%[1]v".codebase_branch(id));
+
+create table if not exists "%[1]v".edp_component(
+	id serial primary key,
+	type text not null unique,
+	url text,
+	icon text,
+	visible boolean not null default true);`,
+	},
+	{
+		Version:     2,
+		Description: "jira server and commit validation",
+		Script: `
+create table if not exists "%[1]v".jira_server(
+	id serial primary key,
+	name text not null unique,
+	available boolean not null default true);
+
+alter table "%[1]v".codebase add column if not exists jira_server_id integer references "%[1]v".jira_server(id);
+alter table "%[1]v".codebase add column if not exists commit_message_pattern text;
+alter table "%[1]v".codebase add column if not exists ticket_name_pattern text;
+alter table "%[1]v".codebase add column if not exists ci_tool text not null default 'Jenkins';`,
+	},
+	{
+		Version:     3,
+		Description: "perf server and data sources",
+		Script: `
+create table if not exists "%[1]v".perf_server(
+	id serial primary key,
+	name text not null unique,
+	available boolean not null default true);
+
+create table if not exists "%[1]v".perf_data_sources(
+	id serial primary key,
+	type text not null unique);
+
+create table if not exists "%[1]v".codebase_perf_data_sources(
+	codebase_id integer not null references "%[1]v".codebase(id) on delete cascade,
+	data_source_id integer not null references "%[1]v".perf_data_sources(id) on delete cascade);
+
+alter table "%[1]v".codebase add column if not exists perf_server_id integer references "%[1]v".perf_server(id);`,
+	},
+	{
+		Version:     4,
+		Description: "codebase default branch",
+		Script: `
+alter table "%[1]v".codebase add column if not exists default_branch text not null default 'master';`,
+	},
+	{
+		Version:     5,
+		Description: "indexes and seed data",
+		Script: `
+create index if not exists codebase_branch_codebase_id_idx on "%[1]v".codebase_branch(codebase_id);
+create index if not exists codebase_docker_stream_branch_id_idx on "%[1]v".codebase_docker_stream(codebase_branch_id);
+create index if not exists codebase_action_log_codebase_id_idx on "%[1]v".codebase_action_log(codebase_id);
+create index if not exists cd_pipeline_action_log_cd_pipeline_id_idx on "%[1]v".cd_pipeline_action_log(cd_pipeline_id);
+create index if not exists cd_stage_cd_pipeline_id_idx on "%[1]v".cd_stage(cd_pipeline_id);
+
+insert into "%[1]v".job_provisioning(name, scope)
+	select 'default', s.scope from (values ('ci'), ('cd')) s(scope)
+	where not exists (select 1 from "%[1]v".job_provisioning jp where jp.name = 'default' and jp.scope = s.scope);
+
+insert into "%[1]v".perf_data_sources(type)
+	select t.type from (values ('JENKINS'), ('SONAR'), ('GITLAB')) t(type)
+	where not exists (select 1 from "%[1]v".perf_data_sources pds where pds.type = t.type);`,
+	},
+	{
+		Version:     6,
+		Description: "idempotent action log",
+		Script: `
+alter table "%[1]v".action_log add column if not exists fingerprint text;
+
+update "%[1]v".action_log al
+	set fingerprint = md5(concat_ws('|', 'codebase', cal.codebase_id, extract(epoch from al.updated_at),
+		al.action, al.result, al.username, al.detailed_message, al.action_message))
+	from "%[1]v".codebase_action_log cal
+	where cal.action_log_id = al.id and al.fingerprint is null;
+
+update "%[1]v".action_log al
+	set fingerprint = md5(concat_ws('|', 'cd_pipeline', cpal.cd_pipeline_id, extract(epoch from al.updated_at),
+		al.action, al.result, al.username, al.detailed_message, al.action_message))
+	from "%[1]v".cd_pipeline_action_log cpal
+	where cpal.action_log_id = al.id and al.fingerprint is null;
+
+delete from "%[1]v".action_log al
+	using "%[1]v".action_log dup
+	where al.fingerprint = dup.fingerprint and al.id > dup.id;
+
+delete from "%[1]v".codebase_action_log cal
+	using "%[1]v".codebase_action_log dup
+	where cal.codebase_id = dup.codebase_id and cal.action_log_id = dup.action_log_id and cal.ctid > dup.ctid;
+
+delete from "%[1]v".cd_pipeline_action_log cpal
+	using "%[1]v".cd_pipeline_action_log dup
+	where cpal.cd_pipeline_id = dup.cd_pipeline_id and cpal.action_log_id = dup.action_log_id and cpal.ctid > dup.ctid;
+
+create unique index if not exists action_log_fingerprint_idx on "%[1]v".action_log(fingerprint);
+create unique index if not exists codebase_action_log_uniq_idx on "%[1]v".codebase_action_log(codebase_id, action_log_id);
+create unique index if not exists cd_pipeline_action_log_uniq_idx on "%[1]v".cd_pipeline_action_log(cd_pipeline_id, action_log_id);`,
+	},
+	{
+		Version:     7,
+		Description: "cd stage action log",
+		Script: `
+create table if not exists "%[1]v".cd_stage_action_log(
+	cd_stage_id integer not null references "%[1]v".cd_stage(id) on delete cascade,
+	action_log_id integer not null references "%[1]v".action_log(id) on delete cascade,
+	unique (cd_stage_id, action_log_id));`,
+	},
+	{
+		Version:     8,
+		Description: "codebase branch action log",
+		Script: `
+create table if not exists "%[1]v".codebase_branch_action_log(
+	codebase_branch_id integer not null references "%[1]v".codebase_branch(id) on delete cascade,
+	action_log_id integer not null references "%[1]v".action_log(id) on delete cascade,
+	unique (codebase_branch_id, action_log_id));`,
+	},
+	{
+		Version:     9,
+		Description: "action log retention",
+		Script: `
+create index if not exists action_log_updated_at_idx on "%[1]v".action_log(updated_at);
+
+create table if not exists "%[1]v".action_log_archive(
+	id integer primary key,
+	entity_kind text,
+	entity_id integer,
+	detailed_message text,
+	username text,
+	updated_at timestamp with time zone,
+	action text,
+	action_message text,
+	result text,
+	archived_at timestamp with time zone not null default now());`,
+	},
+	{
+		Version:     10,
+		Description: "outbox",
+		Script: `
+create table if not exists "%[1]v".outbox(
+	id serial primary key,
+	kind text not null,
+	operation text not null,
+	entity_id integer,
+	subject text not null default '',
+	data jsonb,
+	created_at timestamp with time zone not null default now());`,
+	},
+	{
+		Version:     11,
+		Description: "codebase change log",
+		Script: `
+create table if not exists "%[1]v".codebase_change_log(
+	id serial primary key,
+	codebase_id integer not null references "%[1]v".codebase(id) on delete cascade,
+	field text not null,
+	old_value text,
+	new_value text,
+	changed_at timestamp with time zone not null default now());
+
+create index if not exists codebase_change_log_codebase_id_idx on "%[1]v".codebase_change_log(codebase_id);`,
+	},
+	{
+		Version:     12,
+		Description: "codebase repository history",
+		Script: `
+create table if not exists "%[1]v".codebase_repository_history(
+	id serial primary key,
+	codebase_id integer not null references "%[1]v".codebase(id) on delete cascade,
+	git_server_id integer references "%[1]v".git_server(id),
+	git_project_path text,
+	moved_at timestamp with time zone not null default now());
+
+create index if not exists codebase_repository_history_codebase_id_idx on "%[1]v".codebase_repository_history(codebase_id);`,
+	},
+	{
+		Version:     13,
+		Description: "perf data source config",
+		Script: `
+alter table "%[1]v".codebase_perf_data_sources add column if not exists name text;
+alter table "%[1]v".codebase_perf_data_sources add column if not exists perf_server text;
+alter table "%[1]v".codebase_perf_data_sources add column if not exists url text;
+alter table "%[1]v".codebase_perf_data_sources add column if not exists config jsonb;
+alter table "%[1]v".codebase_perf_data_sources add column if not exists updated_at timestamp with time zone;`,
+	},
+	{
+		Version:     14,
+		Description: "codebase branch build history",
+		Script: `
+alter table "%[1]v".codebase_branch add column if not exists last_success_build_at timestamp with time zone;
+
+create table if not exists "%[1]v".codebase_branch_build_history(
+	id serial primary key,
+	codebase_branch_id integer not null references "%[1]v".codebase_branch(id) on delete cascade,
+	version text,
+	build_number text,
+	created_at timestamp with time zone not null default now());
+
+create index if not exists codebase_branch_build_history_branch_id_idx on "%[1]v".codebase_branch_build_history(codebase_branch_id);`,
+	},
+	{
+		Version:     15,
+		Description: "action log fingerprint without action message",
+		Script: `
+drop index if exists "%[1]v".action_log_fingerprint_idx;
+
+update "%[1]v".action_log al
+	set fingerprint = md5(concat_ws('|', 'codebase', cal.codebase_id, extract(epoch from al.updated_at),
+		al.action, al.result, al.username, al.detailed_message))
+	from "%[1]v".codebase_action_log cal
+	where cal.action_log_id = al.id;
+
+update "%[1]v".action_log al
+	set fingerprint = md5(concat_ws('|', 'codebase_branch', cbal.codebase_branch_id, extract(epoch from al.updated_at),
+		al.action, al.result, al.username, al.detailed_message))
+	from "%[1]v".codebase_branch_action_log cbal
+	where cbal.action_log_id = al.id;
+
+update "%[1]v".action_log al
+	set fingerprint = md5(concat_ws('|', 'cd_pipeline', cpal.cd_pipeline_id, extract(epoch from al.updated_at),
+		al.action, al.result, al.username, al.detailed_message))
+	from "%[1]v".cd_pipeline_action_log cpal
+	where cpal.action_log_id = al.id;
+
+update "%[1]v".action_log al
+	set fingerprint = md5(concat_ws('|', 'cd_stage', csal.cd_stage_id, extract(epoch from al.updated_at),
+		al.action, al.result, al.username, al.detailed_message))
+	from "%[1]v".cd_stage_action_log csal
+	where csal.action_log_id = al.id;
+
+delete from "%[1]v".codebase_action_log cal
+	using "%[1]v".action_log al, "%[1]v".action_log dup
+	where cal.action_log_id = al.id and al.fingerprint = dup.fingerprint and al.id > dup.id;
+
+delete from "%[1]v".codebase_branch_action_log cbal
+	using "%[1]v".action_log al, "%[1]v".action_log dup
+	where cbal.action_log_id = al.id and al.fingerprint = dup.fingerprint and al.id > dup.id;
+
+delete from "%[1]v".cd_pipeline_action_log cpal
+	using "%[1]v".action_log al, "%[1]v".action_log dup
+	where cpal.action_log_id = al.id and al.fingerprint = dup.fingerprint and al.id > dup.id;
+
+delete from "%[1]v".cd_stage_action_log csal
+	using "%[1]v".action_log al, "%[1]v".action_log dup
+	where csal.action_log_id = al.id and al.fingerprint = dup.fingerprint and al.id > dup.id;
+
+delete from "%[1]v".action_log al
+	using "%[1]v".action_log dup
+	where al.fingerprint = dup.fingerprint and al.id > dup.id;
+
+create unique index if not exists action_log_fingerprint_idx on "%[1]v".action_log(fingerprint);`,
+	},
+}
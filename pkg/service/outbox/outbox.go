@@ -0,0 +1,191 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/epmd-edp/reconciler/v2/pkg/repository/outbox"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultBatchSize is number of events delivered in a single transaction.
+	DefaultBatchSize = 100
+	// DefaultSource is the source of events unless another one is configured.
+	DefaultSource = "/reconciler"
+	// TypePrefix precedes entity kind and operation in type of events,
+	// e.g. "com.epam.edp.codebase.created".
+	TypePrefix = "com.epam.edp."
+
+	contentType = "application/cloudevents+json; charset=UTF-8"
+)
+
+// CloudEvent is an event in structured JSON format of CloudEvents 1.0.
+// Tenant is an extension attribute holding the schema the entity belongs to.
+type CloudEvent struct {
+	SpecVersion     string      `json:"specversion"`
+	Id              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            time.Time   `json:"time"`
+	DataContentType string      `json:"datacontenttype"`
+	Tenant          string      `json:"tenant"`
+	Data            interface{} `json:"data"`
+}
+
+// NewCloudEvent converts the event of the tenant. Id is unique within
+// the source, so redelivered event may be recognized by consumers.
+func NewCloudEvent(e model.Event, source, tenant string) CloudEvent {
+	data := make(map[string]interface{}, len(e.Data)+1)
+	for k, v := range e.Data {
+		data[k] = v
+	}
+	if e.EntityId != nil {
+		data["id"] = *e.EntityId
+	}
+	return CloudEvent{
+		SpecVersion:     "1.0",
+		Id:              tenant + "-" + strconv.Itoa(e.Id),
+		Source:          source,
+		Type:            TypePrefix + e.Kind + "." + e.Operation,
+		Subject:         e.Subject,
+		Time:            e.CreatedAt.UTC(),
+		DataContentType: "application/json",
+		Tenant:          tenant,
+		Data:            data,
+	}
+}
+
+// Sink delivers events. An event is considered delivered once Send returns
+// no error.
+type Sink interface {
+	Send(e CloudEvent) error
+}
+
+// HTTPSink posts every event to the webhook in structured mode and expects
+// 2xx status in response.
+type HTTPSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s HTTPSink) Send(e CloudEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	c := s.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Post(s.URL, contentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook has responded with status %v", resp.Status)
+	}
+	return nil
+}
+
+// FileSink appends events to the file as JSON lines. It is intended for
+// local testing.
+type FileSink struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (s *FileSink) Send(e CloudEvent) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+type PublisherService struct {
+	DB     *sql.DB
+	Sink   Sink
+	Source string
+}
+
+// Publish delivers pending events of the tenant in order they have been
+// recorded and returns number of delivered ones. Events are removed from
+// the outbox after delivery, so an event is delivered again if it can't be
+// removed. Delivery stops at the first failed event.
+func (s PublisherService) Publish(schemaName string, batch int) (int, error) {
+	if batch <= 0 {
+		batch = DefaultBatchSize
+	}
+	published := 0
+	for {
+		n, err := s.publishBatch(schemaName, batch)
+		published += n
+		if err != nil {
+			return published, err
+		}
+		if n < batch {
+			return published, nil
+		}
+	}
+}
+
+func (s PublisherService) publishBatch(schemaName string, batch int) (int, error) {
+	var delivered []int
+	var sendErr error
+	err := postgres.WithTx(context.TODO(), s.DB, func(txn *sql.Tx) error {
+		events, err := outbox.SelectPending(*txn, batch, schemaName)
+		if err != nil {
+			return errors.Wrap(err, "an error has occurred while selecting pending events")
+		}
+
+		source := s.Source
+		if source == "" {
+			source = DefaultSource
+		}
+		for _, e := range events {
+			if sendErr = s.Sink.Send(NewCloudEvent(e, source, schemaName)); sendErr != nil {
+				sendErr = errors.Wrapf(sendErr, "couldn't deliver event %v", e.Id)
+				break
+			}
+			delivered = append(delivered, e.Id)
+		}
+		if len(delivered) == 0 {
+			return sendErr
+		}
+
+		if err := outbox.DeleteEvents(*txn, delivered, schemaName); err != nil {
+			return errors.Wrap(err, "an error has occurred while deleting delivered events")
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(delivered), sendErr
+}
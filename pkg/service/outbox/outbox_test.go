@@ -0,0 +1,129 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var pendingColumns = []string{"id", "kind", "operation", "entity_id", "subject", "data", "created_at"}
+
+type fakeSink struct {
+	events []CloudEvent
+	failOn string
+}
+
+func (s *fakeSink) Send(e CloudEvent) error {
+	if e.Id == s.failOn {
+		return errors.New("fake error")
+	}
+	s.events = append(s.events, e)
+	return nil
+}
+
+func TestPublish_ShouldDeleteDeliveredEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select .+ from "fake-schema".outbox`).ExpectQuery().
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(pendingColumns).
+			AddRow(1, "codebase", "created", 5, "fake-app", `{"status":"created"}`, now).
+			AddRow(2, "codebase", "deleted", nil, "fake-app", `null`, now))
+	mock.ExpectPrepare(`delete from "fake-schema".outbox`).ExpectExec().
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select .+ from "fake-schema".outbox`).ExpectQuery().
+		WillReturnRows(sqlmock.NewRows(pendingColumns))
+	mock.ExpectCommit()
+
+	sink := &fakeSink{}
+	n, err := PublisherService{DB: db, Sink: sink}.Publish("fake-schema", 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	if assert.Len(t, sink.events, 2) {
+		e := sink.events[0]
+		assert.Equal(t, "fake-schema-1", e.Id)
+		assert.Equal(t, DefaultSource, e.Source)
+		assert.Equal(t, "com.epam.edp.codebase.created", e.Type)
+		assert.Equal(t, "fake-app", e.Subject)
+		assert.Equal(t, map[string]interface{}{"status": "created", "id": 5}, e.Data)
+	}
+}
+
+func TestPublish_ShouldKeepUndeliveredEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select .+ from "fake-schema".outbox`).ExpectQuery().
+		WillReturnRows(sqlmock.NewRows(pendingColumns).
+			AddRow(1, "cd_stage", "updated", 3, "fake-pipeline/sit", `{}`, now).
+			AddRow(2, "cd_stage", "updated", 3, "fake-pipeline/sit", `{}`, now).
+			AddRow(3, "cd_stage", "updated", 3, "fake-pipeline/sit", `{}`, now))
+	mock.ExpectPrepare(`delete from "fake-schema".outbox`).ExpectExec().
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	sink := &fakeSink{failOn: "fake-schema-2"}
+	n, err := PublisherService{DB: db, Sink: sink}.Publish("fake-schema", 10)
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, sink.events, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHTTPSink_ShouldPostStructuredCloudEvent(t *testing.T) {
+	var contentType string
+	var received CloudEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	id := 5
+	e := NewCloudEvent(model.Event{Id: 1, Kind: model.CodebaseEvent, Operation: model.Created, EntityId: &id,
+		Subject: "fake-app", CreatedAt: time.Now()}, "/fake-source", "fake-schema")
+	assert.NoError(t, HTTPSink{URL: srv.URL}.Send(e))
+
+	assert.Equal(t, "application/cloudevents+json; charset=UTF-8", contentType)
+	assert.Equal(t, "1.0", received.SpecVersion)
+	assert.Equal(t, "fake-schema-1", received.Id)
+	assert.Equal(t, "/fake-source", received.Source)
+	assert.Equal(t, "fake-schema", received.Tenant)
+}
+
+func TestHTTPSink_ShouldFailOnErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	assert.Error(t, HTTPSink{URL: srv.URL}.Send(CloudEvent{}))
+}
@@ -1,90 +1,79 @@
 package perfdatasource
 
 import (
-	"database/sql"
-	"github.com/epmd-edp/reconciler/v2/pkg/model/codebase"
-	"github.com/epmd-edp/reconciler/v2/pkg/repository/perfdatasource"
-	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"context"
+	"fmt"
 	"strings"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/perfdatasource"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
+	"github.com/pkg/errors"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 )
 
 type PerfDataSourceService struct {
-	DB *sql.DB
+	Storage storage.Storage
 }
 
 var log = logf.Log.WithName("perf-data-source-service")
 
-func (s PerfDataSourceService) perfDataSourceExists(dsType, tenant string) (bool, error) {
-	log.Info("checking for existence record data source", "type", dsType)
-	txn, err := s.DB.Begin()
-	if err != nil {
-		return false, err
-	}
-
-	exists, err := perfdatasource.PerfDataSourceExists(*txn, strings.ToUpper(dsType), tenant)
-	if err != nil {
-		return false, err
-	}
-
-	if err := txn.Commit(); err != nil {
-		return false, err
-	}
-
-	return exists, nil
-}
-
-func (s PerfDataSourceService) InsertPerfDataSources(perf *codebase.Perf, tenant string) error {
-	if perf == nil {
-		return nil
-	}
-	log.Info("start inserting data source records")
-
-	txn, err := s.DB.Begin()
-	if err != nil {
-		return err
-	}
-
-	for _, ds := range perf.DataSources {
-		exists, err := s.perfDataSourceExists(ds, tenant)
+// PutPerfDataSource records configuration of the data source. Codebase owns
+// its links to data sources, so the data source is expected to be declared
+// by the codebase and projected already.
+func (s PerfDataSourceService) PutPerfDataSource(ds perfdatasource.PerfDataSource, tenant string) error {
+	rLog := log.WithValues("codebase", ds.Codebase, "data source", ds.Type)
+	rLog.Info("start putting codebase_perf_data_source record")
+	err := storage.WithTx(context.TODO(), s.Storage, func(txn storage.Tx) error {
+		id, err := s.Storage.Codebase().GetId(txn, ds.Codebase, tenant)
 		if err != nil {
-			return err
+			return errors.Wrapf(err, "an error has occurred while fetching codebase %v", ds.Codebase)
 		}
-
-		if exists {
-			log.Info("data source already exists. skip creating", "type", ds)
-			continue
+		if id == nil {
+			return backoff.AsDependency(fmt.Errorf("%v codebase record has not been found", ds.Codebase))
 		}
-
-		if err := perfdatasource.InsertPerfDataSource(*txn, strings.ToUpper(ds), tenant); err != nil {
-			_ = txn.Rollback()
-			return err
+		types, err := s.Storage.Codebase().GetPerfDataSources(txn, *id, tenant)
+		if err != nil {
+			return errors.Wrapf(err, "an error has occurred while fetching data sources of codebase %v", ds.Codebase)
 		}
-		log.Info("data sources has been added to table", "type", ds)
-	}
-
-	if err := txn.Commit(); err != nil {
+		if !contains(types, ds.Type) {
+			return backoff.AsDependency(fmt.Errorf("codebase %v doesn't declare %v data source", ds.Codebase, ds.Type))
+		}
+		if err := s.Storage.Codebase().UpdatePerfDataSource(txn, *id, ds, tenant); err != nil {
+			return errors.Wrapf(err, "an error has occurred while putting %v data source of codebase %v",
+				ds.Type, ds.Codebase)
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
-
+	rLog.Info("codebase_perf_data_source record has been put")
 	return nil
 }
 
 func (s PerfDataSourceService) RemoveCodebaseDataSource(codebase, dataSource, tenant string) error {
 	rLog := log.WithValues("codebase", codebase, "data source", dataSource)
 	rLog.Info("removing codebase_perf_data_source record")
-	txn, err := s.DB.Begin()
+	err := storage.WithTx(context.TODO(), s.Storage, func(txn storage.Tx) error {
+		id, err := s.Storage.Codebase().GetId(txn, codebase, tenant)
+		if err != nil || id == nil {
+			return err
+		}
+		return s.Storage.Codebase().RemovePerfDataSource(txn, *id, strings.ToUpper(dataSource), tenant)
+	})
 	if err != nil {
 		return err
 	}
-
-	if err := perfdatasource.RemoveCodebaseDataSource(*txn, codebase, dataSource, tenant); err != nil {
-		_ = txn.Rollback()
-		return err
-	}
-
-	if err := txn.Commit(); err != nil {
-		return err
-	}
 	rLog.Info("codebase_perf_data_source record has been removed")
 	return nil
 }
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package perfdatasource
+
+import (
+	"testing"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebase"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/perfdatasource"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+const schema = "fake-schema"
+
+func createCodebase(t *testing.T, store *memory.Storage, name string) int {
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	id, err := store.Codebase().Create(tx, codebase.Codebase{Name: name, Tenant: schema}, schema)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	return *id
+}
+
+func declareDataSource(t *testing.T, store *memory.Storage, id int, dsType string) {
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, store.Codebase().AddPerfDataSource(tx, id, dsType, schema))
+	assert.NoError(t, tx.Commit())
+}
+
+func linkedDataSources(t *testing.T, store *memory.Storage, id int) []string {
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	defer tx.Commit()
+	types, err := store.Codebase().GetPerfDataSources(tx, id, schema)
+	assert.NoError(t, err)
+	return types
+}
+
+func TestPutPerfDataSource_ShouldRecordConfigOnCreateAndUpdate(t *testing.T) {
+	store := memory.New()
+	id := createCodebase(t, store, "fake-app")
+	declareDataSource(t, store, id, "GITLAB")
+	s := PerfDataSourceService{Storage: store}
+	ds := perfdatasource.PerfDataSource{
+		Name:       "fake-app-gitlab",
+		Type:       "GITLAB",
+		Codebase:   "fake-app",
+		PerfServer: "epam-perf",
+		Url:        "https://gitlab.example.com",
+		Config:     perfdatasource.Config{Repositories: []string{"group/fake-app"}, Branches: []string{"master"}},
+	}
+
+	assert.NoError(t, s.PutPerfDataSource(ds, schema))
+	assert.Equal(t, []string{"GITLAB"}, linkedDataSources(t, store, id))
+	assert.Equal(t, &ds, store.PerfDataSource(schema, "fake-app", "GITLAB"))
+
+	ds.Config.Branches = []string{"master", "develop"}
+	assert.NoError(t, s.PutPerfDataSource(ds, schema))
+	assert.Equal(t, []string{"GITLAB"}, linkedDataSources(t, store, id))
+	assert.Equal(t, &ds, store.PerfDataSource(schema, "fake-app", "GITLAB"))
+
+	assert.NoError(t, s.RemoveCodebaseDataSource("fake-app", "GitLab", schema))
+	assert.Empty(t, linkedDataSources(t, store, id))
+	assert.Nil(t, store.PerfDataSource(schema, "fake-app", "GITLAB"))
+}
+
+func TestPutPerfDataSource_ShouldWaitForCodebase(t *testing.T) {
+	s := PerfDataSourceService{Storage: memory.New()}
+
+	err := s.PutPerfDataSource(perfdatasource.PerfDataSource{Type: "SONAR", Codebase: "fake-app"}, schema)
+	assert.Error(t, err)
+	assert.Equal(t, backoff.Dependency, backoff.Classify(err))
+}
+
+func TestPutPerfDataSource_ShouldNotLinkDataSourceCodebaseDoesNotDeclare(t *testing.T) {
+	store := memory.New()
+	id := createCodebase(t, store, "fake-app")
+	declareDataSource(t, store, id, "JENKINS")
+	s := PerfDataSourceService{Storage: store}
+
+	err := s.PutPerfDataSource(perfdatasource.PerfDataSource{Type: "SONAR", Codebase: "fake-app"}, schema)
+	assert.Error(t, err)
+	assert.Equal(t, backoff.Dependency, backoff.Classify(err))
+	assert.Equal(t, []string{"JENKINS"}, linkedDataSources(t, store, id))
+	assert.Nil(t, store.PerfDataSource(schema, "fake-app", "SONAR"))
+}
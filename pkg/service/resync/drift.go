@@ -0,0 +1,87 @@
+package resync
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/repository/resync"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
+	"github.com/pkg/errors"
+)
+
+// Mismatch is a field which value in DB differs from custom resource.
+type Mismatch struct {
+	Key      string `json:"key"`
+	Field    string `json:"field"`
+	Database string `json:"database"`
+	Cluster  string `json:"cluster"`
+}
+
+// DriftReport describes difference between DB projection of a kind and
+// custom resources of the kind.
+type DriftReport struct {
+	Tenant     string     `json:"tenant"`
+	Kind       string     `json:"kind"`
+	Missing    []string   `json:"missing"`
+	Orphaned   []string   `json:"orphaned"`
+	Mismatches []Mismatch `json:"mismatches"`
+	// Collected are orphaned rows which have been removed.
+	Collected []string `json:"collected,omitempty"`
+}
+
+// GetProjectedRows returns rows of tenant schema keyed by the first selected column.
+func (s ResyncService) GetProjectedRows(query, schemaName string) (map[string][]string, error) {
+	var rows map[string][]string
+	err := postgres.WithTx(context.TODO(), s.DB, func(txn *sql.Tx) error {
+		var err error
+		rows, err = resync.SelectRows(*txn, query, schemaName)
+		if err != nil {
+			return errors.Wrap(err, "an error has occurred while selecting projected rows")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Compare builds drift report of a kind. Values of cluster and DB rows must
+// be in the same order as field names.
+func Compare(kind string, fields []string, cluster, db map[string][]string) DriftReport {
+	r := DriftReport{
+		Kind:       kind,
+		Missing:    []string{},
+		Orphaned:   []string{},
+		Mismatches: []Mismatch{},
+	}
+
+	for k, cv := range cluster {
+		dv, ok := db[k]
+		if !ok {
+			r.Missing = append(r.Missing, k)
+			continue
+		}
+		for i, f := range fields {
+			if i < len(cv) && i < len(dv) && cv[i] != dv[i] {
+				r.Mismatches = append(r.Mismatches, Mismatch{Key: k, Field: f, Database: dv[i], Cluster: cv[i]})
+			}
+		}
+	}
+	for k := range db {
+		if _, ok := cluster[k]; !ok {
+			r.Orphaned = append(r.Orphaned, k)
+		}
+	}
+
+	sort.Strings(r.Missing)
+	sort.Strings(r.Orphaned)
+	sort.Slice(r.Mismatches, func(i, j int) bool {
+		if r.Mismatches[i].Key != r.Mismatches[j].Key {
+			return r.Mismatches[i].Key < r.Mismatches[j].Key
+		}
+		return r.Mismatches[i].Field < r.Mismatches[j].Field
+	})
+	return r
+}
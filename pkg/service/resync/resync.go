@@ -0,0 +1,73 @@
+package resync
+
+import (
+	"context"
+	"database/sql"
+	"github.com/epmd-edp/reconciler/v2/pkg/repository/resync"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// Report describes difference between DB projection of a kind and
+// custom resources found in cluster.
+type Report struct {
+	Kind string
+	// Created are resources which have no rows in DB yet.
+	Created []string
+	// Existing are resources which already have rows. They are resynced
+	// regardless of whether projected fields differ.
+	Existing []string
+	// Orphaned are rows which have no resource in cluster.
+	Orphaned []string
+}
+
+type ResyncService struct {
+	DB *sql.DB
+}
+
+// GetProjectedKeys returns keys of all rows the query selects from tenant schema.
+func (s ResyncService) GetProjectedKeys(query, schemaName string) ([]string, error) {
+	var keys []string
+	err := postgres.WithTx(context.TODO(), s.DB, func(txn *sql.Tx) error {
+		var err error
+		keys, err = resync.SelectKeys(*txn, query, schemaName)
+		if err != nil {
+			return errors.Wrap(err, "an error has occurred while selecting projected keys")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Diff compares keys of custom resources with keys stored in DB.
+func Diff(kind string, crKeys, dbKeys []string) Report {
+	inDB := make(map[string]bool, len(dbKeys))
+	for _, k := range dbKeys {
+		inDB[k] = true
+	}
+
+	r := Report{Kind: kind}
+	inCluster := make(map[string]bool, len(crKeys))
+	for _, k := range crKeys {
+		inCluster[k] = true
+		if inDB[k] {
+			r.Existing = append(r.Existing, k)
+		} else {
+			r.Created = append(r.Created, k)
+		}
+	}
+	for _, k := range dbKeys {
+		if !inCluster[k] {
+			r.Orphaned = append(r.Orphaned, k)
+		}
+	}
+
+	sort.Strings(r.Created)
+	sort.Strings(r.Existing)
+	sort.Strings(r.Orphaned)
+	return r
+}
@@ -0,0 +1,53 @@
+package resync
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/epmd-edp/reconciler/v2/pkg/repository/resync"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiff_ShouldSplitKeys(t *testing.T) {
+	r := Diff("Codebase", []string{"b", "a", "c"}, []string{"c", "d", "a"})
+
+	assert.Equal(t, "Codebase", r.Kind)
+	assert.Equal(t, []string{"b"}, r.Created)
+	assert.Equal(t, []string{"a", "c"}, r.Existing)
+	assert.Equal(t, []string{"d"}, r.Orphaned)
+}
+
+func TestGetProjectedKeys_ShouldReturnKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select c.name \|\| '/' \|\| cb.name from "fake-schema".codebase_branch`).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("app/master").AddRow("app/release"))
+	mock.ExpectCommit()
+
+	keys, err := ResyncService{DB: db}.GetProjectedKeys(resync.SelectCodebaseBranchKeys, "fake-schema")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app/master", "app/release"}, keys)
+}
+
+func TestCompare_ShouldReportDrift(t *testing.T) {
+	cluster := map[string][]string{
+		"app":  {"active", "[A-Z]+"},
+		"lib":  {"active", ""},
+		"test": {"active", ""},
+	}
+	db := map[string][]string{
+		"app":    {"active", ""},
+		"lib":    {"active", ""},
+		"legacy": {"failed", ""},
+	}
+
+	r := Compare("Codebase", []string{"status", "commit_message_pattern"}, cluster, db)
+
+	assert.Equal(t, []string{"test"}, r.Missing)
+	assert.Equal(t, []string{"legacy"}, r.Orphaned)
+	assert.Equal(t, []Mismatch{{Key: "app", Field: "commit_message_pattern", Database: "", Cluster: "[A-Z]+"}}, r.Mismatches)
+}
@@ -0,0 +1,149 @@
+package retention
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/repository/retention"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
+	"github.com/pkg/errors"
+)
+
+// DefaultBatchSize is number of action logs pruned in a single transaction.
+const DefaultBatchSize = 1000
+
+// Policy defines action logs to be pruned. Action logs which don't belong
+// to any entity anymore are pruned regardless of the policy.
+type Policy struct {
+	// KeepLast is number of the latest action logs kept per entity, zero keeps all.
+	KeepLast int
+	// MaxAge prunes action logs updated earlier, zero keeps all.
+	MaxAge    time.Duration
+	BatchSize int
+}
+
+// Archiver keeps action logs before they are deleted. Archive is called
+// within the transaction deleting the action logs.
+type Archiver interface {
+	Archive(txn *sql.Tx, logs []retention.ActionLog, schemaName string) error
+}
+
+// TableArchiver copies action logs into action_log_archive table of the tenant.
+type TableArchiver struct{}
+
+func (TableArchiver) Archive(txn *sql.Tx, logs []retention.ActionLog, schemaName string) error {
+	return retention.InsertArchive(*txn, logs, schemaName)
+}
+
+// FileArchiver writes action logs as gzip compressed JSON lines, a file per
+// batch. A file is complete once it has the final name, but it may contain
+// action logs which haven't been deleted if the transaction fails after.
+type FileArchiver struct {
+	Dir string
+}
+
+func (a FileArchiver) Archive(_ *sql.Tx, logs []retention.ActionLog, schemaName string) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	name := fmt.Sprintf("%v-action-log-%v-%v.jsonl.gz", schemaName, time.Now().UTC().Format("20060102T150405"), logs[0].Id)
+
+	f, err := ioutil.TempFile(a.Dir, name+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "couldn't create archive file")
+	}
+	defer os.Remove(f.Name())
+
+	zw := gzip.NewWriter(f)
+	enc := json.NewEncoder(zw)
+	for _, l := range logs {
+		if err := enc.Encode(l); err != nil {
+			_ = f.Close()
+			return errors.Wrap(err, "couldn't write archive file")
+		}
+	}
+	if err := zw.Close(); err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, "couldn't write archive file")
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, "couldn't write archive file")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "couldn't write archive file")
+	}
+	return os.Rename(f.Name(), filepath.Join(a.Dir, name))
+}
+
+type RetentionService struct {
+	DB *sql.DB
+	// Archiver is optional, pruned action logs are dropped without it.
+	Archiver Archiver
+}
+
+// Prune deletes action logs of the tenant which are out of the policy in
+// batches and returns number of pruned ones. Batches pruned before a failure
+// stay deleted.
+func (s RetentionService) Prune(schemaName string, p Policy, now time.Time) (int, error) {
+	var cutoff time.Time
+	if p.MaxAge > 0 {
+		cutoff = now.Add(-p.MaxAge)
+	}
+	batch := p.BatchSize
+	if batch <= 0 {
+		batch = DefaultBatchSize
+	}
+
+	pruned := 0
+	for {
+		n, err := s.pruneBatch(schemaName, p.KeepLast, cutoff, batch)
+		pruned += n
+		if err != nil {
+			return pruned, err
+		}
+		if n < batch {
+			return pruned, nil
+		}
+	}
+}
+
+func (s RetentionService) pruneBatch(schemaName string, keepLast int, cutoff time.Time, batch int) (int, error) {
+	pruned := 0
+	err := postgres.WithTx(context.TODO(), s.DB, func(txn *sql.Tx) error {
+		logs, err := retention.SelectExpired(*txn, keepLast, cutoff, batch, schemaName)
+		if err != nil {
+			return errors.Wrap(err, "an error has occurred while selecting expired action logs")
+		}
+		if len(logs) == 0 {
+			return nil
+		}
+
+		if s.Archiver != nil {
+			if err := s.Archiver.Archive(txn, logs, schemaName); err != nil {
+				return errors.Wrap(err, "an error has occurred while archiving action logs")
+			}
+		}
+
+		ids := make([]int, len(logs))
+		for i, l := range logs {
+			ids[i] = l.Id
+		}
+		if err := retention.DeleteActionLogs(*txn, ids, schemaName); err != nil {
+			return errors.Wrap(err, "an error has occurred while deleting action logs")
+		}
+		pruned = len(logs)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return pruned, nil
+}
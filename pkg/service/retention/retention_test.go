@@ -0,0 +1,95 @@
+package retention
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/epmd-edp/reconciler/v2/pkg/repository/retention"
+	"github.com/stretchr/testify/assert"
+)
+
+var expiredColumns = []string{"id", "kind", "entity_id", "detailed_message", "username", "updated_at",
+	"action", "action_message", "result"}
+
+func TestPrune_ShouldArchiveAndDeleteExpiredActionLogsInBatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	updatedAt := now.Add(-48 * time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`with linked as`).ExpectQuery().
+		WithArgs(10, now.Add(-24*time.Hour), 2).
+		WillReturnRows(sqlmock.NewRows(expiredColumns).
+			AddRow(1, "codebase", 5, "", "user", updatedAt, "codebase_registration", "", "success").
+			AddRow(2, "", nil, "", "user", updatedAt, "codebase_registration", "", "success"))
+	mock.ExpectPrepare(`insert into "fake-schema".action_log_archive`).ExpectExec().
+		WithArgs(1, "codebase", 5, "", "user", updatedAt, "codebase_registration", "", "success").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`insert into "fake-schema".action_log_archive`).
+		WithArgs(2, nil, nil, "", "user", updatedAt, "codebase_registration", "", "success").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, table := range []string{"codebase_action_log", "codebase_branch_action_log",
+		"cd_pipeline_action_log", "cd_stage_action_log"} {
+		mock.ExpectPrepare(`delete from "fake-schema".` + table + ` where action_log_id = any`).ExpectExec().
+			WithArgs(sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectPrepare(`delete from "fake-schema".action_log where id = any`).ExpectExec().
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`with linked as`).ExpectQuery().
+		WillReturnRows(sqlmock.NewRows(expiredColumns))
+	mock.ExpectCommit()
+
+	s := RetentionService{DB: db, Archiver: TableArchiver{}}
+	n, err := s.Prune("fake-schema", Policy{KeepLast: 10, MaxAge: 24 * time.Hour, BatchSize: 2}, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFileArchiver_ShouldWriteCompressedJSONLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logs := []retention.ActionLog{{Id: 1, EntityKind: "codebase", Action: "codebase_registration"}, {Id: 2}}
+	assert.NoError(t, FileArchiver{Dir: dir}.Archive(nil, logs, "fake-schema"))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	assert.NoError(t, err)
+	if !assert.Len(t, files, 1) {
+		return
+	}
+	assert.Regexp(t, `fake-schema-action-log-\d{8}T\d{6}-1\.jsonl\.gz$`, files[0])
+
+	f, err := os.Open(files[0])
+	assert.NoError(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+
+	var archived []retention.ActionLog
+	sc := bufio.NewScanner(zr)
+	for sc.Scan() {
+		var l retention.ActionLog
+		assert.NoError(t, json.Unmarshal(sc.Bytes(), &l))
+		archived = append(archived, l)
+	}
+	assert.Equal(t, logs, archived)
+}
@@ -1,15 +1,14 @@
 package stage
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"github.com/epmd-edp/cd-pipeline-operator/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epmd-edp/reconciler/v2/pkg/backoff"
 	"github.com/epmd-edp/reconciler/v2/pkg/model"
 	"github.com/epmd-edp/reconciler/v2/pkg/model/stage"
 	"github.com/epmd-edp/reconciler/v2/pkg/platform"
-	"github.com/epmd-edp/reconciler/v2/pkg/repository"
-	"github.com/epmd-edp/reconciler/v2/pkg/repository/codebasebranch"
-	sr "github.com/epmd-edp/reconciler/v2/pkg/repository/stage"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
 	"github.com/pkg/errors"
 	"k8s.io/client-go/rest"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
@@ -18,7 +17,7 @@ import (
 var log = logf.Log.WithName("cd_stage_service")
 
 type StageService struct {
-	DB        *sql.DB
+	Storage   storage.Storage
 	ClientSet platform.ClientSet
 }
 
@@ -26,91 +25,99 @@ type StageService struct {
 //The main cases which method do:
 //	- checks if stage can be created (checks if previous stage has been added)
 //	- update stage status
-//	- add record to Action Log for last operation
+//	- add record to Action Log for last operation unless it's been added already
 func (s StageService) PutStage(stage stage.Stage) error {
 	log.V(2).Info("start putting stage into db", "name", stage.Name)
-	txn, err := s.DB.Begin()
-	if err != nil {
-		return errors.New("error has occurred during opening transaction")
-	}
+	err := storage.WithTx(context.TODO(), s.Storage, func(txn storage.Tx) error {
+		if !s.canStageBeCreated(txn, stage) {
+			return backoff.AsDependency(fmt.Errorf("previous stage has not been added yet for stage %v", stage.Name))
+		}
 
-	if !canStageBeCreated(txn, stage) {
-		_ = txn.Rollback()
-		return fmt.Errorf("previous stage has not been added yet for stage %v", stage.Name)
-	}
+		id, created, err := s.getStageIdOrCreate(txn, s.ClientSet.EDPRestClient, stage)
+		if err != nil {
+			return errors.Wrapf(err, "cannot create stage %v", stage.Name)
+		}
 
-	id, err := getStageIdOrCreate(txn, s.ClientSet.EDPRestClient, stage)
-	if err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "cannot create stage %v", stage.Name)
-	}
+		if err := s.updateStageStatus(txn, id, stage); err != nil {
+			return errors.Wrapf(err, "cannot create stage %v", stage.Name)
+		}
 
-	if err := updateStageStatus(txn, id, stage); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "cannot create stage %v", stage.Name)
-	}
+		stored, err := s.Storage.ActionLog().PutToCDStage(txn, *id, stage.ActionLog, stage.Tenant)
+		if err != nil {
+			return errors.Wrapf(err, "cannot insert action log of stage %v", stage.Name)
+		}
+		log.V(2).Info("action log of stage has been saved", "name", stage.Name, "stored", stored)
 
-	_ = txn.Commit()
+		if created || stored {
+			if err := s.Storage.Outbox().Put(txn, stageEvent(*id, created, stage), stage.Tenant); err != nil {
+				return errors.Wrapf(err, "cannot record event of stage %v", stage.Name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
 	log.Info("stage has been inserted successfully", "name", stage.Name)
 	return nil
 }
 
-func createCodebaseDockerStreams(tx *sql.Tx, id int, stage stage.Stage, applicationsToApprove []string) error {
+func (s StageService) createCodebaseDockerStreams(tx storage.Tx, id int, stage stage.Stage, applicationsToApprove []string) error {
 	log.V(2).Info("start creating docker streams for stage", "id", id)
-	inputDockerStreams, err := getInputDockerStreams(tx, id, stage)
+	inputDockerStreams, err := s.getInputDockerStreams(tx, id, stage)
 	if err != nil {
 		return errors.Wrapf(err, "cannot get list of input docker streams for stage with id : %v", id)
 	}
-	if err = createOutputStreamsAndLink(tx, id, stage, inputDockerStreams, applicationsToApprove); err != nil {
+	if err = s.createOutputStreamsAndLink(tx, id, stage, inputDockerStreams, applicationsToApprove); err != nil {
 		return errors.Wrapf(err, "cannot create output streams for stage with id: %v", id)
 	}
 	log.Info("docker streams have been successfully created for stage", "stage id", id)
 	return nil
 }
 
-func UpdateSingleStageCodebaseDockerStreamRelations(tx *sql.Tx, id int, stage stage.Stage, applicationsToApprove []string) error {
+func (s StageService) UpdateSingleStageCodebaseDockerStreamRelations(tx storage.Tx, id int, stage stage.Stage, applicationsToApprove []string) error {
 	log.V(2).Info("start updating docker streams relation for stage", "stage id", id)
-	inputDockerStreams, err := getInputDockerStreams(tx, id, stage)
+	inputDockerStreams, err := s.getInputDockerStreams(tx, id, stage)
 	if err != nil {
 		return errors.Wrapf(err, "cannot get list of input docker streams for stage with id : %v", id)
 	}
-	if err = updateOutputStreamsRelation(tx, id, stage, inputDockerStreams, applicationsToApprove); err != nil {
+	if err = s.updateOutputStreamsRelation(tx, id, stage, inputDockerStreams, applicationsToApprove); err != nil {
 		return errors.Wrapf(err, "cannot create output streams for stage with id: %v", id)
 	}
 	log.Info("docker streams relation have been successfully updated for", "stage id", id)
 	return nil
 }
 
-func createOutputStreamsAndLink(tx *sql.Tx, id int, stage stage.Stage, dtos []model.CodebaseDockerStreamReadDTO, applicationsToApprove []string) error {
+func (s StageService) createOutputStreamsAndLink(tx storage.Tx, id int, stage stage.Stage, dtos []model.CodebaseDockerStreamReadDTO, applicationsToApprove []string) error {
 	log.V(2).Info("start creating outputstreams and links for stage", "stage id", id)
 	for _, stream := range dtos {
-		if err := createSingleOutputStreamAndLink(tx, id, stage, stream, applicationsToApprove); err != nil {
+		if err := s.createSingleOutputStreamAndLink(tx, id, stage, stream, applicationsToApprove); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func updateOutputStreamsRelation(tx *sql.Tx, id int, stage stage.Stage, dtos []model.CodebaseDockerStreamReadDTO, applicationsToApprove []string) error {
+func (s StageService) updateOutputStreamsRelation(tx storage.Tx, id int, stage stage.Stage, dtos []model.CodebaseDockerStreamReadDTO, applicationsToApprove []string) error {
 	log.V(2).Info("start updating links for stage ", "stage id", id)
 	for _, stream := range dtos {
-		if err := updateSingleOutputStreamRelation(tx, id, stage, stream, applicationsToApprove); err != nil {
+		if err := s.updateSingleOutputStreamRelation(tx, id, stage, stream, applicationsToApprove); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func createSingleOutputStreamAndLink(tx *sql.Tx, stageId int, stage stage.Stage, dto model.CodebaseDockerStreamReadDTO, applicationsToApprove []string) error {
+func (s StageService) createSingleOutputStreamAndLink(tx storage.Tx, stageId int, stage stage.Stage, dto model.CodebaseDockerStreamReadDTO, applicationsToApprove []string) error {
 	log.V(2).Info("start creating single outputstream and link for stage", "stage id", stageId, "stream id", dto.CodebaseDockerStreamId)
 	ocImageStreamName := fmt.Sprintf("%v-%v-%v-verified", stage.CdPipelineName, stage.Name, dto.CodebaseName)
-	branchId, err := repository.GetCodebaseDockerStreamBranchId(*tx, dto.CodebaseDockerStreamId, stage.Tenant)
+	branchId, err := s.Storage.DockerStream().GetBranchId(tx, dto.CodebaseDockerStreamId, stage.Tenant)
 	if err != nil {
 		return errors.Wrapf(err, "cannot get branch id by codebase docker stream id %v", dto.CodebaseDockerStreamId)
 	}
 
-	outputId, err := repository.CreateCodebaseDockerStream(*tx, stage.Tenant, branchId, ocImageStreamName)
+	outputId, err := s.Storage.DockerStream().Create(tx, branchId, ocImageStreamName, stage.Tenant)
 	if err != nil {
 		return errors.Wrap(err, "cannot create codebase docker stream")
 	}
@@ -118,9 +125,9 @@ func createSingleOutputStreamAndLink(tx *sql.Tx, stageId int, stage stage.Stage,
 
 	stage.Id = stageId
 	if include(applicationsToApprove, dto.CodebaseName) {
-		err = setPreviousStageInputImageStream(tx, stage, dto.CodebaseDockerStreamId, *outputId)
+		err = s.setPreviousStageInputImageStream(tx, stage, dto.CodebaseDockerStreamId, *outputId)
 	} else {
-		err = setOriginalInputImageStream(tx, stage, dto.CodebaseName, *outputId)
+		err = s.setOriginalInputImageStream(tx, stage, dto.CodebaseName, *outputId)
 	}
 	if err != nil {
 		return errors.Wrapf(err, "cannot link codebase docker stream %v", dto.CodebaseDockerStreamId)
@@ -128,18 +135,18 @@ func createSingleOutputStreamAndLink(tx *sql.Tx, stageId int, stage stage.Stage,
 	return nil
 }
 
-func updateSingleOutputStreamRelation(tx *sql.Tx, stageId int, stage stage.Stage, dto model.CodebaseDockerStreamReadDTO, applicationsToApprove []string) error {
+func (s StageService) updateSingleOutputStreamRelation(tx storage.Tx, stageId int, stage stage.Stage, dto model.CodebaseDockerStreamReadDTO, applicationsToApprove []string) error {
 	log.V(2).Info("start updating single relation outputstream for stage", "stage id", stageId)
-	outputId, err := tryToCreateOutputCodebaseDockerStreamIfDoesNotExist(tx, stage, dto)
+	outputId, err := s.tryToCreateOutputCodebaseDockerStreamIfDoesNotExist(tx, stage, dto)
 	if err != nil {
 		return err
 	}
 
 	stage.Id = stageId
 	if include(applicationsToApprove, dto.CodebaseName) {
-		err = setPreviousStageInputImageStream(tx, stage, dto.CodebaseDockerStreamId, *outputId)
+		err = s.setPreviousStageInputImageStream(tx, stage, dto.CodebaseDockerStreamId, *outputId)
 	} else {
-		err = setOriginalInputImageStream(tx, stage, dto.CodebaseName, *outputId)
+		err = s.setOriginalInputImageStream(tx, stage, dto.CodebaseName, *outputId)
 	}
 
 	if err != nil {
@@ -148,12 +155,12 @@ func updateSingleOutputStreamRelation(tx *sql.Tx, stageId int, stage stage.Stage
 	return nil
 }
 
-func tryToCreateOutputCodebaseDockerStreamIfDoesNotExist(tx *sql.Tx, stage stage.Stage, dto model.CodebaseDockerStreamReadDTO) (*int, error) {
+func (s StageService) tryToCreateOutputCodebaseDockerStreamIfDoesNotExist(tx storage.Tx, stage stage.Stage, dto model.CodebaseDockerStreamReadDTO) (*int, error) {
 	ocImageStreamName := fmt.Sprintf("%v-%v-%v-verified", stage.CdPipelineName, stage.Name, dto.CodebaseName)
 
 	var outputId *int
 
-	outputId, err := repository.GetCodebaseDockerStreamId(*tx, ocImageStreamName, stage.Tenant)
+	outputId, err := s.Storage.DockerStream().GetId(tx, ocImageStreamName, stage.Tenant)
 	if err != nil {
 		return nil, fmt.Errorf("cannot get Codebase Docker Stream Id %v: %v", ocImageStreamName, err)
 	}
@@ -161,37 +168,48 @@ func tryToCreateOutputCodebaseDockerStreamIfDoesNotExist(tx *sql.Tx, stage stage
 	if outputId == nil {
 		log.V(2).Info("output stream has not been created. Try to create it ...")
 
-		branchId, err := repository.GetCodebaseDockerStreamBranchId(*tx, dto.CodebaseDockerStreamId, stage.Tenant)
+		branchId, err := s.Storage.DockerStream().GetBranchId(tx, dto.CodebaseDockerStreamId, stage.Tenant)
 		if err != nil {
 			return nil, fmt.Errorf("cannot get branch id by codebase docker stream id %v: %v", dto.CodebaseDockerStreamId, err)
 		}
 
-		outputId, err = repository.CreateCodebaseDockerStream(*tx, stage.Tenant, branchId, ocImageStreamName)
+		outputId, err = s.Storage.DockerStream().Create(tx, branchId, ocImageStreamName, stage.Tenant)
 		if err != nil {
 			return nil, fmt.Errorf("cannot create codebase docker stream for dto: %v", dto)
 		}
 		log.Info("docker stream was created", "id", *outputId)
+
+		e := model.Event{
+			Kind:      model.DockerStreamEvent,
+			Operation: model.Created,
+			EntityId:  outputId,
+			Subject:   ocImageStreamName,
+			Data:      map[string]string{"cdPipeline": stage.CdPipelineName, "cdStage": stage.Name, "codebase": dto.CodebaseName},
+		}
+		if err := s.Storage.Outbox().Put(tx, e, stage.Tenant); err != nil {
+			return nil, errors.Wrapf(err, "cannot record event of codebase docker stream %v", ocImageStreamName)
+		}
 	}
 
 	return outputId, nil
 }
 
-func setPreviousStageInputImageStream(tx *sql.Tx, stage stage.Stage, inputId int, outputId int) error {
+func (s StageService) setPreviousStageInputImageStream(tx storage.Tx, stage stage.Stage, inputId int, outputId int) error {
 	log.V(2).Info("previous Stage Input Stream", "stage", stage.Id, "input", inputId, "output", outputId)
-	return repository.CreateStageCodebaseDockerStream(*tx, stage.Tenant, stage.Id, inputId, outputId)
+	return s.Storage.DockerStream().LinkStage(tx, stage.Id, inputId, outputId, stage.Tenant)
 }
 
-func setOriginalInputImageStream(tx *sql.Tx, stage stage.Stage, codebaseName string, outputId int) error {
-	sourceInputStream, err := getOriginalInputImageStream(tx, stage.CdPipelineName, codebaseName, stage.Tenant)
+func (s StageService) setOriginalInputImageStream(tx storage.Tx, stage stage.Stage, codebaseName string, outputId int) error {
+	sourceInputStream, err := s.getOriginalInputImageStream(tx, stage.CdPipelineName, codebaseName, stage.Tenant)
 	if err != nil {
 		return err
 	}
 	log.V(2).Info("source Stage Input Stream", "stage", stage.Id, "input", sourceInputStream, "output", outputId)
-	return repository.CreateStageCodebaseDockerStream(*tx, stage.Tenant, stage.Id, *sourceInputStream, outputId)
+	return s.Storage.DockerStream().LinkStage(tx, stage.Id, *sourceInputStream, outputId, stage.Tenant)
 }
 
-func getOriginalInputImageStream(tx *sql.Tx, cdPipelineName, codebaseName, schemaName string) (*int, error) {
-	originalInputStream, err := repository.GetSourceInputStream(*tx, cdPipelineName, codebaseName, schemaName)
+func (s StageService) getOriginalInputImageStream(tx storage.Tx, cdPipelineName, codebaseName, schemaName string) (*int, error) {
+	originalInputStream, err := s.Storage.DockerStream().GetSourceStream(tx, cdPipelineName, codebaseName, schemaName)
 	if err != nil {
 		return nil, errors.Wrapf(err, "couldn't fetch Original Input Stream for pipeline %v","codebase %v")
 	}
@@ -218,17 +236,17 @@ func include(applicationsToPromote []string, application string) bool {
 	return false
 }
 
-func getInputDockerStreams(tx *sql.Tx, id int, stage stage.Stage) ([]model.CodebaseDockerStreamReadDTO, error) {
+func (s StageService) getInputDockerStreams(tx storage.Tx, id int, stage stage.Stage) ([]model.CodebaseDockerStreamReadDTO, error) {
 	log.V(2).Info("start reading input docker streams for stage", "stage id", id)
 	if stage.Order == 0 {
-		return getInputDockerStreamsForFirstStage(tx, id, stage)
+		return s.getInputDockerStreamsForFirstStage(tx, id, stage)
 	}
-	return getInputDockerStreamsForArbitraryStage(tx, id, stage)
+	return s.getInputDockerStreamsForArbitraryStage(tx, id, stage)
 }
 
-func getInputDockerStreamsForArbitraryStage(tx *sql.Tx, id int, stage stage.Stage) ([]model.CodebaseDockerStreamReadDTO, error) {
+func (s StageService) getInputDockerStreamsForArbitraryStage(tx storage.Tx, id int, stage stage.Stage) ([]model.CodebaseDockerStreamReadDTO, error) {
 	log.V(2).Info("start reading input docker streams for the arbitrary stage with id: %v", id)
-	streams, err := repository.GetDockerStreamsByPipelineNameAndStageOrder(*tx, stage.Tenant, stage.CdPipelineName, stage.Order-1)
+	streams, err := s.Storage.DockerStream().GetStageStreams(tx, stage.CdPipelineName, stage.Order-1, stage.Tenant)
 	if err != nil {
 		return nil, errors.Wrapf(err, "an error has been occurred during the read docker streams %v",  "stage order %v")
 	}
@@ -236,9 +254,9 @@ func getInputDockerStreamsForArbitraryStage(tx *sql.Tx, id int, stage stage.Stag
 	return streams, nil
 }
 
-func getInputDockerStreamsForFirstStage(tx *sql.Tx, id int, stage stage.Stage) ([]model.CodebaseDockerStreamReadDTO, error) {
+func (s StageService) getInputDockerStreamsForFirstStage(tx storage.Tx, id int, stage stage.Stage) ([]model.CodebaseDockerStreamReadDTO, error) {
 	log.V(2).Info("start reading input docker streams for the first stage", "stage id", id)
-	streams, err := repository.GetDockerStreamsByPipelineName(*tx, stage.Tenant, stage.CdPipelineName)
+	streams, err := s.Storage.DockerStream().GetPipelineStreams(tx, stage.CdPipelineName, stage.Tenant)
 	if err != nil {
 		return nil, errors.Wrapf(err, "an error has been occurred during the read docker streams %v",
 			stage.CdPipelineName)
@@ -247,17 +265,17 @@ func getInputDockerStreamsForFirstStage(tx *sql.Tx, id int, stage stage.Stage) (
 	return streams, nil
 }
 
-func canStageBeCreated(tx *sql.Tx, stage stage.Stage) bool {
+func (s StageService) canStageBeCreated(tx storage.Tx, stage stage.Stage) bool {
 	if stage.Order == 0 {
 		log.V(2).Info("stage is the first in the chain. Returning true..", "name", stage.Name)
 		return true
 	}
-	return prevStageAdded(tx, stage)
+	return s.prevStageAdded(tx, stage)
 }
 
-func prevStageAdded(tx *sql.Tx, stage stage.Stage) bool {
+func (s StageService) prevStageAdded(tx storage.Tx, stage stage.Stage) bool {
 	log.V(2).Info("check previous stage fot stage", "name", stage.Name)
-	stageId, err := sr.GetStageIdByPipelineNameAndOrder(*tx, stage.Tenant, stage.CdPipelineName, stage.Order-1)
+	stageId, err := s.Storage.Stage().GetIdByOrder(tx, stage.CdPipelineName, stage.Order-1, stage.Tenant)
 	if err != nil {
 		log.Error(err, "an error has been occurred while retrieving prev stage id : %v", stageId)
 		return false
@@ -270,9 +288,9 @@ func prevStageAdded(tx *sql.Tx, stage stage.Stage) bool {
 	return true
 }
 
-func updateStageStatus(tx *sql.Tx, id *int, stage stage.Stage) error {
+func (s StageService) updateStageStatus(tx storage.Tx, id *int, stage stage.Stage) error {
 	log.V(2).Info("start updating status for stage", "id", *id, "status", stage.Status)
-	err := sr.UpdateStageStatus(*tx, stage.Tenant, *id, stage.Status)
+	err := s.Storage.Stage().UpdateStatus(tx, *id, stage.Status, stage.Tenant)
 	if err != nil {
 		return errors.Wrapf(err, "an error has been occurred while updating stage status: %v", stage.Name)
 	}
@@ -280,33 +298,56 @@ func updateStageStatus(tx *sql.Tx, id *int, stage stage.Stage) error {
 	return nil
 }
 
-func getStageIdOrCreate(tx *sql.Tx, edpRestClient *rest.RESTClient, stage stage.Stage) (*int, error) {
-	id, err := sr.GetStageId(*tx, stage.Tenant, stage.Name, stage.CdPipelineName)
+// getStageIdOrCreate reports whether the stage has been created along with its id.
+func (s StageService) getStageIdOrCreate(tx storage.Tx, edpRestClient *rest.RESTClient, stage stage.Stage) (*int, bool, error) {
+	id, err := s.Storage.Stage().GetId(tx, stage.CdPipelineName, stage.Name, stage.Tenant)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	if id != nil {
 		log.V(2).Info("stage is already presented. Returning id", "name", stage, "id", *id)
-		return id, err
+		return id, false, err
 	}
-	return createStage(tx, edpRestClient, stage)
+	id, err = s.createStage(tx, edpRestClient, stage)
+	return id, true, err
 }
 
-func createStage(tx *sql.Tx, edpRestClient *rest.RESTClient, stage stage.Stage) (*int, error) {
+// stageEvent describes creation of the stage or its update by the action
+// the stage status is set by.
+func stageEvent(id int, created bool, st stage.Stage) model.Event {
+	op := model.Updated
+	if created {
+		op = model.Created
+	}
+	return model.Event{
+		Kind:      model.CDStageEvent,
+		Operation: op,
+		EntityId:  &id,
+		Subject:   st.CdPipelineName + "/" + st.Name,
+		Data: map[string]string{
+			"cdPipeline": st.CdPipelineName,
+			"status":     st.Status,
+			"action":     st.ActionLog.Action,
+			"result":     st.ActionLog.Result,
+		},
+	}
+}
+
+func (s StageService) createStage(tx storage.Tx, edpRestClient *rest.RESTClient, stage stage.Stage) (*int, error) {
 	log.V(2).Info("start creating stage in db", "name", stage.Name)
-	cdPipeline, err := repository.GetCDPipeline(*tx, stage.CdPipelineName, stage.Tenant)
+	cdPipeline, err := s.Storage.CDPipeline().Get(tx, stage.CdPipelineName, stage.Tenant)
 	if err != nil {
 		return nil, errors.Wrapf(err, "an error has been occurred while reading cd pipeline %v", stage.CdPipelineName)
 	}
 	if cdPipeline == nil {
-		return nil, fmt.Errorf("record for cd pipeline with name %v has not been found", stage.CdPipelineName)
+		return nil, backoff.AsDependency(fmt.Errorf("record for cd pipeline with name %v has not been found", stage.CdPipelineName))
 	}
 
-	if err := setLibraryIdOrDoNothing(tx, &stage.Source, stage.Tenant); err != nil {
+	if err := s.setLibraryIdOrDoNothing(tx, &stage.Source, stage.Tenant); err != nil {
 		return nil, err
 	}
 
-	id, err := sr.CreateStage(*tx, stage, cdPipeline.Id)
+	id, err := s.Storage.Stage().Create(tx, stage, cdPipeline.Id)
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't create stage id db")
 	}
@@ -316,23 +357,23 @@ func createStage(tx *sql.Tx, edpRestClient *rest.RESTClient, stage stage.Stage)
 		return nil, err
 	}
 
-	if err = createCodebaseDockerStreams(tx, *id, stage, pipelineCR.Spec.ApplicationsToPromote); err != nil {
+	if err = s.createCodebaseDockerStreams(tx, *id, stage, pipelineCR.Spec.ApplicationsToPromote); err != nil {
 		return nil, errors.Wrapf(err, "couldn't create docker stream for stage %v in CD Pipeline", stage.Name)
 	}
 
-	if err = insertQualityGateRow(tx, *id, stage.QualityGates, stage.Tenant); err != nil {
+	if err = s.insertQualityGateRow(tx, *id, stage.QualityGates, stage.Tenant); err != nil {
 		return nil, errors.Wrapf(err, "couldn't create quality gate for stage %v", *id)
 	}
 	log.Info("stage has been created in db", "id", *id)
 	return id, nil
 }
 
-func setLibraryIdOrDoNothing(txn *sql.Tx, source *stage.Source, schemaName string) error {
+func (s StageService) setLibraryIdOrDoNothing(txn storage.Tx, source *stage.Source, schemaName string) error {
 	if source.Type == "default" {
 		return nil
 	}
 
-	id, err := repository.GetCodebaseId(*txn, source.Library.Name, schemaName)
+	id, err := s.Storage.Codebase().GetId(txn, source.Library.Name, schemaName)
 	if err != nil {
 		return errors.Wrapf(err, "an error has occurred while getting library id by %v codebase name",
 			source.Library.Name)
@@ -342,7 +383,7 @@ func setLibraryIdOrDoNothing(txn *sql.Tx, source *stage.Source, schemaName strin
 	}
 	source.Library.Id = id
 
-	bid, err := codebasebranch.GetCodebaseBranchId(*txn, source.Library.Name, source.Library.Branch, schemaName)
+	bid, err := s.Storage.CodebaseBranch().GetId(txn, source.Library.Name, source.Library.Branch, schemaName)
 	if err != nil {
 		return errors.Wrapf(err, "an error has occurred while getting library branch id by %v codebase name and %v branch",
 			source.Library.Name, source.Library.Branch)
@@ -355,10 +396,10 @@ func setLibraryIdOrDoNothing(txn *sql.Tx, source *stage.Source, schemaName strin
 	return nil
 }
 
-func insertQualityGateRow(tx *sql.Tx, cdStageId int, gates []stage.QualityGate, schemaName string) error {
+func (s StageService) insertQualityGateRow(tx storage.Tx, cdStageId int, gates []stage.QualityGate, schemaName string) error {
 	for _, gate := range gates {
 		if gate.QualityGate == "autotests" {
-			err := insertAutotestQualityGate(tx, cdStageId, gate, schemaName)
+			err := s.insertAutotestQualityGate(tx, cdStageId, gate, schemaName)
 			if err != nil {
 				return err
 			}
@@ -366,7 +407,7 @@ func insertQualityGateRow(tx *sql.Tx, cdStageId int, gates []stage.QualityGate,
 			continue
 		}
 
-		err := insertManualQualityGate(tx, cdStageId, gate, schemaName)
+		err := s.insertManualQualityGate(tx, cdStageId, gate, schemaName)
 		if err != nil {
 			return err
 		}
@@ -375,53 +416,58 @@ func insertQualityGateRow(tx *sql.Tx, cdStageId int, gates []stage.QualityGate,
 	return nil
 }
 
-func insertAutotestQualityGate(tx *sql.Tx, cdStageId int, gate stage.QualityGate, schemaName string) error {
-	entityIdsDTO, err := sr.GetCodebaseAndBranchIds(*tx, *gate.AutotestName, *gate.BranchName, schemaName)
+func (s StageService) insertAutotestQualityGate(tx storage.Tx, cdStageId int, gate stage.QualityGate, schemaName string) error {
+	entityIdsDTO, err := s.Storage.Stage().GetAutotestIds(tx, *gate.AutotestName, *gate.BranchName, schemaName)
 	if err != nil {
 		return err
 	}
 
-	_, err = sr.CreateQualityGate(*tx, gate.QualityGate, gate.JenkinsStepName, cdStageId, &entityIdsDTO.CodebaseId, &entityIdsDTO.BranchId, schemaName)
+	_, err = s.Storage.Stage().CreateQualityGate(tx, gate.QualityGate, gate.JenkinsStepName, cdStageId, &entityIdsDTO.CodebaseId, &entityIdsDTO.BranchId, schemaName)
 
 	return err
 }
 
-func insertManualQualityGate(tx *sql.Tx, cdStageId int, gate stage.QualityGate, schemaName string) error {
-	_, err := sr.CreateQualityGate(*tx, gate.QualityGate, gate.JenkinsStepName, cdStageId, nil, nil, schemaName)
+func (s StageService) insertManualQualityGate(tx storage.Tx, cdStageId int, gate stage.QualityGate, schemaName string) error {
+	_, err := s.Storage.Stage().CreateQualityGate(tx, gate.QualityGate, gate.JenkinsStepName, cdStageId, nil, nil, schemaName)
 
 	return err
 }
 
 func (s StageService) DeleteCDStage(pipeName, stageName, schema string) error {
 	log.V(2).Info("start deleting cd stage", "pipe name", pipeName, "name", stageName)
-	txn, err := s.DB.Begin()
-	if err != nil {
-		return errors.New("error has occurred during opening transaction")
-	}
-
-	id, err := sr.SelectCodebaseDockerStreamId(*txn, pipeName, stageName, schema)
-	if err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "couldn't get codebase docker stream id by cd stage %v for cd pipeline", stageName)
-	}
+	err := storage.WithTx(context.TODO(), s.Storage, func(txn storage.Tx) error {
+		id, err := s.Storage.DockerStream().GetStageOutputStream(txn, pipeName, stageName, schema)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't get codebase docker stream id by cd stage %v for cd pipeline", stageName)
+		}
 
-	if id == nil {
-		_ = txn.Rollback()
-		log.V(2).Info("docker stream has been deleted", "pipe", pipeName, "stage", stageName)
-		return nil
-	}
+		if id == nil {
+			log.V(2).Info("docker stream has been deleted", "pipe", pipeName, "stage", stageName)
+			return nil
+		}
 
-	if err := sr.DeleteCodebaseDockerStream(*txn, *id, schema); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "couldn't delete codebase docker stream with %v id", *id)
-	}
+		if err := s.Storage.DockerStream().Delete(txn, *id, schema); err != nil {
+			return errors.Wrapf(err, "couldn't delete codebase docker stream with %v id", *id)
+		}
 
-	if err := sr.DeleteCDStage(*txn, pipeName, stageName, schema); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "couldn't delete cd stage %v for cd pipeline", stageName)
-	}
+		if err := s.Storage.Stage().Delete(txn, pipeName, stageName, schema); err != nil {
+			return errors.Wrapf(err, "couldn't delete cd stage %v for cd pipeline", stageName)
+		}
 
-	if err := txn.Commit(); err != nil {
+		events := []model.Event{
+			{Kind: model.DockerStreamEvent, Operation: model.Deleted, EntityId: id,
+				Data: map[string]string{"cdPipeline": pipeName, "cdStage": stageName}},
+			{Kind: model.CDStageEvent, Operation: model.Deleted, Subject: pipeName + "/" + stageName,
+				Data: map[string]string{"cdPipeline": pipeName}},
+		}
+		for _, e := range events {
+			if err := s.Storage.Outbox().Put(txn, e, schema); err != nil {
+				return errors.Wrapf(err, "couldn't record event of deleted cd stage %v", stageName)
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 	log.Info("cd stage was deleted", "pipe name", pipeName, "name", stageName)
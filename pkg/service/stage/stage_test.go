@@ -0,0 +1,71 @@
+package stage
+
+import (
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/cdpipeline"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/stage"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const schema = "fake-schema"
+
+func createStage(t *testing.T, store *memory.Storage, s stage.Stage) {
+	store.AddJobProvisioning(schema, s.JobProvisioning, "cd")
+	tx, err := store.Begin()
+	assert.NoError(t, err)
+	p, err := store.CDPipeline().Create(tx, cdpipeline.CDPipeline{Name: s.CdPipelineName}, schema)
+	assert.NoError(t, err)
+	_, err = store.Stage().Create(tx, s, p.Id)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+}
+
+func TestPutStage_ShouldStoreActionLogOfStageOnce(t *testing.T) {
+	store := memory.New()
+	st := stage.Stage{
+		Name:            "sit",
+		Tenant:          schema,
+		CdPipelineName:  "fake-pipeline",
+		JobProvisioning: "default",
+		Status:          "created",
+		ActionLog:       model.ActionLog{Action: "accept_cd_stage_registration", Result: "success", UpdatedAt: time.Now()},
+	}
+	createStage(t, store, st)
+	s := StageService{Storage: store}
+
+	assert.NoError(t, s.PutStage(st))
+	assert.NoError(t, s.PutStage(st))
+	st.ActionLog.Action = "platform_project_creation"
+	assert.NoError(t, s.PutStage(st))
+
+	assert.Equal(t, "created", store.Status(schema, "cd_stage", "fake-pipeline/sit"))
+	assert.Equal(t, []string{"accept_cd_stage_registration", "platform_project_creation"}, store.ActionLogs(schema))
+}
+
+func TestPutStage_ShouldRecordEventOnlyForNewActionLog(t *testing.T) {
+	store := memory.New()
+	st := stage.Stage{
+		Name:            "sit",
+		Tenant:          schema,
+		CdPipelineName:  "fake-pipeline",
+		JobProvisioning: "default",
+		Status:          "created",
+		ActionLog:       model.ActionLog{Action: "accept_cd_stage_registration", Result: "success", UpdatedAt: time.Now()},
+	}
+	createStage(t, store, st)
+	s := StageService{Storage: store}
+
+	assert.NoError(t, s.PutStage(st))
+	assert.NoError(t, s.PutStage(st))
+
+	events := store.Events(schema)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, model.CDStageEvent, events[0].Kind)
+		assert.Equal(t, model.Updated, events[0].Operation)
+		assert.Equal(t, "fake-pipeline/sit", events[0].Subject)
+		assert.Equal(t, "created", events[0].Data["status"])
+	}
+}
@@ -1,9 +1,11 @@
 package thirdpartyservice
 
 import (
+	"context"
 	"database/sql"
 	"github.com/epmd-edp/reconciler/v2/pkg/model/service"
 	"github.com/epmd-edp/reconciler/v2/pkg/repository/thirdpartyservice"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage/postgres"
 	"github.com/pkg/errors"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 )
@@ -16,20 +18,14 @@ var log = logf.Log.WithName("third-party-service-layer")
 
 func (s ThirdPartyService) PutService(service service.ServiceDto) error {
 	log.Info("start creating ThirdPartyService row in DB", "name", service.Name)
-	txn, err := s.DB.Begin()
-	if err != nil {
-		return errors.Wrapf(err, "couldn't open transaction to create record for %v ThirdPartyService", service.Name)
-	}
-
-	if err := tryToCreateService(txn, service); err != nil {
-		if err := txn.Rollback(); err != nil {
-			return errors.Wrapf(err, "couldn't finish rollback while creating %v ThirdPartyService record in DB", service.Name)
+	err := postgres.WithTx(context.TODO(), s.DB, func(txn *sql.Tx) error {
+		if err := tryToCreateService(txn, service); err != nil {
+			return errors.Wrapf(err, "couldn't create %v ThirdPartyService record in DB", service.Name)
 		}
-		return errors.Wrapf(err, "couldn't create %v ThirdPartyService record in DB", service.Name)
-	}
-
-	if err := txn.Commit(); err != nil {
-		return errors.Wrapf(err, "couldn't commit changes to db for %v ThirdPartyService", service.Name)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 	log.Info("ThirdPartyService has been created", "name", service.Name)
 	return nil
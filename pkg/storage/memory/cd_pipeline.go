@@ -0,0 +1,377 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/cdpipeline"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebase"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/stage"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
+)
+
+type cdPipelineRepository struct {
+	s *Storage
+}
+
+func (r cdPipelineRepository) Get(_ storage.Tx, name, schema string) (*model.CDPipelineDTO, error) {
+	if p := r.s.tenant(schema).pipelineByName(name); p != nil {
+		dto := *p
+		return &dto, nil
+	}
+	return nil, nil
+}
+
+func (r cdPipelineRepository) Create(_ storage.Tx, p cdpipeline.CDPipeline, schema string) (*model.CDPipelineDTO, error) {
+	t := r.s.tenant(schema)
+	if t.pipelineByName(p.Name) != nil {
+		return nil, fmt.Errorf("cd pipeline %v already exists", p.Name)
+	}
+	dto := model.CDPipelineDTO{Id: t.nextId(), Name: p.Name, Status: p.Status}
+	t.pipelines = append(t.pipelines, dto)
+	return &dto, nil
+}
+
+func (r cdPipelineRepository) UpdateStatus(_ storage.Tx, id int, status, schema string) error {
+	if p := r.s.tenant(schema).pipelineById(id); p != nil {
+		p.Status = status
+	}
+	return nil
+}
+
+func (r cdPipelineRepository) Delete(_ storage.Tx, name, schema string) error {
+	t := r.s.tenant(schema)
+	pipelines := t.pipelines[:0]
+	for _, p := range t.pipelines {
+		if p.Name != name {
+			pipelines = append(pipelines, p)
+			continue
+		}
+		t.unlink(pipelineStream, p.Id)
+		t.unlink(pipelineService, p.Id)
+		t.unlink(applicationToPromote, p.Id)
+	}
+	t.pipelines = pipelines
+	return nil
+}
+
+func (r cdPipelineRepository) AddThirdPartyService(_ storage.Tx, pipelineId, serviceId int, schema string) error {
+	r.s.tenant(schema).link(pipelineService, pipelineId, serviceId)
+	return nil
+}
+
+func (r cdPipelineRepository) AddDockerStream(_ storage.Tx, pipelineId, streamId int, schema string) error {
+	r.s.tenant(schema).link(pipelineStream, pipelineId, streamId)
+	return nil
+}
+
+func (r cdPipelineRepository) RemoveDockerStreams(_ storage.Tx, pipelineId int, schema string) error {
+	r.s.tenant(schema).unlink(pipelineStream, pipelineId)
+	return nil
+}
+
+func (r cdPipelineRepository) AddApplicationToPromote(_ storage.Tx, pipelineId, codebaseId int, schema string) error {
+	r.s.tenant(schema).link(applicationToPromote, pipelineId, codebaseId)
+	return nil
+}
+
+func (r cdPipelineRepository) RemoveApplicationsToPromote(_ storage.Tx, pipelineId int, schema string) error {
+	r.s.tenant(schema).unlink(applicationToPromote, pipelineId)
+	return nil
+}
+
+type stageRepository struct {
+	s *Storage
+}
+
+func (r stageRepository) GetId(_ storage.Tx, pipeline, name, schema string) (*int, error) {
+	if st := r.s.tenant(schema).stage(pipeline, name); st != nil {
+		id := st.id
+		return &id, nil
+	}
+	return nil, nil
+}
+
+func (r stageRepository) GetIdByOrder(_ storage.Tx, pipeline string, order int, schema string) (*int, error) {
+	t := r.s.tenant(schema)
+	p := t.pipelineByName(pipeline)
+	if p == nil {
+		return nil, nil
+	}
+	for _, st := range t.stages {
+		if st.pipelineId == p.Id && st.s.Order == order {
+			id := st.id
+			return &id, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r stageRepository) GetStages(_ storage.Tx, pipeline, schema string) ([]stage.Stage, error) {
+	t := r.s.tenant(schema)
+	p := t.pipelineByName(pipeline)
+	if p == nil {
+		return nil, nil
+	}
+	var stages []stage.Stage
+	for _, st := range t.stages {
+		if st.pipelineId == p.Id {
+			stages = append(stages, stage.Stage{
+				Id:          st.id,
+				Name:        st.s.Name,
+				Status:      st.s.Status,
+				TriggerType: st.s.TriggerType,
+				Description: st.s.Description,
+				Order:       st.s.Order,
+			})
+		}
+	}
+	return stages, nil
+}
+
+func (r stageRepository) Create(_ storage.Tx, s stage.Stage, pipelineId int) (*int, error) {
+	t := r.s.tenant(s.Tenant)
+	if t.pipelineById(pipelineId) == nil {
+		return nil, fmt.Errorf("cd pipeline with id %v doesn't exist", pipelineId)
+	}
+	if t.reference(jobProvisioning, s.JobProvisioning, "cd") == nil {
+		return nil, fmt.Errorf("job provisioning %v doesn't exist", s.JobProvisioning)
+	}
+	id := t.nextId()
+	t.stages = append(t.stages, stageRow{id: id, pipelineId: pipelineId, s: s})
+	return &id, nil
+}
+
+func (r stageRepository) UpdateStatus(_ storage.Tx, id int, status, schema string) error {
+	t := r.s.tenant(schema)
+	for i := range t.stages {
+		if t.stages[i].id == id {
+			t.stages[i].s.Status = status
+		}
+	}
+	return nil
+}
+
+func (r stageRepository) Delete(_ storage.Tx, pipeline, name, schema string) error {
+	t := r.s.tenant(schema)
+	st := t.stage(pipeline, name)
+	if st == nil {
+		return nil
+	}
+	id := st.id
+	stages := t.stages[:0]
+	for _, s := range t.stages {
+		if s.id != id {
+			stages = append(stages, s)
+		}
+	}
+	t.stages = stages
+	links := t.stageStreams[:0]
+	for _, l := range t.stageStreams {
+		if l.stageId != id {
+			links = append(links, l)
+		}
+	}
+	t.stageStreams = links
+	gates := t.qualityGates[:0]
+	for _, g := range t.qualityGates {
+		if g.stageId != id {
+			gates = append(gates, g)
+		}
+	}
+	t.qualityGates = gates
+	return nil
+}
+
+func (r stageRepository) CreateQualityGate(_ storage.Tx, gate, stepName string, stageId int, codebaseId, branchId *int,
+	schema string) (*int, error) {
+	t := r.s.tenant(schema)
+	id := t.nextId()
+	t.qualityGates = append(t.qualityGates, qualityGateRow{
+		id:         id,
+		stageId:    stageId,
+		gate:       gate,
+		stepName:   stepName,
+		codebaseId: codebaseId,
+		branchId:   branchId,
+	})
+	return &id, nil
+}
+
+func (r stageRepository) GetAutotestIds(_ storage.Tx, autotest, branch, schema string) (*model.CodebaseBranchIdDTO, error) {
+	t := r.s.tenant(schema)
+	c := t.codebaseByName(autotest)
+	if c == nil || c.c.Type != string(codebase.Autotests) {
+		return nil, nil
+	}
+	for _, b := range t.branches {
+		if b.codebaseId == c.id && b.b.Name == branch {
+			return &model.CodebaseBranchIdDTO{CodebaseId: c.id, BranchId: b.id}, nil
+		}
+	}
+	return nil, nil
+}
+
+type dockerStreamRepository struct {
+	s *Storage
+}
+
+func (r dockerStreamRepository) Create(_ storage.Tx, branchId *int, name, schema string) (*int, error) {
+	t := r.s.tenant(schema)
+	id := t.nextId()
+	t.streams = append(t.streams, streamRow{id: id, name: name, branchId: branchId})
+	return &id, nil
+}
+
+func (r dockerStreamRepository) GetId(_ storage.Tx, name, schema string) (*int, error) {
+	for _, s := range r.s.tenant(schema).streams {
+		if s.name == name {
+			id := s.id
+			return &id, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r dockerStreamRepository) GetBranchId(_ storage.Tx, id int, schema string) (*int, error) {
+	s := r.s.tenant(schema).streamById(id)
+	if s == nil {
+		return nil, fmt.Errorf("docker stream with id %v doesn't exist", id)
+	}
+	return s.branchId, nil
+}
+
+func (r dockerStreamRepository) GetCodebaseStreams(_ storage.Tx, codebase,
+	schema string) ([]model.CodebaseBranchDockerStreamDTO, error) {
+	t := r.s.tenant(schema)
+	var streams []model.CodebaseBranchDockerStreamDTO
+	for _, s := range t.streams {
+		if s.branchId == nil {
+			continue
+		}
+		b := t.branchById(*s.branchId)
+		if b == nil {
+			continue
+		}
+		if c := t.codebaseById(b.codebaseId); c != nil && c.c.Name == codebase {
+			streams = append(streams, model.CodebaseBranchDockerStreamDTO{Id: s.id, Name: s.name, Branch: b.b.Name})
+		}
+	}
+	sort.Slice(streams, func(i, j int) bool { return streams[i].Name < streams[j].Name })
+	return streams, nil
+}
+
+func (r dockerStreamRepository) SetBranchId(_ storage.Tx, id, branchId int, schema string) error {
+	if s := r.s.tenant(schema).streamById(id); s != nil {
+		s.branchId = &branchId
+	}
+	return nil
+}
+
+func (r dockerStreamRepository) Delete(_ storage.Tx, id int, schema string) error {
+	r.s.tenant(schema).deleteStreams(map[int]bool{id: true})
+	return nil
+}
+
+func (r dockerStreamRepository) GetPipelineStreams(_ storage.Tx, pipeline, schema string) ([]model.CodebaseDockerStreamReadDTO, error) {
+	t := r.s.tenant(schema)
+	p := t.pipelineByName(pipeline)
+	if p == nil {
+		return nil, nil
+	}
+	var dtos []model.CodebaseDockerStreamReadDTO
+	for _, id := range t.linked(pipelineStream, p.Id) {
+		dtos = append(dtos, t.streamDTO(id))
+	}
+	return dtos, nil
+}
+
+func (r dockerStreamRepository) GetStageStreams(tx storage.Tx, pipeline string, order int,
+	schema string) ([]model.CodebaseDockerStreamReadDTO, error) {
+	stageId, _ := r.s.Stage().GetIdByOrder(tx, pipeline, order, schema)
+	if stageId == nil {
+		return nil, nil
+	}
+	t := r.s.tenant(schema)
+	var dtos []model.CodebaseDockerStreamReadDTO
+	for _, l := range t.stageStreams {
+		if l.stageId == *stageId {
+			dtos = append(dtos, t.streamDTO(l.outputId))
+		}
+	}
+	return dtos, nil
+}
+
+func (r dockerStreamRepository) GetSourceStream(_ storage.Tx, pipeline, codebase, schema string) (*int, error) {
+	t := r.s.tenant(schema)
+	p := t.pipelineByName(pipeline)
+	if p == nil {
+		return nil, nil
+	}
+	for _, id := range t.linked(pipelineStream, p.Id) {
+		if t.streamDTO(id).CodebaseName == codebase {
+			return &id, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r dockerStreamRepository) GetStageOutputStream(_ storage.Tx, pipeline, stage, schema string) (*int, error) {
+	t := r.s.tenant(schema)
+	st := t.stage(pipeline, stage)
+	if st == nil {
+		return nil, nil
+	}
+	for _, l := range t.stageStreams {
+		if l.stageId == st.id {
+			id := l.outputId
+			return &id, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r dockerStreamRepository) LinkStage(_ storage.Tx, stageId, inputId, outputId int, schema string) error {
+	t := r.s.tenant(schema)
+	t.stageStreams = append(t.stageStreams, stageStreamRow{stageId: stageId, inputId: inputId, outputId: outputId})
+	return nil
+}
+
+func (r dockerStreamRepository) UnlinkStage(_ storage.Tx, stageId int, schema string) ([]int, error) {
+	t := r.s.tenant(schema)
+	var outputs []int
+	links := t.stageStreams[:0]
+	for _, l := range t.stageStreams {
+		if l.stageId == stageId {
+			outputs = append(outputs, l.outputId)
+			continue
+		}
+		links = append(links, l)
+	}
+	t.stageStreams = links
+	return outputs, nil
+}
+
+func (r dockerStreamRepository) DeletePipelineStreams(_ storage.Tx, pipeline, schema string) ([]int, error) {
+	t := r.s.tenant(schema)
+	p := t.pipelineByName(pipeline)
+	if p == nil {
+		return nil, nil
+	}
+	ids := map[int]bool{}
+	var deleted []int
+	for _, st := range t.stages {
+		if st.pipelineId != p.Id {
+			continue
+		}
+		for _, l := range t.stageStreams {
+			if l.stageId == st.id && !ids[l.outputId] {
+				ids[l.outputId] = true
+				deleted = append(deleted, l.outputId)
+			}
+		}
+	}
+	t.deleteStreams(ids)
+	return deleted, nil
+}
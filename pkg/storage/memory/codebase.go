@@ -0,0 +1,384 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebase"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebasebranch"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/perfdatasource"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
+)
+
+type codebaseRepository struct {
+	s *Storage
+}
+
+func (r codebaseRepository) GetId(_ storage.Tx, name, schema string) (*int, error) {
+	if c := r.s.tenant(schema).codebaseByName(name); c != nil {
+		id := c.id
+		return &id, nil
+	}
+	return nil, nil
+}
+
+func (r codebaseRepository) GetApplicationId(_ storage.Tx, name, schema string) (*int, error) {
+	c := r.s.tenant(schema).codebaseByName(name)
+	if c == nil || c.c.Type != string(codebase.Application) {
+		return nil, nil
+	}
+	id := c.id
+	return &id, nil
+}
+
+func (r codebaseRepository) GetType(_ storage.Tx, id int, schema string) (*string, error) {
+	c := r.s.tenant(schema).codebaseById(id)
+	if c == nil {
+		return nil, fmt.Errorf("codebase with id %v doesn't exist", id)
+	}
+	t := c.c.Type
+	return &t, nil
+}
+
+func (r codebaseRepository) Get(_ storage.Tx, name, schema string) (*codebase.Codebase, error) {
+	if row := r.s.tenant(schema).codebaseByName(name); row != nil {
+		c := row.c
+		return &c, nil
+	}
+	return nil, nil
+}
+
+func (r codebaseRepository) Create(_ storage.Tx, c codebase.Codebase, schema string) (*int, error) {
+	t := r.s.tenant(schema)
+	if t.codebaseByName(c.Name) != nil {
+		return nil, fmt.Errorf("codebase %v already exists", c.Name)
+	}
+	id := t.nextId()
+	t.codebases = append(t.codebases, codebaseRow{id: id, c: c})
+	return &id, nil
+}
+
+func (r codebaseRepository) Update(_ storage.Tx, c codebase.Codebase, schema string) error {
+	if row := r.s.tenant(schema).codebaseByName(c.Name); row != nil {
+		c.Status = row.c.Status
+		row.c = c
+	}
+	return nil
+}
+
+func (r codebaseRepository) LogChanges(_ storage.Tx, id int, changes []codebase.Change, schema string) error {
+	t := r.s.tenant(schema)
+	for _, ch := range changes {
+		t.changes = append(t.changes, changeRow{codebaseId: id, change: ch})
+	}
+	return nil
+}
+
+func (r codebaseRepository) AddPreviousLocation(_ storage.Tx, id int, l codebase.RepositoryLocation, schema string) error {
+	t := r.s.tenant(schema)
+	l.MovedAt = time.Now()
+	t.locations = append(t.locations, locationRow{codebaseId: id, location: l})
+	return nil
+}
+
+func (r codebaseRepository) GetPreviousLocations(_ storage.Tx, name, schema string) ([]codebase.RepositoryLocation, error) {
+	t := r.s.tenant(schema)
+	c := t.codebaseByName(name)
+	if c == nil {
+		return nil, nil
+	}
+	var locations []codebase.RepositoryLocation
+	for i := len(t.locations) - 1; i >= 0; i-- {
+		if t.locations[i].codebaseId == c.id {
+			locations = append(locations, t.locations[i].location)
+		}
+	}
+	return locations, nil
+}
+
+func (r codebaseRepository) UpdateStatus(_ storage.Tx, id int, status, schema string) error {
+	if c := r.s.tenant(schema).codebaseById(id); c != nil {
+		c.c.Status = status
+	}
+	return nil
+}
+
+func (r codebaseRepository) Delete(_ storage.Tx, name, schema string) error {
+	t := r.s.tenant(schema)
+	codebases := t.codebases[:0]
+	deleted := map[int]bool{}
+	for _, c := range t.codebases {
+		if c.c.Name != name {
+			codebases = append(codebases, c)
+		} else {
+			deleted[c.id] = true
+		}
+	}
+	t.codebases = codebases
+
+	branches := map[int]bool{}
+	for _, b := range t.branches {
+		if deleted[b.codebaseId] {
+			branches[b.id] = true
+		}
+	}
+	t.deleteBranches(branches)
+	return nil
+}
+
+func (r codebaseRepository) DeletePerfDataSources(_ storage.Tx, id int, schema string) error {
+	t := r.s.tenant(schema)
+	t.unlink(codebasePerfDataSource, id)
+	t.removeDataSources(id, "")
+	return nil
+}
+
+func (r codebaseRepository) GetPerfDataSources(_ storage.Tx, id int, schema string) ([]string, error) {
+	t := r.s.tenant(schema)
+	var types []string
+	for _, dsId := range t.linked(codebasePerfDataSource, id) {
+		if ref := t.referenceById(dsId); ref != nil {
+			types = append(types, ref.name)
+		}
+	}
+	sort.Strings(types)
+	return types, nil
+}
+
+func (r codebaseRepository) AddPerfDataSource(_ storage.Tx, id int, dsType, schema string) error {
+	t := r.s.tenant(schema)
+	dsId := t.reference(perfDataSource, dsType, "")
+	if dsId == nil {
+		nextId := t.nextId()
+		t.references = append(t.references, referenceRow{id: nextId, kind: perfDataSource, name: dsType})
+		dsId = &nextId
+	}
+	t.link(codebasePerfDataSource, id, *dsId)
+	return nil
+}
+
+func (r codebaseRepository) RemovePerfDataSource(_ storage.Tx, id int, dsType, schema string) error {
+	t := r.s.tenant(schema)
+	if dsId := t.reference(perfDataSource, dsType, ""); dsId != nil {
+		t.unlinkRow(codebasePerfDataSource, id, *dsId)
+	}
+	t.removeDataSources(id, dsType)
+	return nil
+}
+
+func (r codebaseRepository) UpdatePerfDataSource(_ storage.Tx, id int, ds perfdatasource.PerfDataSource, schema string) error {
+	t := r.s.tenant(schema)
+	dsId := t.reference(perfDataSource, ds.Type, "")
+	if dsId == nil || !containsId(t.linked(codebasePerfDataSource, id), *dsId) {
+		return nil
+	}
+	t.removeDataSources(id, ds.Type)
+	t.dataSources = append(t.dataSources, dataSourceRow{codebaseId: id, ds: ds})
+	return nil
+}
+
+func stringValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func containsId(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+type codebaseBranchRepository struct {
+	s *Storage
+}
+
+func (r codebaseBranchRepository) GetId(_ storage.Tx, codebase, branch, schema string) (*int, error) {
+	t := r.s.tenant(schema)
+	for _, b := range t.branches {
+		c := t.codebaseById(b.codebaseId)
+		if c != nil && c.c.Name == codebase && b.b.Name == branch {
+			id := b.id
+			return &id, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r codebaseBranchRepository) GetNames(_ storage.Tx, codebase, schema string) ([]string, error) {
+	t := r.s.tenant(schema)
+	var names []string
+	for _, b := range t.branches {
+		if c := t.codebaseById(b.codebaseId); c != nil && c.c.Name == codebase {
+			names = append(names, b.b.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (r codebaseBranchRepository) Get(tx storage.Tx, codebase, branch, schema string) (*codebasebranch.CodebaseBranch, error) {
+	id, _ := r.GetId(tx, codebase, branch, schema)
+	if id == nil {
+		return nil, nil
+	}
+	b := r.s.tenant(schema).branchById(*id).b
+	return &b, nil
+}
+
+func (r codebaseBranchRepository) Create(_ storage.Tx, b codebasebranch.CodebaseBranch, codebaseId int,
+	streamId *int, schema string) (*int, error) {
+	t := r.s.tenant(schema)
+	if t.codebaseById(codebaseId) == nil {
+		return nil, fmt.Errorf("codebase with id %v doesn't exist", codebaseId)
+	}
+	id := t.nextId()
+	b.LastSuccessBuildAt = nil
+	if b.LastSuccessBuild != nil {
+		now := time.Now()
+		b.LastSuccessBuildAt = &now
+	}
+	t.branches = append(t.branches, branchRow{id: id, codebaseId: codebaseId, streamId: streamId, b: b})
+	return &id, nil
+}
+
+func (r codebaseBranchRepository) Update(_ storage.Tx, id int, b codebasebranch.CodebaseBranch, schema string) error {
+	row := r.s.tenant(schema).branchById(id)
+	if row == nil {
+		return nil
+	}
+	if stringValue(row.b.LastSuccessBuild) != stringValue(b.LastSuccessBuild) {
+		now := time.Now()
+		row.b.LastSuccessBuildAt = &now
+	}
+	row.b.FromCommit = b.FromCommit
+	row.b.Release = b.Release
+	row.b.Version = b.Version
+	row.b.BuildNumber = b.BuildNumber
+	row.b.LastSuccessBuild = b.LastSuccessBuild
+	return nil
+}
+
+func (r codebaseBranchRepository) AddBuild(_ storage.Tx, id int, b codebasebranch.Build, schema string) error {
+	t := r.s.tenant(schema)
+	b.CreatedAt = time.Now()
+	t.builds = append(t.builds, buildRow{branchId: id, build: b})
+	return nil
+}
+
+func (r codebaseBranchRepository) GetBuilds(tx storage.Tx, codebase, branch, schema string) ([]codebasebranch.Build, error) {
+	id, _ := r.GetId(tx, codebase, branch, schema)
+	if id == nil {
+		return nil, nil
+	}
+	t := r.s.tenant(schema)
+	var builds []codebasebranch.Build
+	for i := len(t.builds) - 1; i >= 0; i-- {
+		if t.builds[i].branchId == *id {
+			builds = append(builds, t.builds[i].build)
+		}
+	}
+	return builds, nil
+}
+
+func (r codebaseBranchRepository) UpdateStatus(_ storage.Tx, id int, status, schema string) error {
+	if b := r.s.tenant(schema).branchById(id); b != nil {
+		b.b.Status = status
+	}
+	return nil
+}
+
+func (r codebaseBranchRepository) Delete(tx storage.Tx, codebase, branch, schema string) error {
+	id, _ := r.GetId(tx, codebase, branch, schema)
+	if id == nil {
+		return nil
+	}
+	r.s.tenant(schema).deleteBranches(map[int]bool{*id: true})
+	return nil
+}
+
+type actionLogRepository struct {
+	s *Storage
+}
+
+func (r actionLogRepository) PutToCodebase(_ storage.Tx, codebaseId int, al model.ActionLog, schema string) (bool, error) {
+	return r.put(codebaseAction, codebaseId, al, schema), nil
+}
+
+func (r actionLogRepository) PutToCodebaseBranch(_ storage.Tx, branchId int, al model.ActionLog, schema string) (bool, error) {
+	return r.put(branchAction, branchId, al, schema), nil
+}
+
+func (r actionLogRepository) PutToCDPipeline(_ storage.Tx, pipelineId int, al model.ActionLog, schema string) (bool, error) {
+	return r.put(pipelineAction, pipelineId, al, schema), nil
+}
+
+func (r actionLogRepository) PutToCDStage(_ storage.Tx, stageId int, al model.ActionLog, schema string) (bool, error) {
+	return r.put(stageAction, stageId, al, schema), nil
+}
+
+func (r actionLogRepository) GetCodebaseTimeline(_ storage.Tx, codebase string, f model.TimelineFilter, schema string) ([]model.TimelineEntry, error) {
+	t := r.s.tenant(schema)
+	c := t.codebaseByName(codebase)
+	if c == nil {
+		return nil, nil
+	}
+
+	var entries []model.TimelineEntry
+	add := func(kind string, entityId int, branch string) {
+		for _, id := range t.linked(kind, entityId) {
+			l := t.actionLogById(id)
+			if l == nil || (f.Branch != "" && f.Branch != branch) || (f.Action != "" && f.Action != l.Action) ||
+				(f.Result != "" && f.Result != l.Result) || l.UpdatedAt.Before(f.Since) {
+				continue
+			}
+			e := model.TimelineEntry{ActionLog: *l, Branch: branch}
+			e.Id = id
+			entries = append(entries, e)
+		}
+	}
+	add(codebaseAction, c.id, "")
+	for _, b := range t.branches {
+		if b.codebaseId == c.id {
+			add(branchAction, b.id, b.b.Name)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].UpdatedAt.Equal(entries[j].UpdatedAt) {
+			return entries[i].UpdatedAt.After(entries[j].UpdatedAt)
+		}
+		return entries[i].Id > entries[j].Id
+	})
+	if f.Limit > 0 && len(entries) > f.Limit {
+		entries = entries[:f.Limit]
+	}
+	return entries, nil
+}
+
+// put links the action log to the entity unless the entity has the same one.
+func (r actionLogRepository) put(kind string, entityId int, al model.ActionLog, schema string) bool {
+	t := r.s.tenant(schema)
+	for _, id := range t.linked(kind, entityId) {
+		if l := t.actionLogById(id); l != nil && sameActionLog(*l, al) {
+			return false
+		}
+	}
+	id := t.nextId()
+	t.actionLogs = append(t.actionLogs, actionLogRow{id: id, log: al})
+	t.link(kind, entityId, id)
+	return true
+}
+
+// sameActionLog compares action logs as their fingerprint does, so the
+// rendered action message is ignored.
+func sameActionLog(a, b model.ActionLog) bool {
+	return a.UpdatedAt.Equal(b.UpdatedAt) && a.Action == b.Action && a.Result == b.Result &&
+		a.Username == b.Username && a.DetailedMessage == b.DetailedMessage
+}
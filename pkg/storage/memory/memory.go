@@ -0,0 +1,249 @@
+// Package memory implements storage keeping all the data in process memory.
+// It is intended for unit tests of the services.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebase"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/perfdatasource"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
+)
+
+// Storage serializes transactions: Begin blocks until the previous
+// transaction is committed or rolled back.
+type Storage struct {
+	mu      *sync.Mutex
+	tenants map[string]*tenant
+}
+
+type Tx struct {
+	s          *Storage
+	snapshot   map[string]*tenant
+	savepoints map[string]map[string]*tenant
+	done       bool
+}
+
+func New() *Storage {
+	return &Storage{
+		mu:      &sync.Mutex{},
+		tenants: map[string]*tenant{},
+	}
+}
+
+func (s *Storage) Begin() (storage.Tx, error) {
+	s.mu.Lock()
+	return &Tx{s: s, snapshot: s.clone(), savepoints: map[string]map[string]*tenant{}}, nil
+}
+
+func (s *Storage) BeginTx(ctx context.Context) (storage.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.Begin()
+}
+
+func (s *Storage) clone() map[string]*tenant {
+	tenants := make(map[string]*tenant, len(s.tenants))
+	for name, t := range s.tenants {
+		tenants[name] = t.clone()
+	}
+	return tenants
+}
+
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return errors.New("transaction has already been committed or rolled back")
+	}
+	tx.done = true
+	tx.s.mu.Unlock()
+	return nil
+}
+
+func (tx *Tx) Rollback() error {
+	if tx.done {
+		return errors.New("transaction has already been committed or rolled back")
+	}
+	tx.done = true
+	tx.s.tenants = tx.snapshot
+	tx.s.mu.Unlock()
+	return nil
+}
+
+func (tx *Tx) Savepoint(name string) error {
+	tx.savepoints[name] = tx.s.clone()
+	return nil
+}
+
+func (tx *Tx) RollbackTo(name string) error {
+	sp, ok := tx.savepoints[name]
+	if !ok {
+		return fmt.Errorf("savepoint %v does not exist", name)
+	}
+	tx.s.tenants = sp
+	tx.savepoints[name] = tx.s.clone()
+	return nil
+}
+
+func (tx *Tx) Release(name string) error {
+	if _, ok := tx.savepoints[name]; !ok {
+		return fmt.Errorf("savepoint %v does not exist", name)
+	}
+	delete(tx.savepoints, name)
+	return nil
+}
+
+func (s *Storage) Codebase() storage.CodebaseRepository {
+	return codebaseRepository{s}
+}
+
+func (s *Storage) CodebaseBranch() storage.CodebaseBranchRepository {
+	return codebaseBranchRepository{s}
+}
+
+func (s *Storage) CDPipeline() storage.CDPipelineRepository {
+	return cdPipelineRepository{s}
+}
+
+func (s *Storage) Stage() storage.StageRepository {
+	return stageRepository{s}
+}
+
+func (s *Storage) DockerStream() storage.DockerStreamRepository {
+	return dockerStreamRepository{s}
+}
+
+func (s *Storage) ActionLog() storage.ActionLogRepository {
+	return actionLogRepository{s}
+}
+
+func (s *Storage) Server() storage.ServerRepository {
+	return serverRepository{s}
+}
+
+func (s *Storage) Outbox() storage.OutboxRepository {
+	return outboxRepository{s}
+}
+
+// AddJenkinsSlave, AddJobProvisioning and AddService fill reference data
+// which is created outside of the services under test.
+func (s *Storage) AddJenkinsSlave(schema, name string) int {
+	return s.addReference(schema, jenkinsSlave, name, "")
+}
+
+func (s *Storage) AddJobProvisioning(schema, name, scope string) int {
+	return s.addReference(schema, jobProvisioning, name, scope)
+}
+
+func (s *Storage) AddService(schema, name string) int {
+	return s.addReference(schema, service, name, "")
+}
+
+func (s *Storage) addReference(schema, kind, name, scope string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tenant(schema)
+	id := t.nextId()
+	t.references = append(t.references, referenceRow{id: id, kind: kind, name: name, scope: scope})
+	return id
+}
+
+// ActionLogs returns action log records of the schema in order of creation.
+func (s *Storage) ActionLogs(schema string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var actions []string
+	for _, al := range s.tenant(schema).actionLogs {
+		actions = append(actions, al.log.Action)
+	}
+	return actions
+}
+
+// Events returns events recorded in the outbox of the schema in order of
+// creation.
+func (s *Storage) Events(schema string) []model.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]model.Event(nil), s.tenant(schema).events...)
+}
+
+// Changes returns change log of the codebase in order of recording.
+func (s *Storage) Changes(schema, codebaseName string) []codebase.Change {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tenant(schema)
+	c := t.codebaseByName(codebaseName)
+	if c == nil {
+		return nil
+	}
+	var changes []codebase.Change
+	for _, ch := range t.changes {
+		if ch.codebaseId == c.id {
+			changes = append(changes, ch.change)
+		}
+	}
+	return changes
+}
+
+// PerfDataSource returns configuration of the data source of the type the
+// codebase is linked to, nil if it hasn't been recorded.
+func (s *Storage) PerfDataSource(schema, codebaseName, dsType string) *perfdatasource.PerfDataSource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tenant(schema)
+	c := t.codebaseByName(codebaseName)
+	if c == nil {
+		return nil
+	}
+	for _, r := range t.dataSources {
+		if r.codebaseId == c.id && r.ds.Type == dsType {
+			ds := r.ds
+			return &ds
+		}
+	}
+	return nil
+}
+
+// Status returns status of the codebase, codebase branch ("codebase/branch"),
+// CD pipeline or stage ("pipeline/stage") depending on the kind.
+func (s *Storage) Status(schema, kind, key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tenant(schema)
+	switch kind {
+	case "codebase":
+		if c := t.codebaseByName(key); c != nil {
+			return c.c.Status
+		}
+	case "codebase_branch":
+		for _, b := range t.branches {
+			if c := t.codebaseById(b.codebaseId); c != nil && c.c.Name+"/"+b.b.Name == key {
+				return b.b.Status
+			}
+		}
+	case "cd_pipeline":
+		if p := t.pipelineByName(key); p != nil {
+			return p.Status
+		}
+	case "cd_stage":
+		for _, st := range t.stages {
+			if p := t.pipelineById(st.pipelineId); p != nil && p.Name+"/"+st.s.Name == key {
+				return st.s.Status
+			}
+		}
+	}
+	return ""
+}
+
+func (s *Storage) tenant(schema string) *tenant {
+	t, ok := s.tenants[schema]
+	if !ok {
+		t = &tenant{}
+		s.tenants[schema] = t
+	}
+	return t
+}
@@ -0,0 +1,20 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
+)
+
+type outboxRepository struct {
+	s *Storage
+}
+
+func (r outboxRepository) Put(_ storage.Tx, e model.Event, schema string) error {
+	t := r.s.tenant(schema)
+	e.Id = t.nextId()
+	e.CreatedAt = time.Now()
+	t.events = append(t.events, e)
+	return nil
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
+)
+
+type serverRepository struct {
+	s *Storage
+}
+
+func (r serverRepository) get(kind, name, schema string) (*int, error) {
+	if s := r.s.tenant(schema).server(kind, name); s != nil {
+		id := s.id
+		return &id, nil
+	}
+	return nil, nil
+}
+
+func (r serverRepository) create(kind, name, hostname string, available bool, schema string) *int {
+	t := r.s.tenant(schema)
+	id := t.nextId()
+	t.servers = append(t.servers, serverRow{id: id, kind: kind, name: name, hostname: hostname, available: available})
+	return &id
+}
+
+func (r serverRepository) update(kind string, id int, available bool, schema string) {
+	if s := r.s.tenant(schema).serverById(kind, id); s != nil {
+		s.available = available
+	}
+}
+
+func (r serverRepository) GetGitServerId(_ storage.Tx, name, schema string) (*int, error) {
+	return r.get(gitServer, name, schema)
+}
+
+func (r serverRepository) CreateGitServer(_ storage.Tx, name, hostname string, available bool, schema string) (*int, error) {
+	return r.create(gitServer, name, hostname, available, schema), nil
+}
+
+func (r serverRepository) UpdateGitServer(_ storage.Tx, id int, available bool, schema string) error {
+	r.update(gitServer, id, available, schema)
+	return nil
+}
+
+func (r serverRepository) GetJiraServerId(_ storage.Tx, name, schema string) (*int, error) {
+	return r.get(jiraServer, name, schema)
+}
+
+func (r serverRepository) CreateJiraServer(_ storage.Tx, name string, available bool, schema string) error {
+	r.create(jiraServer, name, "", available, schema)
+	return nil
+}
+
+func (r serverRepository) UpdateJiraServer(_ storage.Tx, id int, available bool, schema string) error {
+	r.update(jiraServer, id, available, schema)
+	return nil
+}
+
+func (r serverRepository) GetPerfServerId(_ storage.Tx, name, schema string) (*int, error) {
+	return r.get(perfServer, name, schema)
+}
+
+func (r serverRepository) CreatePerfServer(_ storage.Tx, name string, available bool, schema string) error {
+	r.create(perfServer, name, "", available, schema)
+	return nil
+}
+
+func (r serverRepository) UpdatePerfServer(_ storage.Tx, id int, available bool, schema string) error {
+	r.update(perfServer, id, available, schema)
+	return nil
+}
+
+func (r serverRepository) GetJenkinsSlaveId(_ storage.Tx, name, schema string) (*int, error) {
+	return r.s.tenant(schema).reference(jenkinsSlave, name, ""), nil
+}
+
+func (r serverRepository) GetJobProvisioningId(_ storage.Tx, name, scope, schema string) (*int, error) {
+	return r.s.tenant(schema).reference(jobProvisioning, name, scope), nil
+}
+
+func (r serverRepository) GetServiceId(_ storage.Tx, name, schema string) (*int, error) {
+	return r.s.tenant(schema).reference(service, name, ""), nil
+}
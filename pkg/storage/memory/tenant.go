@@ -0,0 +1,379 @@
+package memory
+
+import (
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebase"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebasebranch"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/perfdatasource"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/stage"
+)
+
+const (
+	gitServer       = "git"
+	jiraServer      = "jira"
+	perfServer      = "perf"
+	jenkinsSlave    = "jenkins_slave"
+	jobProvisioning = "job_provisioning"
+	service         = "third_party_service"
+	perfDataSource  = "perf_data_source"
+
+	pipelineStream         = "cd_pipeline_docker_stream"
+	pipelineService        = "cd_pipeline_third_party_service"
+	applicationToPromote   = "applications_to_promote"
+	codebaseAction         = "codebase_action"
+	pipelineAction         = "cd_pipeline_action_log"
+	stageAction            = "cd_stage_action_log"
+	branchAction           = "codebase_branch_action_log"
+	codebasePerfDataSource = "codebase_perf_data_sources"
+)
+
+// tenant holds tables of a single schema. Rows are kept by value,
+// so copying the slices is enough to take a snapshot.
+type tenant struct {
+	seq          int
+	codebases    []codebaseRow
+	branches     []branchRow
+	streams      []streamRow
+	pipelines    []model.CDPipelineDTO
+	links        []linkRow
+	stages       []stageRow
+	stageStreams []stageStreamRow
+	qualityGates []qualityGateRow
+	actionLogs   []actionLogRow
+	servers      []serverRow
+	references   []referenceRow
+	events       []model.Event
+	changes      []changeRow
+	locations    []locationRow
+	dataSources  []dataSourceRow
+	builds       []buildRow
+}
+
+type codebaseRow struct {
+	id int
+	c  codebase.Codebase
+}
+
+type changeRow struct {
+	codebaseId int
+	change     codebase.Change
+}
+
+type locationRow struct {
+	codebaseId int
+	location   codebase.RepositoryLocation
+}
+
+// dataSourceRow is configuration of the data source the codebase is linked to.
+type dataSourceRow struct {
+	codebaseId int
+	ds         perfdatasource.PerfDataSource
+}
+
+type branchRow struct {
+	id         int
+	codebaseId int
+	streamId   *int
+	b          codebasebranch.CodebaseBranch
+}
+
+type buildRow struct {
+	branchId int
+	build    codebasebranch.Build
+}
+
+type streamRow struct {
+	id       int
+	name     string
+	branchId *int
+}
+
+// linkRow is a row of many-to-many relation table of the kind.
+type linkRow struct {
+	kind string
+	from int
+	to   int
+}
+
+type stageRow struct {
+	id         int
+	pipelineId int
+	s          stage.Stage
+}
+
+type stageStreamRow struct {
+	stageId  int
+	inputId  int
+	outputId int
+}
+
+type qualityGateRow struct {
+	id         int
+	stageId    int
+	gate       string
+	stepName   string
+	codebaseId *int
+	branchId   *int
+}
+
+type actionLogRow struct {
+	id  int
+	log model.ActionLog
+}
+
+type serverRow struct {
+	id        int
+	kind      string
+	name      string
+	hostname  string
+	available bool
+}
+
+type referenceRow struct {
+	id    int
+	kind  string
+	name  string
+	scope string
+}
+
+func (t *tenant) clone() *tenant {
+	return &tenant{
+		seq:          t.seq,
+		codebases:    append([]codebaseRow(nil), t.codebases...),
+		branches:     append([]branchRow(nil), t.branches...),
+		streams:      append([]streamRow(nil), t.streams...),
+		pipelines:    append([]model.CDPipelineDTO(nil), t.pipelines...),
+		links:        append([]linkRow(nil), t.links...),
+		stages:       append([]stageRow(nil), t.stages...),
+		stageStreams: append([]stageStreamRow(nil), t.stageStreams...),
+		qualityGates: append([]qualityGateRow(nil), t.qualityGates...),
+		actionLogs:   append([]actionLogRow(nil), t.actionLogs...),
+		servers:      append([]serverRow(nil), t.servers...),
+		references:   append([]referenceRow(nil), t.references...),
+		events:       append([]model.Event(nil), t.events...),
+		changes:      append([]changeRow(nil), t.changes...),
+		locations:    append([]locationRow(nil), t.locations...),
+		dataSources:  append([]dataSourceRow(nil), t.dataSources...),
+		builds:       append([]buildRow(nil), t.builds...),
+	}
+}
+
+func (t *tenant) nextId() int {
+	t.seq++
+	return t.seq
+}
+
+func (t *tenant) codebaseByName(name string) *codebaseRow {
+	for i := range t.codebases {
+		if t.codebases[i].c.Name == name {
+			return &t.codebases[i]
+		}
+	}
+	return nil
+}
+
+func (t *tenant) codebaseById(id int) *codebaseRow {
+	for i := range t.codebases {
+		if t.codebases[i].id == id {
+			return &t.codebases[i]
+		}
+	}
+	return nil
+}
+
+func (t *tenant) branchById(id int) *branchRow {
+	for i := range t.branches {
+		if t.branches[i].id == id {
+			return &t.branches[i]
+		}
+	}
+	return nil
+}
+
+func (t *tenant) streamById(id int) *streamRow {
+	for i := range t.streams {
+		if t.streams[i].id == id {
+			return &t.streams[i]
+		}
+	}
+	return nil
+}
+
+func (t *tenant) actionLogById(id int) *model.ActionLog {
+	for i := range t.actionLogs {
+		if t.actionLogs[i].id == id {
+			return &t.actionLogs[i].log
+		}
+	}
+	return nil
+}
+
+func (t *tenant) pipelineByName(name string) *model.CDPipelineDTO {
+	for i := range t.pipelines {
+		if t.pipelines[i].Name == name {
+			return &t.pipelines[i]
+		}
+	}
+	return nil
+}
+
+func (t *tenant) pipelineById(id int) *model.CDPipelineDTO {
+	for i := range t.pipelines {
+		if t.pipelines[i].Id == id {
+			return &t.pipelines[i]
+		}
+	}
+	return nil
+}
+
+func (t *tenant) stage(pipeline, name string) *stageRow {
+	for i := range t.stages {
+		p := t.pipelineById(t.stages[i].pipelineId)
+		if p != nil && p.Name == pipeline && t.stages[i].s.Name == name {
+			return &t.stages[i]
+		}
+	}
+	return nil
+}
+
+func (t *tenant) server(kind, name string) *serverRow {
+	for i := range t.servers {
+		if t.servers[i].kind == kind && t.servers[i].name == name {
+			return &t.servers[i]
+		}
+	}
+	return nil
+}
+
+func (t *tenant) serverById(kind string, id int) *serverRow {
+	for i := range t.servers {
+		if t.servers[i].kind == kind && t.servers[i].id == id {
+			return &t.servers[i]
+		}
+	}
+	return nil
+}
+
+func (t *tenant) reference(kind, name, scope string) *int {
+	for _, r := range t.references {
+		if r.kind == kind && r.name == name && r.scope == scope {
+			id := r.id
+			return &id
+		}
+	}
+	return nil
+}
+
+func (t *tenant) referenceById(id int) *referenceRow {
+	for i := range t.references {
+		if t.references[i].id == id {
+			return &t.references[i]
+		}
+	}
+	return nil
+}
+
+func (t *tenant) link(kind string, from, to int) {
+	t.links = append(t.links, linkRow{kind: kind, from: from, to: to})
+}
+
+// linked returns ids the row is related to by the relation of the kind.
+func (t *tenant) linked(kind string, from int) []int {
+	var ids []int
+	for _, l := range t.links {
+		if l.kind == kind && l.from == from {
+			ids = append(ids, l.to)
+		}
+	}
+	return ids
+}
+
+// unlink removes relations of the kind the row takes part in.
+func (t *tenant) unlink(kind string, from int) {
+	links := t.links[:0]
+	for _, l := range t.links {
+		if l.kind != kind || l.from != from {
+			links = append(links, l)
+		}
+	}
+	t.links = links
+}
+
+// unlinkRow removes the relation of the kind between the rows.
+func (t *tenant) unlinkRow(kind string, from, to int) {
+	links := t.links[:0]
+	for _, l := range t.links {
+		if l.kind != kind || l.from != from || l.to != to {
+			links = append(links, l)
+		}
+	}
+	t.links = links
+}
+
+// streamDTO resolves codebase the docker stream is built from.
+func (t *tenant) streamDTO(id int) model.CodebaseDockerStreamReadDTO {
+	dto := model.CodebaseDockerStreamReadDTO{CodebaseDockerStreamId: id}
+	s := t.streamById(id)
+	if s == nil || s.branchId == nil {
+		return dto
+	}
+	b := t.branchById(*s.branchId)
+	if b == nil {
+		return dto
+	}
+	if c := t.codebaseById(b.codebaseId); c != nil {
+		dto.CodebaseId = c.id
+		dto.CodebaseName = c.c.Name
+	}
+	return dto
+}
+
+// deleteStreams removes docker streams along with the stage links they
+// take part in as output.
+// deleteBranches removes the branches along with their docker streams.
+func (t *tenant) deleteBranches(ids map[int]bool) {
+	branches := t.branches[:0]
+	for _, b := range t.branches {
+		if !ids[b.id] {
+			branches = append(branches, b)
+		}
+	}
+	t.branches = branches
+
+	streams := map[int]bool{}
+	for _, s := range t.streams {
+		if s.branchId != nil && ids[*s.branchId] {
+			streams[s.id] = true
+		}
+	}
+	t.deleteStreams(streams)
+}
+
+func (t *tenant) deleteStreams(ids map[int]bool) {
+	streams := t.streams[:0]
+	for _, s := range t.streams {
+		if !ids[s.id] {
+			streams = append(streams, s)
+		}
+	}
+	t.streams = streams
+	links := t.stageStreams[:0]
+	for _, l := range t.stageStreams {
+		if !ids[l.outputId] {
+			links = append(links, l)
+		}
+	}
+	t.stageStreams = links
+}
+
+// removeDataSources removes configuration of the data sources of the codebase
+// matching the type, any type matches if it is empty.
+func (t *tenant) removeDataSources(codebaseId int, dsType string) {
+	rows := t.dataSources[:0]
+	for _, r := range t.dataSources {
+		if r.codebaseId != codebaseId || (dsType != "" && r.ds.Type != dsType) {
+			rows = append(rows, r)
+		}
+	}
+	t.dataSources = rows
+}
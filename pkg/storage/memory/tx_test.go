@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+const schema = "fake-schema"
+
+func gitServerId(t *testing.T, s *Storage, name string) *int {
+	tx, err := s.Begin()
+	assert.NoError(t, err)
+	defer tx.Commit()
+	id, err := s.Server().GetGitServerId(tx, name, schema)
+	assert.NoError(t, err)
+	return id
+}
+
+func createGitServer(s *Storage, name string) func(tx storage.Tx) error {
+	return func(tx storage.Tx) error {
+		_, err := s.Server().CreateGitServer(tx, name, "fake-host", true, schema)
+		return err
+	}
+}
+
+func TestWithTx_ShouldCommitOnSuccess(t *testing.T) {
+	s := New()
+
+	assert.NoError(t, storage.WithTx(context.Background(), s, createGitServer(s, "fake-git")))
+	assert.NotNil(t, gitServerId(t, s, "fake-git"))
+}
+
+func TestWithTx_ShouldRollbackOnError(t *testing.T) {
+	s := New()
+
+	err := storage.WithTx(context.Background(), s, func(tx storage.Tx) error {
+		if err := createGitServer(s, "fake-git")(tx); err != nil {
+			return err
+		}
+		return errors.New("fake error")
+	})
+
+	assert.EqualError(t, err, "fake error")
+	assert.Nil(t, gitServerId(t, s, "fake-git"))
+}
+
+func TestWithTx_ShouldRollbackOnPanic(t *testing.T) {
+	s := New()
+
+	assert.Panics(t, func() {
+		_ = storage.WithTx(context.Background(), s, func(tx storage.Tx) error {
+			_ = createGitServer(s, "fake-git")(tx)
+			panic("fake panic")
+		})
+	})
+	assert.Nil(t, gitServerId(t, s, "fake-git"))
+}
+
+func TestWithTx_ShouldRollbackCancelledTx(t *testing.T) {
+	s := New()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := storage.WithTx(ctx, s, func(tx storage.Tx) error {
+		cancel()
+		return createGitServer(s, "fake-git")(tx)
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, gitServerId(t, s, "fake-git"))
+}
+
+func TestNested_ShouldRollbackOnlyFailedStep(t *testing.T) {
+	s := New()
+
+	err := storage.WithTx(context.Background(), s, func(tx storage.Tx) error {
+		if err := storage.Nested(tx, "first", func() error { return createGitServer(s, "fake-git")(tx) }); err != nil {
+			return err
+		}
+		err := storage.Nested(tx, "second", func() error {
+			_ = createGitServer(s, "other-git")(tx)
+			return errors.New("fake error")
+		})
+		assert.EqualError(t, err, "fake error")
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.NotNil(t, gitServerId(t, s, "fake-git"))
+	assert.Nil(t, gitServerId(t, s, "other-git"))
+}
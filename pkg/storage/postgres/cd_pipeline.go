@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/cdpipeline"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/stage"
+	"github.com/epmd-edp/reconciler/v2/pkg/repository"
+	sr "github.com/epmd-edp/reconciler/v2/pkg/repository/stage"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
+)
+
+type cdPipelineRepository struct{}
+
+func (cdPipelineRepository) Get(tx storage.Tx, name, schema string) (*model.CDPipelineDTO, error) {
+	return repository.GetCDPipeline(txn(tx), name, schema)
+}
+
+func (cdPipelineRepository) Create(tx storage.Tx, p cdpipeline.CDPipeline, schema string) (*model.CDPipelineDTO, error) {
+	return repository.CreateCDPipeline(txn(tx), p, p.Status, schema)
+}
+
+func (cdPipelineRepository) UpdateStatus(tx storage.Tx, id int, status, schema string) error {
+	return repository.UpdateCDPipelineStatus(txn(tx), id, status, schema)
+}
+
+func (cdPipelineRepository) Delete(tx storage.Tx, name, schema string) error {
+	return repository.DeleteCDPipeline(txn(tx), name, schema)
+}
+
+func (cdPipelineRepository) AddThirdPartyService(tx storage.Tx, pipelineId, serviceId int, schema string) error {
+	return repository.CreateCDPipelineThirdPartyService(txn(tx), pipelineId, serviceId, schema)
+}
+
+func (cdPipelineRepository) AddDockerStream(tx storage.Tx, pipelineId, streamId int, schema string) error {
+	return repository.CreateCDPipelineDockerStream(txn(tx), pipelineId, streamId, schema)
+}
+
+func (cdPipelineRepository) RemoveDockerStreams(tx storage.Tx, pipelineId int, schema string) error {
+	return repository.DeleteCDPipelineDockerStreams(txn(tx), pipelineId, schema)
+}
+
+func (cdPipelineRepository) AddApplicationToPromote(tx storage.Tx, pipelineId, codebaseId int, schema string) error {
+	return repository.CreateApplicationsToPromote(txn(tx), pipelineId, codebaseId, schema)
+}
+
+func (cdPipelineRepository) RemoveApplicationsToPromote(tx storage.Tx, pipelineId int, schema string) error {
+	return repository.RemoveApplicationsToPromote(txn(tx), pipelineId, schema)
+}
+
+type stageRepository struct{}
+
+func (stageRepository) GetId(tx storage.Tx, pipeline, name, schema string) (*int, error) {
+	return sr.GetStageId(txn(tx), schema, name, pipeline)
+}
+
+func (stageRepository) GetIdByOrder(tx storage.Tx, pipeline string, order int, schema string) (*int, error) {
+	return sr.GetStageIdByPipelineNameAndOrder(txn(tx), schema, pipeline, order)
+}
+
+func (stageRepository) GetStages(tx storage.Tx, pipeline, schema string) ([]stage.Stage, error) {
+	return sr.GetStages(txn(tx), pipeline, schema)
+}
+
+func (stageRepository) Create(tx storage.Tx, s stage.Stage, pipelineId int) (*int, error) {
+	return sr.CreateStage(txn(tx), s, pipelineId)
+}
+
+func (stageRepository) UpdateStatus(tx storage.Tx, id int, status, schema string) error {
+	return sr.UpdateStageStatus(txn(tx), schema, id, status)
+}
+
+func (stageRepository) Delete(tx storage.Tx, pipeline, name, schema string) error {
+	return sr.DeleteCDStage(txn(tx), pipeline, name, schema)
+}
+
+func (stageRepository) CreateQualityGate(tx storage.Tx, gate, stepName string, stageId int, codebaseId, branchId *int,
+	schema string) (*int, error) {
+	return sr.CreateQualityGate(txn(tx), gate, stepName, stageId, codebaseId, branchId, schema)
+}
+
+func (stageRepository) GetAutotestIds(tx storage.Tx, autotest, branch, schema string) (*model.CodebaseBranchIdDTO, error) {
+	return sr.GetCodebaseAndBranchIds(txn(tx), autotest, branch, schema)
+}
+
+type dockerStreamRepository struct{}
+
+func (dockerStreamRepository) Create(tx storage.Tx, branchId *int, name, schema string) (*int, error) {
+	return repository.CreateCodebaseDockerStream(txn(tx), schema, branchId, name)
+}
+
+func (dockerStreamRepository) GetId(tx storage.Tx, name, schema string) (*int, error) {
+	return repository.GetCodebaseDockerStreamId(txn(tx), name, schema)
+}
+
+func (dockerStreamRepository) GetBranchId(tx storage.Tx, id int, schema string) (*int, error) {
+	return repository.GetCodebaseDockerStreamBranchId(txn(tx), id, schema)
+}
+
+func (dockerStreamRepository) GetCodebaseStreams(tx storage.Tx, codebase,
+	schema string) ([]model.CodebaseBranchDockerStreamDTO, error) {
+	return repository.GetCodebaseBranchDockerStreams(txn(tx), codebase, schema)
+}
+
+func (dockerStreamRepository) SetBranchId(tx storage.Tx, id, branchId int, schema string) error {
+	return repository.UpdateBranchIdCodebaseDockerStream(txn(tx), id, branchId, schema)
+}
+
+func (dockerStreamRepository) Delete(tx storage.Tx, id int, schema string) error {
+	return sr.DeleteCodebaseDockerStream(txn(tx), id, schema)
+}
+
+func (dockerStreamRepository) GetPipelineStreams(tx storage.Tx, pipeline, schema string) ([]model.CodebaseDockerStreamReadDTO, error) {
+	return repository.GetDockerStreamsByPipelineName(txn(tx), schema, pipeline)
+}
+
+func (dockerStreamRepository) GetStageStreams(tx storage.Tx, pipeline string, order int,
+	schema string) ([]model.CodebaseDockerStreamReadDTO, error) {
+	return repository.GetDockerStreamsByPipelineNameAndStageOrder(txn(tx), schema, pipeline, order)
+}
+
+func (dockerStreamRepository) GetSourceStream(tx storage.Tx, pipeline, codebase, schema string) (*int, error) {
+	return repository.GetSourceInputStream(txn(tx), pipeline, codebase, schema)
+}
+
+func (dockerStreamRepository) GetStageOutputStream(tx storage.Tx, pipeline, stage, schema string) (*int, error) {
+	return sr.SelectCodebaseDockerStreamId(txn(tx), pipeline, stage, schema)
+}
+
+func (dockerStreamRepository) LinkStage(tx storage.Tx, stageId, inputId, outputId int, schema string) error {
+	return repository.CreateStageCodebaseDockerStream(txn(tx), schema, stageId, inputId, outputId)
+}
+
+func (dockerStreamRepository) UnlinkStage(tx storage.Tx, stageId int, schema string) ([]int, error) {
+	return repository.DeleteStageCodebaseDockerStream(txn(tx), stageId, schema)
+}
+
+func (dockerStreamRepository) DeletePipelineStreams(tx storage.Tx, pipeline, schema string) ([]int, error) {
+	return sr.DeleteCodebaseDockerStreams(txn(tx), pipeline, schema)
+}
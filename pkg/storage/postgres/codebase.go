@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebase"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebasebranch"
+	pds "github.com/epmd-edp/reconciler/v2/pkg/model/perfdatasource"
+	"github.com/epmd-edp/reconciler/v2/pkg/repository"
+	cbs "github.com/epmd-edp/reconciler/v2/pkg/repository/codebasebranch"
+	"github.com/epmd-edp/reconciler/v2/pkg/repository/codebaseperfdatasource"
+	"github.com/epmd-edp/reconciler/v2/pkg/repository/perfdatasource"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
+)
+
+type codebaseRepository struct{}
+
+func (codebaseRepository) GetId(tx storage.Tx, name, schema string) (*int, error) {
+	return repository.GetCodebaseId(txn(tx), name, schema)
+}
+
+func (codebaseRepository) GetApplicationId(tx storage.Tx, name, schema string) (*int, error) {
+	return repository.GetApplicationId(txn(tx), name, schema)
+}
+
+func (codebaseRepository) GetType(tx storage.Tx, id int, schema string) (*string, error) {
+	return repository.GetCodebaseTypeById(txn(tx), id, schema)
+}
+
+func (codebaseRepository) Get(tx storage.Tx, name, schema string) (*codebase.Codebase, error) {
+	return repository.SelectCodebase(txn(tx), name, schema)
+}
+
+func (codebaseRepository) Create(tx storage.Tx, c codebase.Codebase, schema string) (*int, error) {
+	return repository.CreateCodebase(txn(tx), c, schema)
+}
+
+func (codebaseRepository) Update(tx storage.Tx, c codebase.Codebase, schema string) error {
+	return repository.Update(txn(tx), c, schema)
+}
+
+func (codebaseRepository) LogChanges(tx storage.Tx, id int, changes []codebase.Change, schema string) error {
+	return repository.InsertCodebaseChanges(txn(tx), id, changes, schema)
+}
+
+func (codebaseRepository) AddPreviousLocation(tx storage.Tx, id int, l codebase.RepositoryLocation, schema string) error {
+	return repository.InsertRepositoryHistory(txn(tx), id, l, schema)
+}
+
+func (codebaseRepository) GetPreviousLocations(tx storage.Tx, name, schema string) ([]codebase.RepositoryLocation, error) {
+	return repository.SelectRepositoryHistory(txn(tx), name, schema)
+}
+
+func (codebaseRepository) UpdateStatus(tx storage.Tx, id int, status, schema string) error {
+	return repository.UpdateStatusByCodebaseId(txn(tx), id, status, schema)
+}
+
+func (codebaseRepository) Delete(tx storage.Tx, name, schema string) error {
+	return repository.Delete(txn(tx), name, schema)
+}
+
+func (codebaseRepository) DeletePerfDataSources(tx storage.Tx, id int, schema string) error {
+	return codebaseperfdatasource.DeleteCodebasePerfDataSourceRecord(txn(tx), id, schema)
+}
+
+func (codebaseRepository) GetPerfDataSources(tx storage.Tx, id int, schema string) ([]string, error) {
+	return codebaseperfdatasource.SelectCodebasePerfDataSources(txn(tx), id, schema)
+}
+
+func (codebaseRepository) AddPerfDataSource(tx storage.Tx, id int, dsType, schema string) error {
+	exists, err := perfdatasource.PerfDataSourceExists(txn(tx), dsType, schema)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := perfdatasource.InsertPerfDataSource(txn(tx), dsType, schema); err != nil {
+			return err
+		}
+	}
+	dsId, err := perfdatasource.GetDataSourceId(txn(tx), dsType, schema)
+	if err != nil {
+		return err
+	}
+	return codebaseperfdatasource.InsertCodebasePerfDataSource(txn(tx), id, *dsId, schema)
+}
+
+func (codebaseRepository) RemovePerfDataSource(tx storage.Tx, id int, dsType, schema string) error {
+	return codebaseperfdatasource.DeleteCodebasePerfDataSource(txn(tx), id, dsType, schema)
+}
+
+func (codebaseRepository) UpdatePerfDataSource(tx storage.Tx, id int, ds pds.PerfDataSource, schema string) error {
+	return codebaseperfdatasource.UpdateCodebasePerfDataSourceConfig(txn(tx), id, ds, schema)
+}
+
+type codebaseBranchRepository struct{}
+
+func (codebaseBranchRepository) GetId(tx storage.Tx, codebase, branch, schema string) (*int, error) {
+	return cbs.GetCodebaseBranchId(txn(tx), codebase, branch, schema)
+}
+
+func (codebaseBranchRepository) GetNames(tx storage.Tx, codebase, schema string) ([]string, error) {
+	return cbs.GetCodebaseBranchNames(txn(tx), codebase, schema)
+}
+
+func (codebaseBranchRepository) Get(tx storage.Tx, codebase, branch, schema string) (*codebasebranch.CodebaseBranch, error) {
+	return cbs.GetCodebaseBranch(txn(tx), codebase, branch, schema)
+}
+
+func (codebaseBranchRepository) Create(tx storage.Tx, b codebasebranch.CodebaseBranch, codebaseId int,
+	streamId *int, schema string) (*int, error) {
+	return cbs.CreateCodebaseBranch(txn(tx), b.Name, codebaseId, b.FromCommit, schema, streamId, b.Status,
+		b.Version, b.BuildNumber, b.LastSuccessBuild, b.Release)
+}
+
+func (codebaseBranchRepository) Update(tx storage.Tx, id int, b codebasebranch.CodebaseBranch, schema string) error {
+	return cbs.UpdateCodebaseBranch(txn(tx), id, b, schema)
+}
+
+func (codebaseBranchRepository) AddBuild(tx storage.Tx, id int, b codebasebranch.Build, schema string) error {
+	return cbs.InsertBuildHistory(txn(tx), id, b, schema)
+}
+
+func (codebaseBranchRepository) GetBuilds(tx storage.Tx, codebase, branch, schema string) ([]codebasebranch.Build, error) {
+	return cbs.SelectBuildHistory(txn(tx), codebase, branch, schema)
+}
+
+func (codebaseBranchRepository) UpdateStatus(tx storage.Tx, id int, status, schema string) error {
+	return cbs.UpdateStatusByCodebaseBranchId(txn(tx), id, status, schema)
+}
+
+func (codebaseBranchRepository) Delete(tx storage.Tx, codebase, branch, schema string) error {
+	return cbs.Delete(txn(tx), codebase, branch, schema)
+}
+
+type actionLogRepository struct{}
+
+func (actionLogRepository) PutToCodebase(tx storage.Tx, codebaseId int, al model.ActionLog, schema string) (bool, error) {
+	return repository.PutCodebaseActionLog(txn(tx), codebaseId, al, schema)
+}
+
+func (actionLogRepository) PutToCodebaseBranch(tx storage.Tx, branchId int, al model.ActionLog, schema string) (bool, error) {
+	return repository.PutCodebaseBranchActionLog(txn(tx), branchId, al, schema)
+}
+
+func (actionLogRepository) PutToCDPipeline(tx storage.Tx, pipelineId int, al model.ActionLog, schema string) (bool, error) {
+	return repository.PutCDPipelineActionLog(txn(tx), pipelineId, al, schema)
+}
+
+func (actionLogRepository) PutToCDStage(tx storage.Tx, stageId int, al model.ActionLog, schema string) (bool, error) {
+	return repository.PutCDStageActionLog(txn(tx), stageId, al, schema)
+}
+
+func (actionLogRepository) GetCodebaseTimeline(tx storage.Tx, codebase string, f model.TimelineFilter, schema string) ([]model.TimelineEntry, error) {
+	return repository.GetCodebaseTimeline(txn(tx), codebase, f, schema)
+}
@@ -0,0 +1,14 @@
+package postgres
+
+import (
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/epmd-edp/reconciler/v2/pkg/repository/outbox"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
+)
+
+type outboxRepository struct{}
+
+func (outboxRepository) Put(tx storage.Tx, e model.Event, schema string) error {
+	_, err := outbox.InsertEvent(txn(tx), e, schema)
+	return err
+}
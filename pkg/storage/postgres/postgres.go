@@ -0,0 +1,109 @@
+// Package postgres implements storage on top of the SQL repositories.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/metrics"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
+	"github.com/lib/pq"
+)
+
+type Storage struct {
+	DB *sql.DB
+}
+
+// Tx wraps sql transaction to be passed through the repositories.
+// It records transaction duration and rollbacks in metrics.
+type Tx struct {
+	*sql.Tx
+	start time.Time
+}
+
+func New(db *sql.DB) Storage {
+	return Storage{DB: db}
+}
+
+func (s Storage) BeginTx(ctx context.Context) (storage.Tx, error) {
+	txn, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return Tx{Tx: txn, start: time.Now()}, nil
+}
+
+// WithTx runs fn in a unit of work for the services working with the SQL
+// repositories directly, see storage.WithTx.
+func WithTx(ctx context.Context, db *sql.DB, fn func(txn *sql.Tx) error) error {
+	return storage.WithTx(ctx, New(db), func(tx storage.Tx) error {
+		return fn(tx.(Tx).Tx)
+	})
+}
+
+func (t Tx) Commit() error {
+	err := t.Tx.Commit()
+	metrics.ObserveTx(t.start, err != nil)
+	return err
+}
+
+func (t Tx) Rollback() error {
+	err := t.Tx.Rollback()
+	if err != sql.ErrTxDone {
+		metrics.ObserveTx(t.start, true)
+	}
+	return err
+}
+
+func (t Tx) Savepoint(name string) error {
+	_, err := t.Exec("savepoint " + pq.QuoteIdentifier(name))
+	return err
+}
+
+func (t Tx) RollbackTo(name string) error {
+	_, err := t.Exec("rollback to savepoint " + pq.QuoteIdentifier(name))
+	return err
+}
+
+func (t Tx) Release(name string) error {
+	_, err := t.Exec("release savepoint " + pq.QuoteIdentifier(name))
+	return err
+}
+
+func (s Storage) Codebase() storage.CodebaseRepository {
+	return codebaseRepository{}
+}
+
+func (s Storage) CodebaseBranch() storage.CodebaseBranchRepository {
+	return codebaseBranchRepository{}
+}
+
+func (s Storage) CDPipeline() storage.CDPipelineRepository {
+	return cdPipelineRepository{}
+}
+
+func (s Storage) Stage() storage.StageRepository {
+	return stageRepository{}
+}
+
+func (s Storage) DockerStream() storage.DockerStreamRepository {
+	return dockerStreamRepository{}
+}
+
+func (s Storage) ActionLog() storage.ActionLogRepository {
+	return actionLogRepository{}
+}
+
+func (s Storage) Server() storage.ServerRepository {
+	return serverRepository{}
+}
+
+func (s Storage) Outbox() storage.OutboxRepository {
+	return outboxRepository{}
+}
+
+// txn returns sql transaction in the form the repositories accept it.
+func txn(tx storage.Tx) sql.Tx {
+	return *tx.(Tx).Tx
+}
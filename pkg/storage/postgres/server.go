@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"github.com/epmd-edp/reconciler/v2/pkg/repository"
+	jenkinsslave "github.com/epmd-edp/reconciler/v2/pkg/repository/jenkins-slave"
+	jiraserver "github.com/epmd-edp/reconciler/v2/pkg/repository/jira-server"
+	jp "github.com/epmd-edp/reconciler/v2/pkg/repository/job-provisioning"
+	"github.com/epmd-edp/reconciler/v2/pkg/repository/perfserver"
+	"github.com/epmd-edp/reconciler/v2/pkg/repository/thirdpartyservice"
+	"github.com/epmd-edp/reconciler/v2/pkg/storage"
+)
+
+type serverRepository struct{}
+
+func (serverRepository) GetGitServerId(tx storage.Tx, name, schema string) (*int, error) {
+	return repository.SelectGitServer(txn(tx), name, schema)
+}
+
+func (serverRepository) CreateGitServer(tx storage.Tx, name, hostname string, available bool, schema string) (*int, error) {
+	return repository.CreateGitServer(txn(tx), name, hostname, available, schema)
+}
+
+func (serverRepository) UpdateGitServer(tx storage.Tx, id int, available bool, schema string) error {
+	return repository.UpdateGitServer(txn(tx), &id, available, schema)
+}
+
+func (serverRepository) GetJiraServerId(tx storage.Tx, name, schema string) (*int, error) {
+	return jiraserver.SelectJiraServer(txn(tx), name, schema)
+}
+
+func (serverRepository) CreateJiraServer(tx storage.Tx, name string, available bool, schema string) error {
+	return jiraserver.CreateJiraServer(txn(tx), name, available, schema)
+}
+
+func (serverRepository) UpdateJiraServer(tx storage.Tx, id int, available bool, schema string) error {
+	return jiraserver.UpdateJiraServer(txn(tx), &id, available, schema)
+}
+
+func (serverRepository) GetPerfServerId(tx storage.Tx, name, schema string) (*int, error) {
+	return perfserver.SelectPerfServer(txn(tx), name, schema)
+}
+
+func (serverRepository) CreatePerfServer(tx storage.Tx, name string, available bool, schema string) error {
+	return perfserver.CreatePerfServer(txn(tx), name, available, schema)
+}
+
+func (serverRepository) UpdatePerfServer(tx storage.Tx, id int, available bool, schema string) error {
+	return perfserver.UpdatePerfServer(txn(tx), &id, available, schema)
+}
+
+func (serverRepository) GetJenkinsSlaveId(tx storage.Tx, name, schema string) (*int, error) {
+	return jenkinsslave.SelectJenkinsSlave(txn(tx), name, schema)
+}
+
+func (serverRepository) GetJobProvisioningId(tx storage.Tx, name, scope, schema string) (*int, error) {
+	return jp.SelectJobProvision(txn(tx), name, scope, schema)
+}
+
+func (serverRepository) GetServiceId(tx storage.Tx, name, schema string) (*int, error) {
+	return thirdpartyservice.GetService(txn(tx), name, schema)
+}
@@ -0,0 +1,175 @@
+// Package storage declares repositories of the aggregates reconciler
+// projects custom resources to. Services depend on these interfaces only,
+// so the store can be replaced, e.g. by in-memory one in unit tests.
+package storage
+
+import (
+	"context"
+
+	"github.com/epmd-edp/reconciler/v2/pkg/model"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/cdpipeline"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebase"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/codebasebranch"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/perfdatasource"
+	"github.com/epmd-edp/reconciler/v2/pkg/model/stage"
+)
+
+// Tx is a transaction all repository calls of a single operation share.
+// Services don't manage it directly but run their operations in WithTx.
+type Tx interface {
+	Commit() error
+	Rollback() error
+	// Savepoint, RollbackTo and Release manage savepoints steps run in
+	// Nested are isolated with.
+	Savepoint(name string) error
+	RollbackTo(name string) error
+	Release(name string) error
+}
+
+// Storage opens transactions and gives access to the repositories.
+type Storage interface {
+	// BeginTx opens transaction which is rolled back once ctx is done.
+	BeginTx(ctx context.Context) (Tx, error)
+	Codebase() CodebaseRepository
+	CodebaseBranch() CodebaseBranchRepository
+	CDPipeline() CDPipelineRepository
+	Stage() StageRepository
+	DockerStream() DockerStreamRepository
+	ActionLog() ActionLogRepository
+	Server() ServerRepository
+	Outbox() OutboxRepository
+}
+
+type CodebaseRepository interface {
+	GetId(tx Tx, name, schema string) (*int, error)
+	GetApplicationId(tx Tx, name, schema string) (*int, error)
+	GetType(tx Tx, id int, schema string) (*string, error)
+	// Get returns the stored codebase with references filled by ids only,
+	// nil if it doesn't exist.
+	Get(tx Tx, name, schema string) (*codebase.Codebase, error)
+	Create(tx Tx, c codebase.Codebase, schema string) (*int, error)
+	// Update sets all the columns derived from custom resource but status.
+	Update(tx Tx, c codebase.Codebase, schema string) error
+	// LogChanges appends changes of the codebase columns to its change log.
+	LogChanges(tx Tx, id int, changes []codebase.Change, schema string) error
+	// AddPreviousLocation records the location the codebase has been moved away from.
+	AddPreviousLocation(tx Tx, id int, l codebase.RepositoryLocation, schema string) error
+	// GetPreviousLocations returns locations the codebase has been moved away
+	// from, the latest go first.
+	GetPreviousLocations(tx Tx, name, schema string) ([]codebase.RepositoryLocation, error)
+	UpdateStatus(tx Tx, id int, status, schema string) error
+	Delete(tx Tx, name, schema string) error
+	DeletePerfDataSources(tx Tx, id int, schema string) error
+	// GetPerfDataSources returns sorted types of data sources the codebase
+	// is linked to.
+	GetPerfDataSources(tx Tx, id int, schema string) ([]string, error)
+	// AddPerfDataSource links the codebase to the data source of the type,
+	// the data source is created if it doesn't exist yet.
+	AddPerfDataSource(tx Tx, id int, dsType, schema string) error
+	RemovePerfDataSource(tx Tx, id int, dsType, schema string) error
+	// UpdatePerfDataSource records configuration of the data source of ds.Type
+	// the codebase is linked to, links themselves follow the codebase only.
+	UpdatePerfDataSource(tx Tx, id int, ds perfdatasource.PerfDataSource, schema string) error
+}
+
+type CodebaseBranchRepository interface {
+	GetId(tx Tx, codebase, branch, schema string) (*int, error)
+	// GetNames returns sorted names of the codebase branches.
+	GetNames(tx Tx, codebase, schema string) ([]string, error)
+	// Get returns the stored codebase branch, nil if it doesn't exist.
+	Get(tx Tx, codebase, branch, schema string) (*codebasebranch.CodebaseBranch, error)
+	Create(tx Tx, b codebasebranch.CodebaseBranch, codebaseId int, streamId *int, schema string) (*int, error)
+	// Update sets all the columns derived from custom resource but status,
+	// the time of the last successful build is set once the build changes.
+	Update(tx Tx, id int, b codebasebranch.CodebaseBranch, schema string) error
+	// AddBuild appends the version and build number to the build history of the branch.
+	AddBuild(tx Tx, id int, b codebasebranch.Build, schema string) error
+	// GetBuilds returns build history of the branch, the latest go first.
+	GetBuilds(tx Tx, codebase, branch, schema string) ([]codebasebranch.Build, error)
+	UpdateStatus(tx Tx, id int, status, schema string) error
+	Delete(tx Tx, codebase, branch, schema string) error
+}
+
+type CDPipelineRepository interface {
+	Get(tx Tx, name, schema string) (*model.CDPipelineDTO, error)
+	Create(tx Tx, p cdpipeline.CDPipeline, schema string) (*model.CDPipelineDTO, error)
+	UpdateStatus(tx Tx, id int, status, schema string) error
+	Delete(tx Tx, name, schema string) error
+	AddThirdPartyService(tx Tx, pipelineId, serviceId int, schema string) error
+	AddDockerStream(tx Tx, pipelineId, streamId int, schema string) error
+	RemoveDockerStreams(tx Tx, pipelineId int, schema string) error
+	AddApplicationToPromote(tx Tx, pipelineId, codebaseId int, schema string) error
+	RemoveApplicationsToPromote(tx Tx, pipelineId int, schema string) error
+}
+
+type StageRepository interface {
+	GetId(tx Tx, pipeline, name, schema string) (*int, error)
+	GetIdByOrder(tx Tx, pipeline string, order int, schema string) (*int, error)
+	GetStages(tx Tx, pipeline, schema string) ([]stage.Stage, error)
+	Create(tx Tx, s stage.Stage, pipelineId int) (*int, error)
+	UpdateStatus(tx Tx, id int, status, schema string) error
+	Delete(tx Tx, pipeline, name, schema string) error
+	CreateQualityGate(tx Tx, gate, stepName string, stageId int, codebaseId, branchId *int, schema string) (*int, error)
+	GetAutotestIds(tx Tx, autotest, branch, schema string) (*model.CodebaseBranchIdDTO, error)
+}
+
+type DockerStreamRepository interface {
+	Create(tx Tx, branchId *int, name, schema string) (*int, error)
+	GetId(tx Tx, name, schema string) (*int, error)
+	GetBranchId(tx Tx, id int, schema string) (*int, error)
+	// GetCodebaseStreams returns docker streams built from branches of the
+	// codebase sorted by name, they are removed along with the branches.
+	GetCodebaseStreams(tx Tx, codebase, schema string) ([]model.CodebaseBranchDockerStreamDTO, error)
+	SetBranchId(tx Tx, id, branchId int, schema string) error
+	Delete(tx Tx, id int, schema string) error
+	// GetPipelineStreams returns input streams of CD pipeline.
+	GetPipelineStreams(tx Tx, pipeline, schema string) ([]model.CodebaseDockerStreamReadDTO, error)
+	// GetStageStreams returns output streams of the stage with the order.
+	GetStageStreams(tx Tx, pipeline string, order int, schema string) ([]model.CodebaseDockerStreamReadDTO, error)
+	// GetSourceStream returns input stream of the codebase in CD pipeline.
+	GetSourceStream(tx Tx, pipeline, codebase, schema string) (*int, error)
+	// GetStageOutputStream returns one of output streams of the stage.
+	GetStageOutputStream(tx Tx, pipeline, stage, schema string) (*int, error)
+	LinkStage(tx Tx, stageId, inputId, outputId int, schema string) error
+	UnlinkStage(tx Tx, stageId int, schema string) ([]int, error)
+	// DeletePipelineStreams removes output streams of all stages of CD pipeline
+	// and returns their ids.
+	DeletePipelineStreams(tx Tx, pipeline, schema string) ([]int, error)
+}
+
+// ActionLogRepository keeps history of the entities. An action log is stored
+// once per entity, so replaying the same status is a no-op. Put methods
+// report whether the action log has been stored.
+type ActionLogRepository interface {
+	PutToCodebase(tx Tx, codebaseId int, al model.ActionLog, schema string) (bool, error)
+	PutToCodebaseBranch(tx Tx, branchId int, al model.ActionLog, schema string) (bool, error)
+	PutToCDPipeline(tx Tx, pipelineId int, al model.ActionLog, schema string) (bool, error)
+	PutToCDStage(tx Tx, stageId int, al model.ActionLog, schema string) (bool, error)
+	// GetCodebaseTimeline returns action logs of the codebase and its branches
+	// matching the filter, the newest go first.
+	GetCodebaseTimeline(tx Tx, codebase string, f model.TimelineFilter, schema string) ([]model.TimelineEntry, error)
+}
+
+// OutboxRepository records changes of the entities to be published. Events
+// are put in the transaction changing the entity, so they are published
+// only if the change is committed.
+type OutboxRepository interface {
+	Put(tx Tx, e model.Event, schema string) error
+}
+
+// ServerRepository keeps servers and other entities codebases and pipelines
+// refer to.
+type ServerRepository interface {
+	GetGitServerId(tx Tx, name, schema string) (*int, error)
+	CreateGitServer(tx Tx, name, hostname string, available bool, schema string) (*int, error)
+	UpdateGitServer(tx Tx, id int, available bool, schema string) error
+	GetJiraServerId(tx Tx, name, schema string) (*int, error)
+	CreateJiraServer(tx Tx, name string, available bool, schema string) error
+	UpdateJiraServer(tx Tx, id int, available bool, schema string) error
+	GetPerfServerId(tx Tx, name, schema string) (*int, error)
+	CreatePerfServer(tx Tx, name string, available bool, schema string) error
+	UpdatePerfServer(tx Tx, id int, available bool, schema string) error
+	GetJenkinsSlaveId(tx Tx, name, schema string) (*int, error)
+	GetJobProvisioningId(tx Tx, name, scope, schema string) (*int, error)
+	GetServiceId(tx Tx, name, schema string) (*int, error)
+}
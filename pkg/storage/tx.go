@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// WithTx runs fn as a unit of work: the transaction is committed if fn
+// succeeds and rolled back if fn fails, panics or ctx is done before commit.
+func WithTx(ctx context.Context, s Storage, fn func(tx Tx) error) error {
+	tx, err := s.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "an error has occurred while opening transaction")
+	}
+	return run(ctx, tx, fn)
+}
+
+func run(ctx context.Context, tx Tx, fn func(tx Tx) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		_ = tx.Rollback()
+		return errors.Wrap(err, "transaction has been cancelled")
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "an error has occurred while committing transaction")
+	}
+	return nil
+}
+
+// Nested runs fn in a savepoint of the transaction: if fn fails only its
+// changes are rolled back, so the caller may handle the error and go on
+// with the transaction. Panics roll back the whole transaction in WithTx.
+func Nested(tx Tx, name string, fn func() error) error {
+	if err := tx.Savepoint(name); err != nil {
+		return errors.Wrapf(err, "couldn't create savepoint %v", name)
+	}
+
+	if err := fn(); err != nil {
+		if rbErr := tx.RollbackTo(name); rbErr != nil {
+			return errors.Wrapf(rbErr, "couldn't roll back to savepoint %v after %v", name, err)
+		}
+		return err
+	}
+	return tx.Release(name)
+}